@@ -0,0 +1,205 @@
+//-----------------------------------------------------------------------------
+/*
+
+Ball Bearings
+
+Dummy bearing bodies for assemblies and pockets for mounting them.
+
+The bearing and its pocket share a coordinate frame: the face of the part is
+on the z = 0 plane and the bearing sits below it, from z = -width to z = 0.
+Subtract the pocket from the part and (optionally) union in the bearing.
+
+*/
+//-----------------------------------------------------------------------------
+
+package obj
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/gmlewis/sdfx/sdf"
+	v3 "github.com/gmlewis/sdfx/vec/v3"
+)
+
+//-----------------------------------------------------------------------------
+
+// Pocket fits are diametral allowances (mm) added to the nominal size of a part.
+// Printed holes tend to come out undersize, adjust to suit the printer being used.
+const (
+	PocketPressFit     = 0.0 // the part is pressed into the pocket
+	PocketClearanceFit = 0.2 // the part slides into the pocket
+)
+
+//-----------------------------------------------------------------------------
+
+// BearingParms stores the parameters that define a ball bearing (mm).
+type BearingParms struct {
+	Name  string  // name of bearing
+	Bore  float64 // inner diameter
+	Outer float64 // outer diameter
+	Width float64 // width of bearing
+}
+
+type bearingDatabase map[string]*BearingParms
+
+var bearingDB = initBearingLookup()
+
+// Add adds a bearing to the database.
+func (m bearingDatabase) Add(name string, bore, outer, width float64) {
+	m[name] = &BearingParms{
+		Name:  name,
+		Bore:  bore,
+		Outer: outer,
+		Width: width,
+	}
+}
+
+// initBearingLookup adds a collection of named bearings to the database.
+func initBearingLookup() bearingDatabase {
+	m := make(bearingDatabase)
+	// miniature
+	m.Add("mr63", 3, 6, 2.5)
+	m.Add("mr85", 5, 8, 2.5)
+	m.Add("mr105", 5, 10, 4)
+	m.Add("mr115", 5, 11, 4)
+	m.Add("mr128", 8, 12, 3.5)
+	// 60 series
+	m.Add("603", 3, 9, 5)
+	m.Add("604", 4, 12, 4)
+	m.Add("605", 5, 14, 5)
+	m.Add("606", 6, 17, 6)
+	m.Add("607", 7, 19, 6)
+	m.Add("608", 8, 22, 7)
+	m.Add("609", 9, 24, 7)
+	// 62 series
+	m.Add("623", 3, 10, 4)
+	m.Add("624", 4, 13, 5)
+	m.Add("625", 5, 16, 5)
+	m.Add("626", 6, 19, 6)
+	m.Add("627", 7, 22, 7)
+	m.Add("629", 9, 26, 8)
+	// 68 series
+	m.Add("688", 8, 16, 5)
+	m.Add("6800", 10, 19, 5)
+	m.Add("6801", 12, 21, 5)
+	m.Add("6802", 15, 24, 5)
+	// 69 series
+	m.Add("699", 9, 20, 6)
+	m.Add("6900", 10, 22, 6)
+	m.Add("6901", 12, 24, 6)
+	m.Add("6902", 15, 28, 7)
+	// 6000 series
+	m.Add("6000", 10, 26, 8)
+	m.Add("6001", 12, 28, 8)
+	m.Add("6002", 15, 32, 9)
+	m.Add("6003", 17, 35, 10)
+	m.Add("6004", 20, 42, 12)
+	m.Add("6005", 25, 47, 12)
+	// 6200 series
+	m.Add("6200", 10, 30, 9)
+	m.Add("6201", 12, 32, 10)
+	m.Add("6202", 15, 35, 11)
+	m.Add("6203", 17, 40, 12)
+	m.Add("6204", 20, 47, 14)
+	m.Add("6205", 25, 52, 15)
+	return m
+}
+
+// BearingLookup returns the parameters for a named bearing (E.g. "608").
+// Seal suffixes (E.g. "zz", "2rs") should be dropped from the name.
+func BearingLookup(name string) (*BearingParms, error) {
+	k, ok := bearingDB[name]
+	if !ok {
+		return nil, fmt.Errorf("bearing \"%s\" not found", name)
+	}
+	return k, nil
+}
+
+// BearingNames returns the sorted names of the bearings in the database.
+func BearingNames() []string {
+	names := make([]string, 0, len(bearingDB))
+	for name := range bearingDB {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validate checks the bearing parameters.
+func (k *BearingParms) validate() error {
+	if k.Bore <= 0 {
+		return sdf.ErrMsg("Bore <= 0")
+	}
+	if k.Outer <= k.Bore {
+		return sdf.ErrMsg("Outer <= Bore")
+	}
+	if k.Width <= 0 {
+		return sdf.ErrMsg("Width <= 0")
+	}
+	return nil
+}
+
+// shoulderRadius returns a radius between the inner and outer races.
+func (k *BearingParms) shoulderRadius() float64 {
+	return 0.25 * (k.Bore + k.Outer)
+}
+
+//-----------------------------------------------------------------------------
+
+// Bearing3D returns a dummy body for a ball bearing.
+func Bearing3D(k *BearingParms) (sdf.SDF3, error) {
+	if err := k.validate(); err != nil {
+		return nil, err
+	}
+	body, err := Pipe3D(0.5*k.Outer, 0.5*k.Bore, k.Width)
+	if err != nil {
+		return nil, err
+	}
+	// recess the seals between the inner and outer races
+	t := 0.25 * (k.Outer - k.Bore)
+	seal, err := Pipe3D(k.shoulderRadius()+0.25*t, k.shoulderRadius()-0.25*t, 1.1*k.Width)
+	if err != nil {
+		return nil, err
+	}
+	core, err := sdf.Cylinder3D(0.9*k.Width, 0.5*k.Outer, 0)
+	if err != nil {
+		return nil, err
+	}
+	body = sdf.Difference3D(body, sdf.Difference3D(seal, core))
+	return sdf.Transform3D(body, sdf.Translate3d(v3.Vec{X: 0, Y: 0, Z: -0.5 * k.Width})), nil
+}
+
+// BearingPocket3D returns a pocket for mounting a ball bearing.
+// A relief below the bearing keeps the inner race clear of the part.
+func BearingPocket3D(
+	k *BearingParms, // bearing parameters
+	fit float64, // diametral allowance (E.g. PocketPressFit, PocketClearanceFit)
+	relief float64, // depth of the relief below the bearing (0 for none)
+) (sdf.SDF3, error) {
+	if err := k.validate(); err != nil {
+		return nil, err
+	}
+	if relief < 0 {
+		return nil, sdf.ErrMsg("relief < 0")
+	}
+	// extend the pocket above the face of the part to avoid coincident surfaces
+	ext := 0.1 * k.Width
+	h := k.Width + ext
+	seat, err := sdf.Cylinder3D(h, 0.5*(k.Outer+fit), 0)
+	if err != nil {
+		return nil, err
+	}
+	seat = sdf.Transform3D(seat, sdf.Translate3d(v3.Vec{X: 0, Y: 0, Z: 0.5*ext - 0.5*k.Width}))
+	if relief == 0 {
+		return seat, nil
+	}
+	r, err := sdf.Cylinder3D(relief+ext, k.shoulderRadius(), 0)
+	if err != nil {
+		return nil, err
+	}
+	r = sdf.Transform3D(r, sdf.Translate3d(v3.Vec{X: 0, Y: 0, Z: -k.Width - 0.5*(relief-ext)}))
+	return sdf.Union3D(seat, r), nil
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,92 @@
+//-----------------------------------------------------------------------------
+/*
+
+Ball Bearing Tests
+
+*/
+//-----------------------------------------------------------------------------
+
+package obj
+
+import (
+	"math"
+	"testing"
+
+	v3 "github.com/gmlewis/sdfx/vec/v3"
+)
+
+//-----------------------------------------------------------------------------
+
+func Test_Bearing3D_Errors(t *testing.T) {
+	if _, err := BearingLookup("608zz"); err == nil {
+		t.Error("unknown bearing: expected an error")
+	}
+	tests := []struct {
+		name string
+		k    BearingParms
+	}{
+		{"Bore", BearingParms{Bore: 0, Outer: 22, Width: 7}},
+		{"Outer", BearingParms{Bore: 8, Outer: 8, Width: 7}},
+		{"Width", BearingParms{Bore: 8, Outer: 22, Width: 0}},
+	}
+	for _, test := range tests {
+		if _, err := Bearing3D(&test.k); err == nil {
+			t.Errorf("%s: expected a bearing error", test.name)
+		}
+		if _, err := BearingPocket3D(&test.k, PocketPressFit, 0); err == nil {
+			t.Errorf("%s: expected a pocket error", test.name)
+		}
+	}
+	k, err := BearingLookup("608")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := BearingPocket3D(k, PocketPressFit, -1); err == nil {
+		t.Error("relief: expected an error")
+	}
+}
+
+func Test_Bearing3D(t *testing.T) {
+	k, err := BearingLookup("608")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k.Bore != 8 || k.Outer != 22 || k.Width != 7 {
+		t.Errorf("608: %v", k)
+	}
+	s, err := Bearing3D(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the bearing sits below the face of the part
+	bb := s.BoundingBox()
+	want := v3.Vec{X: 11, Y: 11, Z: 0}
+	if !bb.Min.Equals(want.Neg().Add(v3.Vec{Z: -7}), 1e-9) || !bb.Max.Equals(want, 1e-9) {
+		t.Errorf("bounding box %v", bb)
+	}
+	// the bore is clear and the races are solid
+	for _, z := range []float64{-6.9, -3.5, -0.1} {
+		if d := s.Evaluate(v3.Vec{X: 3.9, Y: 0, Z: z}); d <= 0 {
+			t.Errorf("bore at z = %f: %f", z, d)
+		}
+		if d := s.Evaluate(v3.Vec{X: 0, Y: 4.1, Z: z}); d >= 0 {
+			t.Errorf("inner race at z = %f: %f", z, d)
+		}
+		if d := s.Evaluate(v3.Vec{X: -10.9, Y: 0, Z: z}); d >= 0 {
+			t.Errorf("outer race at z = %f: %f", z, d)
+		}
+	}
+
+	// the pocket fits around the bearing with the diametral allowance
+	pocket, err := BearingPocket3D(k, PocketClearanceFit, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bb = pocket.BoundingBox()
+	r := 0.5 * (k.Outer + PocketClearanceFit)
+	if !bb.Contains(s.BoundingBox().Min) || math.Abs(bb.Max.X-r) > 1e-9 || math.Abs(bb.Min.Z+k.Width+1) > 1e-9 {
+		t.Errorf("pocket bounding box %v", bb)
+	}
+}
+
+//-----------------------------------------------------------------------------
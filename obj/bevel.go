@@ -0,0 +1,177 @@
+//-----------------------------------------------------------------------------
+/*
+
+Straight Bevel Gears
+
+The teeth are formed on a pitch cone and taper towards the cone apex.
+The tooth profile uses the Tredgold approximation: the back cone is developed
+into a plane to give a virtual spur gear with N/cos(pitch angle) teeth and the
+involute profile of that virtual gear is used for the teeth.
+
+*/
+//-----------------------------------------------------------------------------
+
+package obj
+
+import (
+	"math"
+
+	"github.com/gmlewis/sdfx/sdf"
+	v2 "github.com/gmlewis/sdfx/vec/v2"
+	v3 "github.com/gmlewis/sdfx/vec/v3"
+)
+
+//-----------------------------------------------------------------------------
+
+// BevelGearParms defines the parameters for a straight bevel gear.
+// The gear and its mating gear have shafts at 90 degrees.
+type BevelGearParms struct {
+	NumberTeeth   int     // number of gear teeth
+	MatingTeeth   int     // number of teeth on the mating gear
+	Module        float64 // pitch circle diameter / number of gear teeth (at the large end of the teeth)
+	PressureAngle float64 // gear pressure angle (radians)
+	FaceWidth     float64 // length of the teeth along the pitch cone
+	Backlash      float64 // backlash expressed as per-tooth distance at pitch circumference
+	Clearance     float64 // additional root clearance
+	Facets        int     // number of facets for involute flank
+}
+
+// PitchAngle returns the half angle of the pitch cone.
+func (k *BevelGearParms) PitchAngle() float64 {
+	return math.Atan2(float64(k.NumberTeeth), float64(k.MatingTeeth))
+}
+
+// ConeDistance returns the distance from the cone apex to the pitch circle.
+func (k *BevelGearParms) ConeDistance() float64 {
+	return 0.5 * k.Module * math.Hypot(float64(k.NumberTeeth), float64(k.MatingTeeth))
+}
+
+// bevelSDF3 is a straight bevel gear.
+type bevelSDF3 struct {
+	tooth      sdf.SDF2 // virtual spur gear tooth
+	n          int      // number of teeth
+	nv         float64  // number of teeth on the virtual spur gear
+	rootRadius float64  // root radius of the virtual spur gear
+	sin, cos   float64  // pitch cone angle
+	sinA, cosA float64  // face cone angle
+	apex       float64  // z position of the cone apex
+	a          float64  // cone distance
+	f          float64  // face width
+	back       float64  // z position of the back face
+	outer      float64  // radius of the tooth tips at the back cone
+	k          float64  // lipschitz factor of the back cone mapping
+	bb         sdf.Box3 // bounding box
+}
+
+// BevelGear3D returns a straight bevel gear.
+// The axis of the gear is the z-axis with the cone apex above the gear on the +z axis.
+// The pitch circle at the large end of the teeth is on the z = 0 plane.
+func BevelGear3D(k *BevelGearParms) (sdf.SDF3, error) {
+	if k.NumberTeeth <= 0 {
+		return nil, sdf.ErrMsg("NumberTeeth <= 0")
+	}
+	if k.MatingTeeth <= 0 {
+		return nil, sdf.ErrMsg("MatingTeeth <= 0")
+	}
+	if k.Module <= 0 {
+		return nil, sdf.ErrMsg("Module <= 0")
+	}
+	if k.PressureAngle <= 0 {
+		return nil, sdf.ErrMsg("PressureAngle <= 0")
+	}
+	if k.FaceWidth <= 0 {
+		return nil, sdf.ErrMsg("FaceWidth <= 0")
+	}
+	if k.Backlash < 0 {
+		return nil, sdf.ErrMsg("Backlash < 0")
+	}
+	if k.Clearance < 0 {
+		return nil, sdf.ErrMsg("Clearance < 0")
+	}
+	if k.Facets <= 0 {
+		return nil, sdf.ErrMsg("Facets <= 0")
+	}
+
+	s := bevelSDF3{}
+	delta := k.PitchAngle()
+	s.sin, s.cos = math.Sincos(delta)
+	s.a = k.ConeDistance()
+	s.f = k.FaceWidth
+	if s.f >= 0.5*s.a {
+		return nil, sdf.ErrMsg("FaceWidth >= half the cone distance")
+	}
+	addendum := k.Module
+	dedendum := k.Module + k.Clearance
+
+	// the virtual spur gear on the developed back cone
+	s.n = k.NumberTeeth
+	s.nv = float64(k.NumberTeeth) / s.cos
+	rv := 0.5 * s.nv * k.Module
+	s.rootRadius = rv - dedendum
+	baseRadius := rv * math.Cos(k.PressureAngle)
+	var err error
+	s.tooth, err = involuteGearTooth(s.nv, k.Module, s.rootRadius, baseRadius, rv+addendum, k.Backlash, k.Facets)
+	if err != nil {
+		return nil, err
+	}
+
+	// face (tip) and root cone angles
+	deltaA := delta + math.Atan(addendum/s.a)
+	deltaF := delta - math.Atan(dedendum/s.a)
+	s.sinA, s.cosA = math.Sincos(deltaA)
+
+	pitchRadius := 0.5 * float64(k.NumberTeeth) * k.Module
+	s.apex = pitchRadius * s.cos / s.sin
+	s.back = -dedendum * s.sin
+	s.outer = pitchRadius + addendum*s.cos
+	// The mapping to the back cone scales distances by up to a/(a-f) and the rays from
+	// the apex meet the back cone at up to the face/root angle from the pitch cone.
+	gamma := math.Max(deltaA-delta, delta-deltaF)
+	s.k = s.a / ((s.a - s.f) * math.Cos(gamma))
+
+	// the tooth tips at the front of the gear are the highest point
+	top := s.apex - (s.apex-addendum*s.sin)*(s.a-s.f)/s.a
+	s.bb = sdf.Box3{
+		Min: v3.Vec{X: -s.outer, Y: -s.outer, Z: s.back},
+		Max: v3.Vec{X: s.outer, Y: s.outer, Z: top},
+	}
+	return &s, nil
+}
+
+// Evaluate returns the minimum distance to a bevel gear.
+func (s *bevelSDF3) Evaluate(p v3.Vec) float64 {
+	rho := math.Sqrt(p.X*p.X + p.Y*p.Y)
+	z := s.apex - p.Z // distance below the apex
+	// distance along the pitch cone from the apex
+	t := rho*s.sin + z*s.cos
+	// between the back and front cones, above the back face and inside the face cone
+	d := math.Max(t-s.a, s.a-s.f-t)
+	d = math.Max(d, s.back-p.Z)
+	d = math.Max(d, rho*s.cosA-z*s.sinA)
+
+	// Project the point along the ray from the apex onto the back cone,
+	// then develop the back cone into the plane of the virtual spur gear.
+	tc := sdf.Clamp(t, s.a-s.f, s.a)
+	rb := math.Min(rho*s.a/tc, s.outer)
+	theta := math.Atan2(p.Y, p.X)
+	// reduce the angle to the nearest tooth
+	pitch := sdf.Tau / float64(s.n)
+	theta -= pitch * math.Round(theta/pitch)
+	phi := theta * s.cos
+	r := rb / s.cos
+	// the tooth and its neighbours
+	dt := r - s.rootRadius
+	pitchV := sdf.Tau / s.nv
+	for i := -1; i <= 1; i++ {
+		a := phi + float64(i)*pitchV
+		dt = math.Min(dt, s.tooth.Evaluate(v2.Vec{X: r * math.Cos(a), Y: r * math.Sin(a)}))
+	}
+	return math.Max(d, dt/s.k)
+}
+
+// BoundingBox returns the bounding box of a bevel gear.
+func (s *bevelSDF3) BoundingBox() sdf.Box3 {
+	return s.bb
+}
+
+//-----------------------------------------------------------------------------
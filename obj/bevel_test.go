@@ -0,0 +1,97 @@
+//-----------------------------------------------------------------------------
+/*
+
+Straight Bevel Gear Tests
+
+*/
+//-----------------------------------------------------------------------------
+
+package obj
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gmlewis/sdfx/sdf"
+	v3 "github.com/gmlewis/sdfx/vec/v3"
+)
+
+//-----------------------------------------------------------------------------
+
+func bevelGearParms() BevelGearParms {
+	return BevelGearParms{
+		NumberTeeth:   20,
+		MatingTeeth:   40,
+		Module:        2,
+		PressureAngle: sdf.DtoR(20),
+		FaceWidth:     10,
+		Facets:        5,
+	}
+}
+
+func Test_BevelGear3D_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		set  func(k *BevelGearParms)
+	}{
+		{"NumberTeeth", func(k *BevelGearParms) { k.NumberTeeth = 0 }},
+		{"MatingTeeth", func(k *BevelGearParms) { k.MatingTeeth = -1 }},
+		{"Module", func(k *BevelGearParms) { k.Module = 0 }},
+		{"PressureAngle", func(k *BevelGearParms) { k.PressureAngle = 0 }},
+		{"FaceWidth", func(k *BevelGearParms) { k.FaceWidth = 0 }},
+		{"FaceWidth too large", func(k *BevelGearParms) { k.FaceWidth = 30 }},
+		{"Backlash", func(k *BevelGearParms) { k.Backlash = -0.1 }},
+		{"Clearance", func(k *BevelGearParms) { k.Clearance = -0.1 }},
+		{"Facets", func(k *BevelGearParms) { k.Facets = 0 }},
+	}
+	for _, test := range tests {
+		k := bevelGearParms()
+		test.set(&k)
+		if _, err := BevelGear3D(&k); err == nil {
+			t.Errorf("%s: expected an error", test.name)
+		}
+	}
+}
+
+func Test_BevelGear3D(t *testing.T) {
+	k := bevelGearParms()
+	s, err := BevelGear3D(&k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// pitch cone for a 1:2 ratio
+	delta := k.PitchAngle()
+	if math.Abs(math.Tan(delta)-0.5) > 1e-12 {
+		t.Errorf("pitch angle %f", sdf.RtoD(delta))
+	}
+	a := k.ConeDistance()
+	if math.Abs(a-2*math.Sqrt(500)) > 1e-12 {
+		t.Errorf("cone distance %f", a)
+	}
+	// Along the pitch cone the teeth are centered on the x-axis and the
+	// gaps are half a tooth pitch away.
+	apex := 20 / math.Tan(delta)
+	pitch := sdf.Tau / float64(k.NumberTeeth)
+	for _, l := range []float64{a - 0.1*k.FaceWidth, a - 0.5*k.FaceWidth, a - 0.9*k.FaceWidth} {
+		r := l * math.Sin(delta)
+		z := apex - l*math.Cos(delta)
+		for i := 0; i < k.NumberTeeth; i++ {
+			theta := float64(i) * pitch
+			tooth := v3.Vec{X: r * math.Cos(theta), Y: r * math.Sin(theta), Z: z}
+			if d := s.Evaluate(tooth); d >= 0 {
+				t.Errorf("tooth %d at %f: %f", i, l, d)
+			}
+			gap := v3.Vec{X: r * math.Cos(theta+0.5*pitch), Y: r * math.Sin(theta+0.5*pitch), Z: z}
+			if d := s.Evaluate(gap); d <= 0 {
+				t.Errorf("gap %d at %f: %f", i, l, d)
+			}
+		}
+	}
+	// the back face is below the pitch circle and the tips are outside it
+	bb := s.BoundingBox()
+	if bb.Min.Z >= 0 || bb.Max.X <= 20 || bb.Max.X >= 20+k.Module {
+		t.Errorf("bounding box %v", bb)
+	}
+}
+
+//-----------------------------------------------------------------------------
@@ -10,6 +10,7 @@ package obj
 
 import (
 	"fmt"
+	"math"
 
 	"github.com/gmlewis/sdfx/sdf"
 	v3 "github.com/gmlewis/sdfx/vec/v3"
@@ -21,12 +22,18 @@ import (
 type BoltParms struct {
 	Thread      string  // name of thread
 	Style       string  // head style "hex" or "knurl"
-	Tolerance   float64 // subtract from external thread radius
+	Class       string  // thread tolerance class (E.g. "6g", "2A"), "" for the nominal thread
+	Tolerance   float64 // subtract from external thread radius (after the Class offset)
 	TotalLength float64 // threaded length + shank length
 	ShankLength float64 // non threaded length
+	Starts      int     // number of thread starts (0 for a single start, < 0 for left hand threads)
+	Chamfer     float64 // lead-in chamfer length at the end of the thread (0 for the default, < 0 for none)
+	Runout      float64 // length of the thread run-out into the shank
 }
 
 // Bolt returns a simple bolt suitable for 3d printing.
+// The thread radius is the radius for the tolerance class less the tolerance,
+// so Class gives a standard fit and Tolerance adds clearance for the printer.
 func Bolt(k *BoltParms) (sdf.SDF3, error) {
 	// validate parameters
 	t, err := sdf.ThreadLookup(k.Thread)
@@ -42,6 +49,9 @@ func Bolt(k *BoltParms) (sdf.SDF3, error) {
 	if k.Tolerance < 0 {
 		return nil, sdf.ErrMsg("Tolerance < 0")
 	}
+	if k.Runout < 0 {
+		return nil, sdf.ErrMsg("Runout < 0")
+	}
 
 	// head
 	var head sdf.SDF3
@@ -75,18 +85,24 @@ func Bolt(k *BoltParms) (sdf.SDF3, error) {
 	}
 	var thread sdf.SDF3
 	if threadLength != 0 {
-		r := t.Radius - k.Tolerance
+		r, err := t.ThreadRadius(true, k.Class)
+		if err != nil {
+			return nil, err
+		}
+		r -= k.Tolerance
 		threadOffset := threadLength/2 + shankLength
-		isoThread, err := sdf.ISOThread(r, t.Pitch, true)
+		threadProfile, err := t.ThreadProfile(r, true)
 		if err != nil {
 			return nil, err
 		}
-		thread, err = sdf.Screw3D(isoThread, threadLength, t.Taper, t.Pitch, 1)
+		thread, err = sdf.Screw3D(threadProfile, threadLength, t.Taper, t.Pitch, threadStarts(k.Starts))
 		if err != nil {
 			return nil, err
 		}
-		// chamfer the thread
-		thread, err = ChamferedCylinder(thread, 0, 0.5)
+		// run-out into the shank, chamfer the end
+		bottom := sdf.ScrewEnd{Runout: k.Runout}
+		top := sdf.ScrewEnd{Chamfer: threadChamfer(k.Chamfer, r)}
+		thread, err = sdf.ScrewEnds3D(thread, true, bottom, top)
 		if err != nil {
 			return nil, err
 		}
@@ -96,4 +112,21 @@ func Bolt(k *BoltParms) (sdf.SDF3, error) {
 	return sdf.Union3D(head, shank, thread), nil
 }
 
+// threadStarts returns the number of thread starts (0 is a single start).
+func threadStarts(starts int) int {
+	if starts == 0 {
+		return 1
+	}
+	return starts
+}
+
+// threadChamfer returns the lead-in chamfer length (0 is the default, < 0 is none).
+// The default is the chamfer of ChamferedCylinder(thread, 0, 0.5).
+func threadChamfer(chamfer, radius float64) float64 {
+	if chamfer == 0 {
+		return 0.5 * radius * math.Sqrt(0.5)
+	}
+	return math.Max(chamfer, 0)
+}
+
 //-----------------------------------------------------------------------------
@@ -0,0 +1,239 @@
+//-----------------------------------------------------------------------------
+/*
+
+Bolt and Nut Tests
+
+*/
+//-----------------------------------------------------------------------------
+
+package obj
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gmlewis/sdfx/sdf"
+	v3 "github.com/gmlewis/sdfx/vec/v3"
+)
+
+//-----------------------------------------------------------------------------
+
+// surfaceRadius returns the radius where a ray from the axis at height z crosses the surface.
+func surfaceRadius(s sdf.SDF3, z float64) float64 {
+	return rayRadius(s, 0, z)
+}
+
+// rayRadius returns the radius where a ray from the axis at angle theta and height z crosses the surface.
+func rayRadius(s sdf.SDF3, theta, z float64) float64 {
+	u := v3.Vec{math.Cos(theta), math.Sin(theta), 0}
+	at := func(r float64) v3.Vec { return u.MulScalar(r).Add(v3.Vec{0, 0, z}) }
+	r0, r1 := 0.0, 6.0
+	inside := s.Evaluate(at(r0)) < 0
+	for i := 0; i < 50; i++ {
+		r := 0.5 * (r0 + r1)
+		if (s.Evaluate(at(r)) < 0) == inside {
+			r0 = r
+		} else {
+			r1 = r
+		}
+	}
+	return r0
+}
+
+// boltRadius returns the major radius of an external thread between z0 and z1.
+func boltRadius(s sdf.SDF3, z0, z1 float64) float64 {
+	r := 0.0
+	for z := z0; z <= z1; z += 0.01 {
+		r = math.Max(r, surfaceRadius(s, z))
+	}
+	return r
+}
+
+// nutRadius returns the minor radius of an internal thread between z0 and z1.
+func nutRadius(s sdf.SDF3, z0, z1 float64) float64 {
+	r := math.MaxFloat64
+	for z := z0; z <= z1; z += 0.01 {
+		r = math.Min(r, surfaceRadius(s, z))
+	}
+	return r
+}
+
+func Test_BoltClass(t *testing.T) {
+	tp, err := sdf.ThreadLookup("M10x1.5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ofs, err := tp.ToleranceOffset("6g")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the middle of the thread, clear of the run-out and chamfer
+	z0 := 5 + 0.5*tp.HexHeight() + 4
+	tests := []struct {
+		class string
+		tol   float64
+		r     float64
+	}{
+		{"", 0, 5},
+		{"", 0.1, 4.9},
+		{"6g", 0, 5 + ofs},
+		{"6g", 0.1, 5 + ofs - 0.1},
+	}
+	for _, test := range tests {
+		k := BoltParms{
+			Thread:      "M10x1.5",
+			Style:       "hex",
+			Class:       test.class,
+			Tolerance:   test.tol,
+			TotalLength: 20,
+			ShankLength: 5,
+		}
+		s, err := Bolt(&k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r := boltRadius(s, z0, z0+6); math.Abs(r-test.r) > 1e-6 {
+			t.Errorf("class %q tolerance %g: radius %f (expected) %f (actual)", test.class, test.tol, test.r, r)
+		}
+	}
+	k := BoltParms{Thread: "M10x1.5", Style: "hex", Class: "6H", TotalLength: 20}
+	if _, err := Bolt(&k); err == nil {
+		t.Error("internal class: expected an error")
+	}
+}
+
+// maxRadius returns the largest surface radius at height z.
+func maxRadius(s sdf.SDF3, z float64) float64 {
+	r := 0.0
+	for i := 0; i < 360; i++ {
+		r = math.Max(r, rayRadius(s, sdf.DtoR(float64(i)), z))
+	}
+	return r
+}
+
+func Test_BoltEnds(t *testing.T) {
+	tp, err := sdf.ThreadLookup("M10x1.5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	shank := 5 + 0.5*tp.HexHeight()
+	top := shank + 15
+	// The default chamfer is the 45 degree chamfer of ChamferedCylinder(thread, 0, 0.5).
+	// The thread radius on the chamfer cone increases by the distance from the end.
+	tests := []struct {
+		chamfer float64
+		length  float64 // axial length of the chamfer
+	}{
+		{0, 0.5 * 5 * math.Sqrt(0.5)},
+		{1, 1},
+		{-1, 0},
+	}
+	for _, test := range tests {
+		k := BoltParms{Thread: "M10x1.5", Style: "hex", TotalLength: 20, ShankLength: 5, Chamfer: test.chamfer}
+		s, err := Bolt(&k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, e := range []float64{0.1, 0.3, 0.6} {
+			r := maxRadius(s, top-e)
+			expect := math.Min(5, 5-test.length+e)
+			if math.Abs(r-expect) > 1e-3 {
+				t.Errorf("chamfer %g at %g from the end: radius %f (expected) %f (actual)", test.chamfer, e, expect, r)
+			}
+		}
+	}
+	// the run-out rises from the thread root to the shank radius
+	k := BoltParms{Thread: "M10x1.5", Style: "hex", TotalLength: 20, ShankLength: 5, Runout: 3}
+	s, err := Bolt(&k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	minRadius := func(z float64) float64 {
+		r := math.MaxFloat64
+		for i := 0; i < 360; i++ {
+			r = math.Min(r, rayRadius(s, sdf.DtoR(float64(i)), z))
+		}
+		return r
+	}
+	if r := minRadius(shank + 0.01); math.Abs(r-5) > 0.01 {
+		t.Errorf("run-out start: radius 5 (expected) %f (actual)", r)
+	}
+	// the run-out radius falls linearly, over the run-out length, by about the thread depth
+	depth := 5 - minRadius(shank+4)
+	r1, r2 := minRadius(shank+1), minRadius(shank+2)
+	h := 3 * (5 - r1)
+	if math.Abs(r2-(5-2*h/3)) > 1e-3 {
+		t.Errorf("run-out: radius %f (expected) %f (actual)", 5-2*h/3, r2)
+	}
+	if h < depth || h > depth+tp.Pitch/16 {
+		t.Errorf("run-out: depth %f (expected) %f (actual)", depth, h)
+	}
+}
+
+func Test_BoltStarts(t *testing.T) {
+	tp, err := sdf.ThreadLookup("M10x1.5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	z0 := 5 + 0.5*tp.HexHeight() + 5
+	for _, starts := range []int{1, 2, 3, -2} {
+		k := BoltParms{Thread: "M10x1.5", Style: "hex", TotalLength: 20, ShankLength: 5, Starts: starts}
+		s, err := Bolt(&k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The lead is starts * pitch. A quarter turn moves the thread by a quarter of the lead.
+		lead := float64(starts) * tp.Pitch
+		for z := z0; z < z0+tp.Pitch; z += 0.05 {
+			r0 := rayRadius(s, 0.5*math.Pi, z)
+			r1 := rayRadius(s, 0, z-0.25*lead)
+			if math.Abs(r0-r1) > 1e-6 {
+				t.Errorf("starts %d at %g: radius %f (quarter turn) %f (quarter lead)", starts, z, r0, r1)
+			}
+		}
+	}
+}
+
+func Test_NutClass(t *testing.T) {
+	tp, err := sdf.ThreadLookup("M10x1.5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ofs, err := tp.ToleranceOffset("6H")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := 0.25 * tp.HexHeight()
+	k := NutParms{Thread: "M10x1.5", Style: "hex"}
+	s, err := Nut(&k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the nominal thread
+	r0 := nutRadius(s, -h, h)
+	tests := []struct {
+		class string
+		tol   float64
+		ofs   float64
+	}{
+		{"", 0.1, 0.1},
+		{"6H", 0, ofs},
+		{"6H", 0.1, ofs + 0.1},
+	}
+	for _, test := range tests {
+		k := NutParms{Thread: "M10x1.5", Style: "hex", Class: test.class, Tolerance: test.tol}
+		s, err := Nut(&k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r := nutRadius(s, -h, h); math.Abs(r-r0-test.ofs) > 1e-6 {
+			t.Errorf("class %q tolerance %g: radius %f (expected) %f (actual)", test.class, test.tol, r0+test.ofs, r)
+		}
+	}
+	k = NutParms{Thread: "M10x1.5", Style: "hex", Class: "6g"}
+	if _, err := Nut(&k); err == nil {
+		t.Error("external class: expected an error")
+	}
+}
+
+//-----------------------------------------------------------------------------
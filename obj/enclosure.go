@@ -0,0 +1,231 @@
+//-----------------------------------------------------------------------------
+/*
+
+PCB Enclosures
+
+Build an enclosure around a PCB outline with standoffs at the mounting holes,
+cutouts in the side walls for connectors and an optional vented lid.
+
+The parts are returned in their assembled positions:
+
+The PCB outline is in the x/y plane and the bottom of the base is at z = 0.
+The base has a floor and walls, the standoffs sit on the floor and the PCB
+sits on the standoffs. The lid is a plate on top of the walls with a lip
+that fits inside them.
+
+*/
+//-----------------------------------------------------------------------------
+
+package obj
+
+import (
+	"math"
+
+	"github.com/gmlewis/sdfx/sdf"
+	v2 "github.com/gmlewis/sdfx/vec/v2"
+	v2i "github.com/gmlewis/sdfx/vec/v2i"
+	v3 "github.com/gmlewis/sdfx/vec/v3"
+)
+
+//-----------------------------------------------------------------------------
+
+// EnclosureCutout defines a cutout in a side wall of an enclosure.
+// The profile is in wall coordinates: x is along the wall (the enclosure x-axis
+// for the front/back walls, the y-axis for the left/right walls) and y is the
+// height above the top surface of the PCB.
+type EnclosureCutout struct {
+	Side    string   // wall "front" (-y), "back" (+y), "left" (-x) or "right" (+x)
+	Profile sdf.SDF2 // cutout profile
+}
+
+// EnclosureVentParms defines the parameters for a pattern of vent slots in the lid.
+type EnclosureVentParms struct {
+	Slot   v2.Vec  // size of a vent slot
+	Pitch  v2.Vec  // center to center distance between slots
+	Margin float64 // minimum distance from the vents to the inside of the walls
+}
+
+// EnclosureParms defines the parameters for a PCB enclosure.
+type EnclosureParms struct {
+	PCB          sdf.SDF2            // PCB outline
+	PCBThickness float64             // PCB thickness
+	Holes        []v2.Vec            // PCB mounting hole positions
+	Standoff     StandoffParms       // standoff parameters (PillarHeight is the gap under the PCB)
+	Clearance    float64             // gap between the PCB outline and the inside of the walls
+	Wall         float64             // thickness of the walls, floor and lid
+	Height       float64             // inside height above the top surface of the PCB
+	LidDepth     float64             // depth of the lid lip inside the walls (0 for no lip)
+	LidClearance float64             // gap between the lid lip and the walls
+	Cutouts      []EnclosureCutout   // side wall cutouts
+	Vent         *EnclosureVentParms // lid vents (nil for no vents)
+}
+
+// Enclosure is the set of parts for a PCB enclosure.
+type Enclosure struct {
+	Base      sdf.SDF3   // floor and walls with the side wall cutouts
+	Lid       sdf.SDF3   // lid with the vents cut out
+	Standoffs []sdf.SDF3 // standoffs at the PCB mounting holes, union with the base for a one piece print
+	Vents     sdf.SDF3   // vent pattern cut from the lid (nil for no vents)
+	PCBTop    float64    // z position of the top surface of the PCB
+}
+
+// enclosureCutout3D returns a side wall cutout in enclosure coordinates.
+func enclosureCutout3D(
+	c *EnclosureCutout, // cutout
+	outer sdf.Box2, // bounding box of the outside of the walls
+	depth float64, // depth of the cut into the enclosure from the outside of the walls
+	z float64, // z position of the top surface of the PCB
+) (sdf.SDF3, error) {
+	if c.Profile == nil {
+		return nil, sdf.ErrMsg("cutout profile is nil")
+	}
+	// cut from outside the wall to the given depth inside it
+	s := sdf.Extrude3D(c.Profile, 2*depth)
+	// profile y is enclosure z, extrusion is along -y
+	m := sdf.RotateX(0.5 * sdf.Pi)
+	switch c.Side {
+	case "front":
+		m = sdf.Translate3d(v3.Vec{X: 0, Y: outer.Min.Y, Z: z}).Mul(m)
+	case "back":
+		m = sdf.Translate3d(v3.Vec{X: 0, Y: outer.Max.Y, Z: z}).Mul(m)
+	case "left":
+		m = sdf.Translate3d(v3.Vec{X: outer.Min.X, Y: 0, Z: z}).Mul(sdf.RotateZ(0.5 * sdf.Pi)).Mul(m)
+	case "right":
+		m = sdf.Translate3d(v3.Vec{X: outer.Max.X, Y: 0, Z: z}).Mul(sdf.RotateZ(0.5 * sdf.Pi)).Mul(m)
+	default:
+		return nil, sdf.ErrMsg("invalid cutout side")
+	}
+	return sdf.Transform3D(s, m), nil
+}
+
+// enclosureVents2D returns a pattern of vent slots within a region.
+func enclosureVents2D(k *EnclosureVentParms, region sdf.SDF2) (sdf.SDF2, error) {
+	if k.Slot.X <= 0 || k.Slot.Y <= 0 {
+		return nil, sdf.ErrMsg("invalid vent slot size")
+	}
+	if k.Pitch.X < k.Slot.X || k.Pitch.Y < k.Slot.Y {
+		return nil, sdf.ErrMsg("vent pitch is less than the slot size")
+	}
+	if k.Margin < 0 {
+		return nil, sdf.ErrMsg("vent margin < 0")
+	}
+	region = sdf.Offset2D(region, -k.Margin)
+	bb := region.BoundingBox()
+	size := bb.Size()
+	num := v2i.Vec{
+		X: int(math.Floor((size.X-k.Slot.X)/k.Pitch.X)) + 1,
+		Y: int(math.Floor((size.Y-k.Slot.Y)/k.Pitch.Y)) + 1,
+	}
+	if num.X <= 0 || num.Y <= 0 {
+		return nil, sdf.ErrMsg("no room for the vents")
+	}
+	slot := sdf.Box2D(k.Slot, 0.5*math.Min(k.Slot.X, k.Slot.Y))
+	vents := sdf.Array2D(slot, num, k.Pitch)
+	// center the slots on the region
+	ofs := bb.Center().Sub(vents.BoundingBox().Center())
+	vents = sdf.Transform2D(vents, sdf.Translate2d(ofs))
+	return sdf.Intersect2D(vents, region), nil
+}
+
+// Enclosure3D returns the parts for a PCB enclosure.
+func Enclosure3D(k *EnclosureParms) (*Enclosure, error) {
+	if k.PCB == nil {
+		return nil, sdf.ErrMsg("PCB outline is nil")
+	}
+	if k.PCBThickness <= 0 {
+		return nil, sdf.ErrMsg("PCBThickness <= 0")
+	}
+	if k.Standoff.PillarHeight <= 0 {
+		return nil, sdf.ErrMsg("Standoff.PillarHeight <= 0")
+	}
+	if k.Clearance < 0 {
+		return nil, sdf.ErrMsg("Clearance < 0")
+	}
+	if k.Wall <= 0 {
+		return nil, sdf.ErrMsg("Wall <= 0")
+	}
+	if k.Height <= 0 {
+		return nil, sdf.ErrMsg("Height <= 0")
+	}
+	if k.LidDepth < 0 {
+		return nil, sdf.ErrMsg("LidDepth < 0")
+	}
+	if k.LidDepth >= k.Height {
+		return nil, sdf.ErrMsg("LidDepth >= Height")
+	}
+	if k.LidClearance < 0 {
+		return nil, sdf.ErrMsg("LidClearance < 0")
+	}
+	for _, h := range k.Holes {
+		if k.PCB.Evaluate(h) >= 0 {
+			return nil, sdf.ErrMsg("mounting hole is outside the PCB outline")
+		}
+	}
+
+	e := Enclosure{}
+	inner := sdf.Offset2D(k.PCB, k.Clearance)
+	outer := sdf.Offset2D(k.PCB, k.Clearance+k.Wall)
+
+	// z levels
+	zFloor := k.Wall
+	e.PCBTop = zFloor + k.Standoff.PillarHeight + k.PCBThickness
+	zTop := e.PCBTop + k.Height
+
+	// base
+	walls := sdf.Extrude3D(sdf.Difference2D(outer, inner), zTop)
+	walls = sdf.Transform3D(walls, sdf.Translate3d(v3.Vec{X: 0, Y: 0, Z: 0.5 * zTop}))
+	floor := sdf.Extrude3D(outer, k.Wall)
+	floor = sdf.Transform3D(floor, sdf.Translate3d(v3.Vec{X: 0, Y: 0, Z: 0.5 * k.Wall}))
+	e.Base = sdf.Union3D(walls, floor)
+	var cutouts []sdf.SDF3
+	for i := range k.Cutouts {
+		c, err := enclosureCutout3D(&k.Cutouts[i], outer.BoundingBox(), k.Wall+k.Clearance, e.PCBTop)
+		if err != nil {
+			return nil, err
+		}
+		cutouts = append(cutouts, c)
+	}
+	e.Base = sdf.Difference3D(e.Base, sdf.Union3D(cutouts...))
+
+	// standoffs
+	if len(k.Holes) > 0 {
+		standoff, err := Standoff3D(&k.Standoff)
+		if err != nil {
+			return nil, err
+		}
+		for _, h := range k.Holes {
+			ofs := v3.Vec{X: h.X, Y: h.Y, Z: zFloor + 0.5*k.Standoff.PillarHeight}
+			e.Standoffs = append(e.Standoffs, sdf.Transform3D(standoff, sdf.Translate3d(ofs)))
+		}
+	}
+
+	// lid
+	plate := sdf.Extrude3D(outer, k.Wall)
+	e.Lid = sdf.Transform3D(plate, sdf.Translate3d(v3.Vec{X: 0, Y: 0, Z: zTop + 0.5*k.Wall}))
+	if k.LidDepth > 0 {
+		lip0 := sdf.Offset2D(inner, -k.LidClearance)
+		lip1 := sdf.Offset2D(inner, -k.LidClearance-k.Wall)
+		lip := sdf.Extrude3D(sdf.Difference2D(lip0, lip1), k.LidDepth)
+		lip = sdf.Transform3D(lip, sdf.Translate3d(v3.Vec{X: 0, Y: 0, Z: zTop - 0.5*k.LidDepth}))
+		e.Lid = sdf.Union3D(e.Lid, lip)
+	}
+	if k.Vent != nil {
+		// keep the vents clear of the lid lip
+		region := inner
+		if k.LidDepth > 0 {
+			region = sdf.Offset2D(inner, -k.LidClearance-k.Wall)
+		}
+		vents, err := enclosureVents2D(k.Vent, region)
+		if err != nil {
+			return nil, err
+		}
+		// extend the vents through the lid to avoid coincident surfaces
+		h := 2 * k.Wall
+		e.Vents = sdf.Transform3D(sdf.Extrude3D(vents, h), sdf.Translate3d(v3.Vec{X: 0, Y: 0, Z: zTop + 0.5*k.Wall}))
+		e.Lid = sdf.Difference3D(e.Lid, e.Vents)
+	}
+
+	return &e, nil
+}
+
+//-----------------------------------------------------------------------------
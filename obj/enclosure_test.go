@@ -0,0 +1,122 @@
+//-----------------------------------------------------------------------------
+/*
+
+PCB Enclosure Tests
+
+*/
+//-----------------------------------------------------------------------------
+
+package obj
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gmlewis/sdfx/sdf"
+	v2 "github.com/gmlewis/sdfx/vec/v2"
+	v3 "github.com/gmlewis/sdfx/vec/v3"
+)
+
+//-----------------------------------------------------------------------------
+
+// enclosureParms returns the parameters for an enclosure around a 50x30 PCB.
+func enclosureParms() EnclosureParms {
+	connector := sdf.Box2D(v2.Vec{X: 10, Y: 5}, 0)
+	connector = sdf.Transform2D(connector, sdf.Translate2d(v2.Vec{X: 0, Y: 4}))
+	return EnclosureParms{
+		PCB:          sdf.Box2D(v2.Vec{X: 50, Y: 30}, 0),
+		PCBThickness: 1.6,
+		Holes:        []v2.Vec{{X: -20, Y: -10}, {X: 20, Y: -10}, {X: -20, Y: 10}, {X: 20, Y: 10}},
+		Standoff: StandoffParms{
+			PillarHeight:   5,
+			PillarDiameter: 6,
+			HoleDepth:      4,
+			HoleDiameter:   2.5,
+		},
+		Clearance:    1,
+		Wall:         2,
+		Height:       15,
+		LidDepth:     3,
+		LidClearance: 0.2,
+		Cutouts:      []EnclosureCutout{{Side: "front", Profile: connector}},
+		Vent:         &EnclosureVentParms{Slot: v2.Vec{X: 2, Y: 10}, Pitch: v2.Vec{X: 4, Y: 12}, Margin: 2},
+	}
+}
+
+func Test_Enclosure3D_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		set  func(k *EnclosureParms)
+	}{
+		{"PCB", func(k *EnclosureParms) { k.PCB = nil }},
+		{"PCBThickness", func(k *EnclosureParms) { k.PCBThickness = 0 }},
+		{"PillarHeight", func(k *EnclosureParms) { k.Standoff.PillarHeight = 0 }},
+		{"Clearance", func(k *EnclosureParms) { k.Clearance = -1 }},
+		{"Wall", func(k *EnclosureParms) { k.Wall = 0 }},
+		{"Height", func(k *EnclosureParms) { k.Height = 0 }},
+		{"LidDepth", func(k *EnclosureParms) { k.LidDepth = -1 }},
+		{"LidDepth >= Height", func(k *EnclosureParms) { k.LidDepth = 15 }},
+		{"LidClearance", func(k *EnclosureParms) { k.LidClearance = -1 }},
+		{"hole outside the PCB", func(k *EnclosureParms) { k.Holes = append(k.Holes, v2.Vec{X: 30, Y: 0}) }},
+		{"cutout side", func(k *EnclosureParms) { k.Cutouts[0].Side = "top" }},
+		{"cutout profile", func(k *EnclosureParms) { k.Cutouts[0].Profile = nil }},
+		{"vent slot", func(k *EnclosureParms) { k.Vent.Slot.X = 0 }},
+		{"vent pitch", func(k *EnclosureParms) { k.Vent.Pitch.Y = 5 }},
+		{"vent margin", func(k *EnclosureParms) { k.Vent.Margin = -1 }},
+		{"no room for vents", func(k *EnclosureParms) { k.Vent.Slot.Y = 40; k.Vent.Pitch.Y = 40 }},
+	}
+	for _, test := range tests {
+		k := enclosureParms()
+		test.set(&k)
+		if _, err := Enclosure3D(&k); err == nil {
+			t.Errorf("%s: expected an error", test.name)
+		}
+	}
+}
+
+func Test_Enclosure3D(t *testing.T) {
+	k := enclosureParms()
+	e, err := Enclosure3D(&k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// floor + standoff + PCB
+	if math.Abs(e.PCBTop-8.6) > 1e-9 {
+		t.Errorf("8.6 (expected) %f (actual)", e.PCBTop)
+	}
+	zTop := e.PCBTop + k.Height
+	// the outside of the walls is clearance + wall from the PCB outline
+	bb := e.Base.BoundingBox()
+	if !bb.Min.Equals(v3.Vec{X: -28, Y: -18, Z: 0}, 1e-9) || !bb.Max.Equals(v3.Vec{X: 28, Y: 18, Z: zTop}, 1e-9) {
+		t.Errorf("base bounding box %v", bb)
+	}
+	if len(e.Standoffs) != len(k.Holes) {
+		t.Fatalf("%d standoffs (expected) %d (actual)", len(k.Holes), len(e.Standoffs))
+	}
+
+	tests := []struct {
+		name   string
+		s      sdf.SDF3
+		p      v3.Vec
+		inside bool
+	}{
+		{"floor", e.Base, v3.Vec{X: 0, Y: 0, Z: 1}, true},
+		{"inside", e.Base, v3.Vec{X: 0, Y: 0, Z: 5}, false},
+		{"front wall", e.Base, v3.Vec{X: 15, Y: -17, Z: e.PCBTop + 4}, true},
+		{"connector cutout", e.Base, v3.Vec{X: 0, Y: -17, Z: e.PCBTop + 4}, false},
+		{"back wall", e.Base, v3.Vec{X: 0, Y: 17, Z: e.PCBTop + 4}, true},
+		{"standoff", e.Standoffs[1], v3.Vec{X: 22.5, Y: -10, Z: 3}, true},
+		{"standoff hole", e.Standoffs[1], v3.Vec{X: 20, Y: -10, Z: 6.5}, false},
+		{"lid", e.Lid, v3.Vec{X: 25, Y: 15, Z: zTop + 1}, true},
+		{"lid lip", e.Lid, v3.Vec{X: -24.8, Y: 0, Z: zTop - 1}, true},
+		{"lid vent", e.Lid, v3.Vec{X: 0, Y: 6, Z: zTop + 1}, false},
+		{"between vents", e.Lid, v3.Vec{X: 2, Y: 6, Z: zTop + 1}, true},
+	}
+	for _, test := range tests {
+		if d := test.s.Evaluate(test.p); (d < 0) != test.inside {
+			t.Errorf("%s: %v %f, inside %v (expected)", test.name, test.p, d, test.inside)
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
@@ -13,6 +13,7 @@ import (
 
 	"github.com/gmlewis/sdfx/sdf"
 	v2 "github.com/gmlewis/sdfx/vec/v2"
+	v3 "github.com/gmlewis/sdfx/vec/v3"
 )
 
 //-----------------------------------------------------------------------------
@@ -43,7 +44,7 @@ func involuteTheta(
 
 // involuteGearTooth returns a 2D profile for a single involute tooth.
 func involuteGearTooth(
-	numberTeeth int, // number of gear teeth
+	numberTeeth float64, // number of gear teeth (non-integral for virtual gears)
 	gearModule float64, // pitch circle diameter / number of gear teeth
 	rootRadius float64, // radius at tooth root
 	baseRadius float64, // radius at the base of the involute
@@ -52,13 +53,13 @@ func involuteGearTooth(
 	facets int, // number of facets for involute flank
 ) (sdf.SDF2, error) {
 
-	pitchRadius := float64(numberTeeth) * gearModule / 2.0
+	pitchRadius := numberTeeth * gearModule / 2.0
 
 	// work out the angular extent of the tooth on the base radius
 	pitchPoint := involuteXY(baseRadius, involuteTheta(baseRadius, pitchRadius))
 	faceAngle := math.Atan2(pitchPoint.Y, pitchPoint.X)
 	backlashAngle := backlash / (2.0 * pitchRadius)
-	centerAngle := sdf.Pi/(2.0*float64(numberTeeth)) + faceAngle - backlashAngle
+	centerAngle := sdf.Pi/(2.0*numberTeeth) + faceAngle - backlashAngle
 
 	// work out the angles over which the involute will be used
 	startAngle := involuteTheta(baseRadius, math.Max(baseRadius, rootRadius))
@@ -100,30 +101,46 @@ type InvoluteGearParms struct {
 	Facets        int     // number of facets for involute flank
 }
 
-// InvoluteGear returns an 2D polygon for an involute gear.
-func InvoluteGear(k *InvoluteGearParms) (sdf.SDF2, error) {
-
+// validate checks the gear parameters.
+func (k *InvoluteGearParms) validate() error {
 	if k.NumberTeeth <= 0 {
-		return nil, sdf.ErrMsg("NumberTeeth <= 0")
+		return sdf.ErrMsg("NumberTeeth <= 0")
 	}
 	if k.Module <= 0 {
-		return nil, sdf.ErrMsg("Module <= 0")
+		return sdf.ErrMsg("Module <= 0")
 	}
 	if k.PressureAngle <= 0 {
-		return nil, sdf.ErrMsg("PressureAngle <= 0")
+		return sdf.ErrMsg("PressureAngle <= 0")
 	}
 	if k.Backlash < 0 {
-		return nil, sdf.ErrMsg("Backlash <= 0")
+		return sdf.ErrMsg("Backlash <= 0")
 	}
 	if k.Clearance < 0 {
-		return nil, sdf.ErrMsg("Clearance < 0")
+		return sdf.ErrMsg("Clearance < 0")
 	}
 	if k.RingWidth < 0 {
-		return nil, sdf.ErrMsg("RingWidth < 0")
+		return sdf.ErrMsg("RingWidth < 0")
 	}
 	if k.Facets <= 0 {
-		return nil, sdf.ErrMsg("Facets <= 0")
+		return sdf.ErrMsg("Facets <= 0")
+	}
+	return nil
+}
+
+// InvoluteGear returns an 2D polygon for an involute gear.
+func InvoluteGear(k *InvoluteGearParms) (sdf.SDF2, error) {
+	if err := k.validate(); err != nil {
+		return nil, err
 	}
+	return involuteGear2D(k, k.Module, k.Module+k.Clearance)
+}
+
+// involuteGear2D returns the 2D profile of an external gear.
+func involuteGear2D(
+	k *InvoluteGearParms, // gear parameters
+	addendum float64, // radial distance from pitch circle to outside circle
+	dedendum float64, // radial distance from pitch circle to root circle
+) (sdf.SDF2, error) {
 
 	// pitch radius
 	pitchRadius := float64(k.NumberTeeth) * k.Module * 0.5
@@ -131,16 +148,11 @@ func InvoluteGear(k *InvoluteGearParms) (sdf.SDF2, error) {
 	// base circle radius
 	baseRadius := pitchRadius * math.Cos(k.PressureAngle)
 
-	// addendum: radial distance from pitch circle to outside circle
-	addendum := k.Module * 1.0
-	// dedendum: radial distance from pitch circle to root circle
-	dedendum := addendum + k.Clearance
-
 	outerRadius := pitchRadius + addendum
 	rootRadius := pitchRadius - dedendum
 
 	tooth, err := involuteGearTooth(
-		k.NumberTeeth,
+		float64(k.NumberTeeth),
 		k.Module,
 		rootRadius,
 		baseRadius,
@@ -173,3 +185,136 @@ func InvoluteGear(k *InvoluteGearParms) (sdf.SDF2, error) {
 }
 
 //-----------------------------------------------------------------------------
+// Internal Ring Gears
+
+// RingGear returns a 2D polygon for an internal ring gear.
+// RingWidth is the width of the ring wall outside the root circle.
+// The tooth spaces are the teeth of an external gear with the same parameters.
+// The involute can't extend inside the base circle, so the tips of the ring teeth
+// are cut back to the base circle when the addendum would take them inside it.
+// To avoid tip interference the ring should have at least 12 more teeth than the pinion.
+func RingGear(k *InvoluteGearParms) (sdf.SDF2, error) {
+	if err := k.validate(); err != nil {
+		return nil, err
+	}
+	if k.RingWidth <= 0 {
+		return nil, sdf.ErrMsg("RingWidth <= 0")
+	}
+	return ringGear2D(k, k.Module, k.Module+k.Clearance)
+}
+
+// ringGear2D returns the 2D profile of an internal ring gear.
+func ringGear2D(
+	k *InvoluteGearParms, // gear parameters
+	addendum float64, // radial distance from pitch circle to the tooth tips (inwards)
+	dedendum float64, // radial distance from pitch circle to the root circle (outwards)
+) (sdf.SDF2, error) {
+
+	pitchRadius := float64(k.NumberTeeth) * k.Module * 0.5
+	baseRadius := pitchRadius * math.Cos(k.PressureAngle)
+	tipRadius := math.Max(pitchRadius-addendum, baseRadius)
+	rootRadius := pitchRadius + dedendum
+
+	// the tooth space is an external tooth, widened by the backlash
+	space, err := involuteGearTooth(
+		float64(k.NumberTeeth),
+		k.Module,
+		baseRadius,
+		baseRadius,
+		rootRadius,
+		-k.Backlash,
+		k.Facets,
+	)
+	if err != nil {
+		return nil, err
+	}
+	tip, err := sdf.Circle2D(tipRadius)
+	if err != nil {
+		return nil, err
+	}
+	cutter := sdf.Union2D(sdf.RotateCopy2D(space, k.NumberTeeth), tip)
+
+	ring, err := sdf.Circle2D(rootRadius + k.RingWidth)
+	if err != nil {
+		return nil, err
+	}
+	return sdf.Difference2D(ring, cutter), nil
+}
+
+//-----------------------------------------------------------------------------
+// Helical and Herringbone Gears
+
+// HelicalGearParms defines the parameters for a helical or herringbone gear.
+type HelicalGearParms struct {
+	Gear        InvoluteGearParms // gear parameters, Module and PressureAngle are in the normal plane
+	HelixAngle  float64           // helix angle at the pitch circle (radians, > 0 for right hand, < 0 for left hand)
+	Width       float64           // face width of the gear
+	Herringbone bool              // herringbone gear (the helix is mirrored about the middle of the face)
+	Internal    bool              // internal ring gear
+}
+
+// HelicalGear3D returns a helical or herringbone gear.
+// The gear is centered on the origin with its axis along z.
+// Meshing external gears have opposite hands, an external gear and a ring gear have the same hand.
+func HelicalGear3D(k *HelicalGearParms) (sdf.SDF3, error) {
+	if err := k.Gear.validate(); err != nil {
+		return nil, err
+	}
+	if math.Abs(k.HelixAngle) >= 0.5*sdf.Pi {
+		return nil, sdf.ErrMsg("abs(HelixAngle) >= Pi/2")
+	}
+	if k.Width <= 0 {
+		return nil, sdf.ErrMsg("Width <= 0")
+	}
+	if k.Internal && k.Gear.RingWidth <= 0 {
+		return nil, sdf.ErrMsg("RingWidth <= 0")
+	}
+
+	// the transverse profile is wider than the normal profile by 1/cos(helix angle)
+	c := math.Cos(k.HelixAngle)
+	t := k.Gear
+	t.Module = k.Gear.Module / c
+	t.PressureAngle = math.Atan(math.Tan(k.Gear.PressureAngle) / c)
+	t.Backlash = k.Gear.Backlash / c
+	addendum := k.Gear.Module
+	dedendum := k.Gear.Module + k.Gear.Clearance
+	pitchRadius := float64(t.NumberTeeth) * t.Module * 0.5
+
+	var profile sdf.SDF2
+	var r float64
+	var err error
+	if k.Internal {
+		profile, err = ringGear2D(&t, addendum, dedendum)
+		r = pitchRadius + dedendum + t.RingWidth
+	} else {
+		profile, err = involuteGear2D(&t, addendum, dedendum)
+		r = pitchRadius + addendum
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Rotating the profile by -tan(helix angle)/pitchRadius radians per unit z
+	// moves the pitch circle along the helix.
+	twist := -math.Tan(k.HelixAngle) / pitchRadius * k.Width
+	var gear sdf.SDF3
+	if k.Herringbone {
+		// twist the upper half and mirror it about z = 0
+		h := 0.5 * k.Width
+		m := sdf.Translate3d(v3.Vec{X: 0, Y: 0, Z: 0.5 * h}).Mul(sdf.RotateZ(-0.25 * twist))
+		upper := sdf.Transform3D(sdf.TwistExtrude3D(profile, h, 0.5*twist), m)
+		gear = sdf.Union3D(upper, sdf.Transform3D(upper, sdf.MirrorXY()))
+	} else {
+		gear = sdf.TwistExtrude3D(profile, k.Width, twist)
+	}
+
+	// The twisted extrusion is bounded by the corners of the profile bounding box.
+	// Intersect it with the outer cylinder to get a tight bounding box.
+	cylinder, err := sdf.Cylinder3D(k.Width, r, 0)
+	if err != nil {
+		return nil, err
+	}
+	return sdf.Intersect3D(cylinder, gear), nil
+}
+
+//-----------------------------------------------------------------------------
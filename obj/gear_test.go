@@ -0,0 +1,85 @@
+//-----------------------------------------------------------------------------
+/*
+
+Involute Gear Tests
+
+*/
+//-----------------------------------------------------------------------------
+
+package obj
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gmlewis/sdfx/sdf"
+	v2 "github.com/gmlewis/sdfx/vec/v2"
+	v3 "github.com/gmlewis/sdfx/vec/v3"
+)
+
+//-----------------------------------------------------------------------------
+
+func Test_HelicalGear3D(t *testing.T) {
+	k := HelicalGearParms{
+		Gear: InvoluteGearParms{
+			NumberTeeth:   17,
+			Module:        1,
+			PressureAngle: sdf.DtoR(20),
+			RingWidth:     2,
+			Facets:        7,
+		},
+		HelixAngle: sdf.DtoR(25),
+		Width:      8,
+	}
+	// the transverse profile
+	c := math.Cos(k.HelixAngle)
+	g := k.Gear
+	g.Module /= c
+	g.PressureAngle = math.Atan(math.Tan(g.PressureAngle) / c)
+	pitchRadius := float64(g.NumberTeeth) * g.Module * 0.5
+	rate := math.Tan(k.HelixAngle) / pitchRadius
+
+	for _, herringbone := range []bool{false, true} {
+		for _, internal := range []bool{false, true} {
+			k.Herringbone = herringbone
+			k.Internal = internal
+			s, err := HelicalGear3D(&k)
+			if err != nil {
+				t.Fatal(err)
+			}
+			profile, err := involuteGear2D(&g, k.Gear.Module, k.Gear.Module)
+			r := pitchRadius + k.Gear.Module
+			if internal {
+				profile, err = ringGear2D(&g, k.Gear.Module, k.Gear.Module)
+				r = pitchRadius + k.Gear.Module + g.RingWidth
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			// a tight bounding box
+			bb := s.BoundingBox()
+			if !bb.Max.Equals(v3.Vec{X: r, Y: r, Z: 0.5 * k.Width}, 1e-9) {
+				t.Errorf("herringbone %v internal %v: bounding box %v", herringbone, internal, bb)
+			}
+			// the transverse profile rotates along the helix
+			test := bb.ScaleAboutCenter(1.1)
+			for _, p := range test.RandomSet(5000) {
+				z := p.Z
+				if herringbone {
+					z = math.Abs(z)
+				}
+				q := sdf.Rotate(-rate * z).MulPosition(v2.Vec{X: p.X, Y: p.Y})
+				d := math.Max(profile.Evaluate(q), math.Abs(p.Z)-0.5*k.Width)
+				if math.Abs(d) < 1e-3 {
+					continue
+				}
+				if x := s.Evaluate(p); (x < 0) != (d < 0) {
+					t.Errorf("herringbone %v internal %v: %v %f (expected) %f (actual)", herringbone, internal, p, d, x)
+					break
+				}
+			}
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,128 @@
+//-----------------------------------------------------------------------------
+/*
+
+Gear Pairs
+
+Position a pair of meshing external involute gears.
+
+*/
+//-----------------------------------------------------------------------------
+
+package obj
+
+import (
+	"math"
+
+	"github.com/gmlewis/sdfx/sdf"
+	v2 "github.com/gmlewis/sdfx/vec/v2"
+	v3 "github.com/gmlewis/sdfx/vec/v3"
+)
+
+//-----------------------------------------------------------------------------
+
+// GearPair is a pair of meshing external involute gears.
+// The first gear is centered on the origin and the second gear is on the +x axis.
+type GearPair struct {
+	Gear           [2]InvoluteGearParms // gear parameters
+	CenterDistance float64              // distance between the gear centers
+	ContactRatio   float64              // average number of teeth in contact
+	Backlash       float64              // total backlash at the pitch circle
+}
+
+// NewGearPair returns a pair of meshing gears.
+// The gears must have the same module and pressure angle.
+func NewGearPair(k0, k1 *InvoluteGearParms) (*GearPair, error) {
+	if err := k0.validate(); err != nil {
+		return nil, err
+	}
+	if err := k1.validate(); err != nil {
+		return nil, err
+	}
+	if math.Abs(k0.Module-k1.Module) > 1e-9*k0.Module {
+		return nil, sdf.ErrMsg("gear modules don't match")
+	}
+	if math.Abs(k0.PressureAngle-k1.PressureAngle) > 1e-9 {
+		return nil, sdf.ErrMsg("gear pressure angles don't match")
+	}
+
+	p := GearPair{}
+	p.Gear = [2]InvoluteGearParms{*k0, *k1}
+	m := k0.Module
+	n0 := float64(k0.NumberTeeth)
+	n1 := float64(k1.NumberTeeth)
+	p.CenterDistance = 0.5 * m * (n0 + n1)
+	p.Backlash = k0.Backlash + k1.Backlash
+
+	// length of the line of action / base pitch
+	c := math.Cos(k0.PressureAngle)
+	s := math.Sin(k0.PressureAngle)
+	contact := 0.0
+	for _, n := range []float64{n0, n1} {
+		rp := 0.5 * m * n
+		ra := rp + m
+		rb := rp * c
+		contact += math.Sqrt(ra*ra - rb*rb)
+	}
+	contact -= p.CenterDistance * s
+	p.ContactRatio = contact / (sdf.Pi * m * c)
+	if p.ContactRatio < 1 {
+		return nil, sdf.ErrMsg("contact ratio < 1, use more teeth")
+	}
+	return &p, nil
+}
+
+// Ratio returns the gear ratio (rotations of the first gear per rotation of the second gear).
+func (p *GearPair) Ratio() float64 {
+	return float64(p.Gear[1].NumberTeeth) / float64(p.Gear[0].NumberTeeth)
+}
+
+// Rotation returns the rotation of each gear when the first gear is rotated by theta (radians).
+// The gears mesh with a tooth of the first gear centered on the line between the gear centers.
+func (p *GearPair) Rotation(theta float64) [2]float64 {
+	n1 := float64(p.Gear[1].NumberTeeth)
+	// turn a tooth space of the second gear to face the first gear
+	phase := sdf.Pi + sdf.Pi/n1
+	return [2]float64{theta, phase - theta/p.Ratio()}
+}
+
+// Parts2D returns the gear profiles positioned and rotated to mesh with the first gear rotated by theta.
+func (p *GearPair) Parts2D(theta float64) ([2]sdf.SDF2, error) {
+	var parts [2]sdf.SDF2
+	rotation := p.Rotation(theta)
+	for i := range p.Gear {
+		gear, err := InvoluteGear(&p.Gear[i])
+		if err != nil {
+			return parts, err
+		}
+		m := sdf.Rotate2d(rotation[i])
+		if i == 1 {
+			m = sdf.Translate2d(v2.Vec{X: p.CenterDistance, Y: 0}).Mul(m)
+		}
+		parts[i] = sdf.Transform2D(gear, m)
+	}
+	return parts, nil
+}
+
+// Parts3D returns the gears extruded to the given height, positioned and rotated
+// to mesh with the first gear rotated by theta.
+func (p *GearPair) Parts3D(height, theta float64) ([2]sdf.SDF3, error) {
+	var parts [2]sdf.SDF3
+	if height <= 0 {
+		return parts, sdf.ErrMsg("height <= 0")
+	}
+	rotation := p.Rotation(theta)
+	for i := range p.Gear {
+		gear, err := InvoluteGear(&p.Gear[i])
+		if err != nil {
+			return parts, err
+		}
+		m := sdf.RotateZ(rotation[i])
+		if i == 1 {
+			m = sdf.Translate3d(v3.Vec{X: p.CenterDistance, Y: 0, Z: 0}).Mul(m)
+		}
+		parts[i] = sdf.Transform3D(sdf.Extrude3D(gear, height), m)
+	}
+	return parts, nil
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,100 @@
+//-----------------------------------------------------------------------------
+/*
+
+Gear Pair Tests
+
+*/
+//-----------------------------------------------------------------------------
+
+package obj
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gmlewis/sdfx/sdf"
+	v2 "github.com/gmlewis/sdfx/vec/v2"
+)
+
+//-----------------------------------------------------------------------------
+
+func spurGearParms(n int) InvoluteGearParms {
+	return InvoluteGearParms{
+		NumberTeeth:   n,
+		Module:        2,
+		PressureAngle: sdf.DtoR(20),
+		Backlash:      0.05,
+		Facets:        7,
+	}
+}
+
+func Test_GearPair_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		set  func(k0, k1 *InvoluteGearParms)
+	}{
+		{"NumberTeeth", func(k0, k1 *InvoluteGearParms) { k0.NumberTeeth = 0 }},
+		{"Module", func(k0, k1 *InvoluteGearParms) { k1.Module = 0 }},
+		{"Facets", func(k0, k1 *InvoluteGearParms) { k1.Facets = 0 }},
+		{"module mismatch", func(k0, k1 *InvoluteGearParms) { k1.Module = 2.5 }},
+		{"pressure angle mismatch", func(k0, k1 *InvoluteGearParms) { k1.PressureAngle = sdf.DtoR(14.5) }},
+	}
+	for _, test := range tests {
+		k0, k1 := spurGearParms(20), spurGearParms(40)
+		test.set(&k0, &k1)
+		if _, err := NewGearPair(&k0, &k1); err == nil {
+			t.Errorf("%s: expected an error", test.name)
+		}
+	}
+	k0, k1 := spurGearParms(20), spurGearParms(40)
+	p, err := NewGearPair(&k0, &k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Parts3D(0, 0); err == nil {
+		t.Error("height: expected an error")
+	}
+}
+
+func Test_GearPair(t *testing.T) {
+	k0, k1 := spurGearParms(20), spurGearParms(40)
+	p, err := NewGearPair(&k0, &k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a = m * (n0 + n1) / 2
+	if math.Abs(p.CenterDistance-60) > 1e-12 {
+		t.Errorf("60 (expected) %f (actual)", p.CenterDistance)
+	}
+	// the standard contact ratio for 20 and 40 teeth at 20 degrees
+	if math.Abs(p.ContactRatio-1.6352) > 1e-4 {
+		t.Errorf("1.6352 (expected) %f (actual)", p.ContactRatio)
+	}
+	if math.Abs(p.Ratio()-2) > 1e-12 || math.Abs(p.Backlash-0.1) > 1e-12 {
+		t.Errorf("ratio %f backlash %f", p.Ratio(), p.Backlash)
+	}
+
+	// The gears don't overlap and are close to touching at each rotation.
+	pitch := sdf.Tau / float64(k0.NumberTeeth)
+	for i := 0; i < 5; i++ {
+		parts, err := p.Parts2D(0.2 * float64(i) * pitch)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// sample the meshing region around the pitch point
+		bb := sdf.NewBox2(v2.Vec{X: 20, Y: 0}, v2.Vec{X: 6, Y: 12})
+		closest := math.Inf(1)
+		for _, q := range bb.RandomSet(20000) {
+			d0, d1 := parts[0].Evaluate(q), parts[1].Evaluate(q)
+			if d0 < -1e-3 && d1 < -1e-3 {
+				t.Fatalf("rotation %d: the gears overlap at %v", i, q)
+			}
+			closest = math.Min(closest, math.Max(d0, d1))
+		}
+		if closest > 0.1 {
+			t.Errorf("rotation %d: the gears are %f apart", i, closest)
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,328 @@
+//-----------------------------------------------------------------------------
+/*
+
+Mesh Importers
+
+Read triangle meshes from STL (binary or ASCII), Wavefront OBJ and 3MF files.
+The Load functions return the triangles and the Import functions convert them
+into the same MeshSDF3 as Mesh3D.
+
+*/
+//-----------------------------------------------------------------------------
+
+package obj
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gmlewis/sdfx/render"
+	"github.com/gmlewis/sdfx/sdf"
+	v3 "github.com/gmlewis/sdfx/vec/v3"
+	"github.com/hpinc/go3mf"
+	"github.com/hschendel/stl"
+)
+
+//-----------------------------------------------------------------------------
+
+// parseVec parses 3 floats as a 3D vector.
+func parseVec(f []string) (v3.Vec, error) {
+	if len(f) < 3 {
+		return v3.Vec{}, sdf.ErrMsg("expected 3 coordinates")
+	}
+	var x [3]float64
+	for i := range x {
+		var err error
+		x[i], err = strconv.ParseFloat(f[i], 64)
+		if err != nil {
+			return v3.Vec{}, err
+		}
+	}
+	return v3.Vec{X: x[0], Y: x[1], Z: x[2]}, nil
+}
+
+//-----------------------------------------------------------------------------
+
+// importMesh converts loaded triangles into an SDF3 using Mesh3D.
+func importMesh(tris []*render.Triangle3, err error) (sdf.SDF3, error) {
+	if err != nil {
+		return nil, err
+	}
+	return Mesh3D(tris)
+}
+
+//-----------------------------------------------------------------------------
+// STL
+
+// LoadSTL reads the triangles of an STL model (binary or ASCII).
+func LoadSTL(reader io.ReadSeeker) ([]*render.Triangle3, error) {
+	ascii, err := isASCIISTL(reader)
+	if err != nil {
+		return nil, err
+	}
+	if ascii {
+		return LoadASCIISTL(reader)
+	}
+	mesh, err := stl.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	tris := make([]*render.Triangle3, len(mesh.Triangles))
+	for i, triangle := range mesh.Triangles {
+		tri := &render.Triangle3{}
+		for j, vertex := range triangle.Vertices {
+			tri.V[j] = v3.Vec{X: float64(vertex[0]), Y: float64(vertex[1]), Z: float64(vertex[2])}
+		}
+		tris[i] = tri
+	}
+	return tris, nil
+}
+
+// isASCIISTL returns true if an STL model is ASCII, the reader is left at the start.
+// Binary files may also start with "solid", so the file size is checked against the
+// triangle count of the binary header.
+func isASCIISTL(reader io.ReadSeeker) (bool, error) {
+	size, err := reader.Seek(0, io.SeekEnd)
+	if err != nil {
+		return false, err
+	}
+	if _, err := reader.Seek(0, io.SeekStart); err != nil {
+		return false, err
+	}
+	var hdr [84]byte
+	n, err := io.ReadFull(reader, hdr[:])
+	if _, serr := reader.Seek(0, io.SeekStart); serr != nil {
+		return false, serr
+	}
+	solid := strings.HasPrefix(strings.ToLower(strings.TrimSpace(string(hdr[:n]))), "solid")
+	if err != nil {
+		// too short for a binary header
+		return solid, nil
+	}
+	count := int64(binary.LittleEndian.Uint32(hdr[80:]))
+	return solid && size != 84+50*count, nil
+}
+
+// LoadASCIISTL reads the triangles of an ASCII STL model.
+// The parser is lenient: only the vertex lines are used and keywords are not case sensitive.
+func LoadASCIISTL(reader io.Reader) ([]*render.Triangle3, error) {
+	var tris []*render.Triangle3
+	var v []v3.Vec
+	scanner := bufio.NewScanner(reader)
+	line := 0
+	for scanner.Scan() {
+		line++
+		f := strings.Fields(scanner.Text())
+		if len(f) == 0 || !strings.EqualFold(f[0], "vertex") {
+			continue
+		}
+		p, err := parseVec(f[1:])
+		if err != nil {
+			return nil, sdf.ErrMsg(fmt.Sprintf("line %d: %s", line, err))
+		}
+		v = append(v, p)
+		if len(v) == 3 {
+			tris = append(tris, render.NewTriangle3(v[0], v[1], v[2]))
+			v = v[:0]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(v) != 0 {
+		return nil, sdf.ErrMsg("incomplete facet")
+	}
+	return tris, nil
+}
+
+// ImportASCIISTL converts an ASCII STL model into an SDF3 using Mesh3D.
+func ImportASCIISTL(reader io.Reader) (sdf.SDF3, error) {
+	return importMesh(LoadASCIISTL(reader))
+}
+
+//-----------------------------------------------------------------------------
+// Wavefront OBJ
+
+// objIndex converts an OBJ face vertex ("v", "v/vt", "v//vn" or "v/vt/vn") to a vertex index.
+func objIndex(s string, n int) (int, error) {
+	i, err := strconv.Atoi(strings.Split(s, "/")[0])
+	if err != nil {
+		return 0, err
+	}
+	// negative indices are relative to the end of the vertex list
+	if i < 0 {
+		i += n
+	} else {
+		i--
+	}
+	if i < 0 || i >= n {
+		return 0, sdf.ErrMsg("vertex index out of range")
+	}
+	return i, nil
+}
+
+// LoadOBJ reads the triangles of a Wavefront OBJ model.
+// Only the vertices and faces are used. Polygon faces are split into triangles.
+func LoadOBJ(reader io.Reader) ([]*render.Triangle3, error) {
+	var tris []*render.Triangle3
+	var vertex []v3.Vec
+	scanner := bufio.NewScanner(reader)
+	line := 0
+	for scanner.Scan() {
+		line++
+		f := strings.Fields(scanner.Text())
+		if len(f) == 0 {
+			continue
+		}
+		switch f[0] {
+		case "v":
+			p, err := parseVec(f[1:])
+			if err != nil {
+				return nil, sdf.ErrMsg(fmt.Sprintf("line %d: %s", line, err))
+			}
+			vertex = append(vertex, p)
+		case "f":
+			if len(f) < 4 {
+				return nil, sdf.ErrMsg(fmt.Sprintf("line %d: face has less than 3 vertices", line))
+			}
+			idx := make([]int, len(f)-1)
+			for i, s := range f[1:] {
+				var err error
+				idx[i], err = objIndex(s, len(vertex))
+				if err != nil {
+					return nil, sdf.ErrMsg(fmt.Sprintf("line %d: %s", line, err))
+				}
+			}
+			// triangle fan
+			for i := 1; i < len(idx)-1; i++ {
+				tris = append(tris, render.NewTriangle3(vertex[idx[0]], vertex[idx[i]], vertex[idx[i+1]]))
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return tris, nil
+}
+
+// ImportOBJ converts a Wavefront OBJ model into an SDF3 using Mesh3D.
+func ImportOBJ(reader io.Reader) (sdf.SDF3, error) {
+	return importMesh(LoadOBJ(reader))
+}
+
+//-----------------------------------------------------------------------------
+// 3MF
+
+// matrix3MF returns a 3MF transform, an unset transform is the identity.
+func matrix3MF(m go3mf.Matrix) go3mf.Matrix {
+	if m == (go3mf.Matrix{}) {
+		return go3mf.Identity()
+	}
+	return m
+}
+
+// triangles3MF returns the triangles of a 3MF object (and its components).
+// The object is placed with the transform m.
+func triangles3MF(model *go3mf.Model, path string, o *go3mf.Object, m go3mf.Matrix, depth int) ([]*render.Triangle3, error) {
+	if depth > 32 {
+		return nil, sdf.ErrMsg("3mf components nested too deeply")
+	}
+	var tris []*render.Triangle3
+	if o.Mesh != nil {
+		v := make([]v3.Vec, len(o.Mesh.Vertices.Vertex))
+		for i, p := range o.Mesh.Vertices.Vertex {
+			p = m.Mul3D(p)
+			v[i] = v3.Vec{X: float64(p[0]), Y: float64(p[1]), Z: float64(p[2])}
+		}
+		for _, t := range o.Mesh.Triangles.Triangle {
+			if int(t.V1) >= len(v) || int(t.V2) >= len(v) || int(t.V3) >= len(v) {
+				return nil, sdf.ErrMsg("3mf vertex index out of range")
+			}
+			tris = append(tris, render.NewTriangle3(v[t.V1], v[t.V2], v[t.V3]))
+		}
+	}
+	if o.Components != nil {
+		for _, c := range o.Components.Component {
+			cpath := c.ObjectPath(path)
+			co, ok := model.FindObject(cpath, c.ObjectID)
+			if !ok {
+				return nil, sdf.ErrMsg(fmt.Sprintf("3mf component object %d not found", c.ObjectID))
+			}
+			ct, err := triangles3MF(model, cpath, co, m.Mul(matrix3MF(c.Transform)), depth+1)
+			if err != nil {
+				return nil, err
+			}
+			tris = append(tris, ct...)
+		}
+	}
+	return tris, nil
+}
+
+// Load3MF reads the triangles of the build items of a 3MF file.
+func Load3MF(path string) ([]*render.Triangle3, error) {
+	r, err := go3mf.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	var model go3mf.Model
+	if err := r.Decode(&model); err != nil {
+		return nil, err
+	}
+	var tris []*render.Triangle3
+	for _, item := range model.Build.Items {
+		ipath := item.ObjectPath()
+		o, ok := model.FindObject(ipath, item.ObjectID)
+		if !ok {
+			return nil, sdf.ErrMsg(fmt.Sprintf("3mf build object %d not found", item.ObjectID))
+		}
+		t, err := triangles3MF(&model, ipath, o, matrix3MF(item.Transform), 0)
+		if err != nil {
+			return nil, err
+		}
+		tris = append(tris, t...)
+	}
+	return tris, nil
+}
+
+// Import3MF converts the build items of a 3MF file into an SDF3 using Mesh3D.
+func Import3MF(path string) (sdf.SDF3, error) {
+	return importMesh(Load3MF(path))
+}
+
+//-----------------------------------------------------------------------------
+
+// LoadMesh reads the triangles of an STL (binary or ASCII), OBJ or 3MF file.
+// The file format is determined by the file extension.
+func LoadMesh(path string) ([]*render.Triangle3, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == ".3mf" {
+		return Load3MF(path)
+	}
+	if ext != ".stl" && ext != ".obj" {
+		return nil, sdf.ErrMsg(fmt.Sprintf("unknown mesh file type %s", path))
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	if ext == ".obj" {
+		return LoadOBJ(file)
+	}
+	return LoadSTL(file)
+}
+
+// ImportMesh converts an STL (binary or ASCII), OBJ or 3MF file into an SDF3 using Mesh3D.
+// The file format is determined by the file extension.
+func ImportMesh(path string) (sdf.SDF3, error) {
+	return importMesh(LoadMesh(path))
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,232 @@
+//-----------------------------------------------------------------------------
+/*
+
+Mesh Import Tests
+
+Meshes are written with the render package writers and read back.
+
+*/
+//-----------------------------------------------------------------------------
+
+package obj
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gmlewis/sdfx/render"
+	"github.com/gmlewis/sdfx/sdf"
+	v3 "github.com/gmlewis/sdfx/vec/v3"
+	"github.com/hpinc/go3mf"
+)
+
+//-----------------------------------------------------------------------------
+
+// sameTriangles checks that two triangle sets are the same (to float32 precision).
+func sameTriangles(t *testing.T, name string, want, got []*render.Triangle3) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Errorf("%s: %d triangles (expected) %d (actual)", name, len(want), len(got))
+		return
+	}
+	for i := range want {
+		for j := range want[i].V {
+			if !got[i].V[j].Equals(want[i].V[j], 1e-6) {
+				t.Errorf("%s: triangle %d vertex %d: %v (expected) %v (actual)", name, i, j, want[i].V[j], got[i].V[j])
+				return
+			}
+		}
+	}
+}
+
+// loadMesh reads a mesh file, failing the test on error.
+func loadMesh(t *testing.T, path string) []*render.Triangle3 {
+	t.Helper()
+	tris, err := LoadMesh(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tris
+}
+
+// translateMesh returns a copy of a mesh moved by an offset.
+func translateMesh(mesh []*render.Triangle3, ofs v3.Vec) []*render.Triangle3 {
+	out := make([]*render.Triangle3, len(mesh))
+	for i, t := range mesh {
+		out[i] = render.NewTriangle3(t.V[0].Add(ofs), t.V[1].Add(ofs), t.V[2].Add(ofs))
+	}
+	return out
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_LoadSTL_Binary(t *testing.T) {
+	dir := t.TempDir()
+	cube := translateMesh(cubeMesh(2), v3.Vec{X: 1.5, Y: -2.25, Z: 0.125})
+
+	path := filepath.Join(dir, "cube.stl")
+	if err := render.SaveSTL(path, cube); err != nil {
+		t.Fatal(err)
+	}
+	sameTriangles(t, "SaveSTL", cube, loadMesh(t, path))
+
+	path = filepath.Join(dir, "mesh.stl")
+	if err := render.SaveMeshSTL(path, render.NewMeshFromTriangles(cube, 0)); err != nil {
+		t.Fatal(err)
+	}
+	sameTriangles(t, "SaveMeshSTL", cube, loadMesh(t, path))
+
+	// the streaming writer
+	s, err := sdf.Sphere3D(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path = filepath.Join(dir, "sphere.stl")
+	render.ToSTL(s, path, render.NewMarchingCubesUniform(20))
+	tris := loadMesh(t, path)
+	if n := len(render.ToTriangles(s, render.NewMarchingCubesUniform(20))); len(tris) != n {
+		t.Errorf("ToSTL: %d triangles (expected) %d (actual)", n, len(tris))
+	}
+	for _, tri := range tris {
+		for _, v := range tri.V {
+			if math.Abs(s.Evaluate(v)) > 0.1 {
+				t.Fatalf("ToSTL: vertex %v is not on the sphere", v)
+			}
+		}
+	}
+}
+
+func Test_LoadSTL_ASCII(t *testing.T) {
+	cube := cubeMesh(2)
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "solid cube\n")
+	for i, tri := range cube {
+		n := tri.Normal()
+		// the parser ignores the keyword case
+		facet, vertex := "facet normal", "vertex"
+		if i%2 == 1 {
+			facet, vertex = "FACET NORMAL", "VERTEX"
+		}
+		fmt.Fprintf(&buf, "  %s %g %g %g\n    outer loop\n", facet, n.X, n.Y, n.Z)
+		for _, v := range tri.V {
+			fmt.Fprintf(&buf, "      %s %g %g %g\n", vertex, v.X, v.Y, v.Z)
+		}
+		fmt.Fprintf(&buf, "    endloop\n  endfacet\n")
+	}
+	fmt.Fprintf(&buf, "endsolid cube\n")
+
+	tris, err := LoadSTL(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sameTriangles(t, "ascii", cube, tris)
+
+	// through a file and into an SDF3
+	path := filepath.Join(t.TempDir(), "cube.stl")
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := ImportMesh(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := s.Evaluate(v3.Vec{}); math.Abs(d+1) > 1e-9 {
+		t.Errorf("-1 (expected) %f (actual)", d)
+	}
+
+	// a truncated facet is an error
+	if _, err := LoadASCIISTL(bytes.NewReader([]byte("vertex 0 0 0\nvertex 1 0 0\n"))); err == nil {
+		t.Error("expected an error for an incomplete facet")
+	}
+}
+
+func Test_LoadOBJ(t *testing.T) {
+	dir := t.TempDir()
+	cube := cubeMesh(2)
+	m := render.NewMeshFromTriangles(cube, 0)
+
+	path := filepath.Join(dir, "cube.obj")
+	if err := render.SaveMeshOBJ(path, m, nil); err != nil {
+		t.Fatal(err)
+	}
+	sameTriangles(t, "SaveMeshOBJ", cube, loadMesh(t, path))
+
+	// vertex normals use the v//vn face format
+	normals := make([]v3.Vec, len(m.Vertex))
+	for i, v := range m.Vertex {
+		normals[i] = v.Normalize()
+	}
+	path = filepath.Join(dir, "normals.obj")
+	if err := render.SaveMeshOBJ(path, m, normals); err != nil {
+		t.Fatal(err)
+	}
+	sameTriangles(t, "SaveMeshOBJ normals", cube, loadMesh(t, path))
+
+	// polygons are fanned into triangles and negative indices are relative
+	tris, err := LoadOBJ(bytes.NewReader([]byte("v 0 0 0\nv 1 0 0\nv 1 1 0\nv 0 1 0\nf -4 -3 -2 -1\n")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*render.Triangle3{
+		render.NewTriangle3(v3.Vec{}, v3.Vec{X: 1}, v3.Vec{X: 1, Y: 1}),
+		render.NewTriangle3(v3.Vec{}, v3.Vec{X: 1, Y: 1}, v3.Vec{Y: 1}),
+	}
+	sameTriangles(t, "quad", want, tris)
+}
+
+func Test_Load3MF(t *testing.T) {
+	dir := t.TempDir()
+	cube := cubeMesh(2)
+
+	path := filepath.Join(dir, "cube.3mf")
+	if err := render.SaveMesh3MF(path, render.NewMeshFromTriangles(cube, 0)); err != nil {
+		t.Fatal(err)
+	}
+	sameTriangles(t, "SaveMesh3MF", cube, loadMesh(t, path))
+
+	// A build item with a component: the component transform is applied first.
+	var model go3mf.Model
+	mesh := &go3mf.Mesh{}
+	mb := go3mf.NewMeshBuilder(mesh)
+	for _, tri := range cube {
+		var idx [3]uint32
+		for j, v := range tri.V {
+			idx[j] = mb.AddVertex(go3mf.Point3D{float32(v.X), float32(v.Y), float32(v.Z)})
+		}
+		mesh.Triangles.Triangle = append(mesh.Triangles.Triangle, go3mf.Triangle{V1: idx[0], V2: idx[1], V3: idx[2]})
+	}
+	part := &go3mf.Object{ID: 1, Mesh: mesh}
+	rotate := go3mf.Matrix{0, 1, 0, 0, -1, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1} // 90 degrees about z
+	assembly := &go3mf.Object{ID: 2, Components: &go3mf.Components{Component: []*go3mf.Component{
+		{ObjectID: 1, Transform: go3mf.Identity().Translate(10, 0, 0)},
+	}}}
+	model.Resources.Objects = append(model.Resources.Objects, part, assembly)
+	model.Build.Items = append(model.Build.Items, &go3mf.Item{ObjectID: 2, Transform: rotate})
+
+	path = filepath.Join(dir, "assembly.3mf")
+	w, err := go3mf.CreateWriter(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Encode(&model); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	// translate by (10, 0, 0) then rotate to (0, 10, 0)
+	want := make([]*render.Triangle3, len(cube))
+	for i, tri := range cube {
+		var v [3]v3.Vec
+		for j, p := range tri.V {
+			v[j] = v3.Vec{X: -p.Y, Y: p.X + 10, Z: p.Z}
+		}
+		want[i] = render.NewTriangle3(v[0], v[1], v[2])
+	}
+	sameTriangles(t, "assembly", want, loadMesh(t, path))
+}
+
+//-----------------------------------------------------------------------------
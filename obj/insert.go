@@ -0,0 +1,213 @@
+//-----------------------------------------------------------------------------
+/*
+
+Heat-Set Inserts and Captive Nut Traps
+
+Dummy bodies for assemblies and pockets for mounting them.
+
+As with bearings, the face of the part is on the z = 0 plane and the insert
+or nut sits below it. Subtract the pocket from the part.
+
+*/
+//-----------------------------------------------------------------------------
+
+package obj
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/gmlewis/sdfx/sdf"
+	v2 "github.com/gmlewis/sdfx/vec/v2"
+	v3 "github.com/gmlewis/sdfx/vec/v3"
+)
+
+//-----------------------------------------------------------------------------
+// Heat-Set Inserts
+
+// InsertParms stores the parameters that define a heat-set insert (mm).
+type InsertParms struct {
+	Name     string  // name of insert
+	Thread   string  // name of internal thread
+	Outer    float64 // outer (knurl) diameter
+	Length   float64 // length of insert
+	Hole     float64 // recommended hole diameter
+	Overfill float64 // extra pocket depth for displaced plastic
+}
+
+type insertDatabase map[string]*InsertParms
+
+var insertDB = initInsertLookup()
+
+// Add adds a heat-set insert to the database.
+func (m insertDatabase) Add(name, thread string, outer, length, hole float64) {
+	m[name] = &InsertParms{
+		Name:     name,
+		Thread:   thread,
+		Outer:    outer,
+		Length:   length,
+		Hole:     hole,
+		Overfill: 1,
+	}
+}
+
+// initInsertLookup adds a collection of common heat-set inserts to the database.
+func initInsertLookup() insertDatabase {
+	m := make(insertDatabase)
+	// name, thread, outer, length, hole
+	m.Add("M2x3", "M2x0.4", 3.6, 3, 3.2)
+	m.Add("M2.5x4", "M2.5x0.45", 4.6, 4, 4.0)
+	m.Add("M3x4", "M3x0.5", 4.6, 4, 4.0)
+	m.Add("M3x5.7", "M3x0.5", 4.6, 5.7, 4.0)
+	m.Add("M4x8.1", "M4x0.7", 6.3, 8.1, 5.6)
+	m.Add("M5x9.5", "M5x0.8", 7.1, 9.5, 6.4)
+	m.Add("M6x12.7", "M6x1", 8.7, 12.7, 8.0)
+	return m
+}
+
+// InsertLookup returns the parameters for a named heat-set insert (E.g. "M3x5.7").
+func InsertLookup(name string) (*InsertParms, error) {
+	k, ok := insertDB[name]
+	if !ok {
+		return nil, fmt.Errorf("insert \"%s\" not found", name)
+	}
+	return k, nil
+}
+
+// InsertNames returns the sorted names of the heat-set inserts in the database.
+func InsertNames() []string {
+	names := make([]string, 0, len(insertDB))
+	for name := range insertDB {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validate checks the insert parameters.
+func (k *InsertParms) validate() error {
+	if k.Outer <= 0 {
+		return sdf.ErrMsg("Outer <= 0")
+	}
+	if k.Length <= 0 {
+		return sdf.ErrMsg("Length <= 0")
+	}
+	if k.Hole <= 0 || k.Hole >= k.Outer {
+		return sdf.ErrMsg("Hole must be > 0 and < Outer")
+	}
+	if k.Overfill < 0 {
+		return sdf.ErrMsg("Overfill < 0")
+	}
+	return nil
+}
+
+// Insert3D returns a dummy body for a heat-set insert.
+func Insert3D(k *InsertParms) (sdf.SDF3, error) {
+	if err := k.validate(); err != nil {
+		return nil, err
+	}
+	t, err := sdf.ThreadLookup(k.Thread)
+	if err != nil {
+		return nil, err
+	}
+	body, err := Pipe3D(0.5*k.Outer, t.Radius, k.Length)
+	if err != nil {
+		return nil, err
+	}
+	return sdf.Transform3D(body, sdf.Translate3d(v3.Vec{X: 0, Y: 0, Z: -0.5 * k.Length})), nil
+}
+
+// InsertPocket3D returns a pocket for a heat-set insert.
+// The pocket uses the recommended hole diameter, it is deeper than the insert to
+// leave room for the displaced plastic.
+func InsertPocket3D(
+	k *InsertParms, // insert parameters
+	fit float64, // diametral allowance added to the recommended hole (normally 0)
+) (sdf.SDF3, error) {
+	if err := k.validate(); err != nil {
+		return nil, err
+	}
+	ext := 0.1 * k.Length
+	h := k.Length + k.Overfill + ext
+	s, err := sdf.Cylinder3D(h, 0.5*(k.Hole+fit), 0)
+	if err != nil {
+		return nil, err
+	}
+	return sdf.Transform3D(s, sdf.Translate3d(v3.Vec{X: 0, Y: 0, Z: ext - 0.5*h})), nil
+}
+
+//-----------------------------------------------------------------------------
+// Captive Nut Traps
+
+// NutTrapParms defines the parameters for a captive nut trap.
+type NutTrapParms struct {
+	Thread string  // name of thread
+	Fit    float64 // allowance added to the nut flat to flat distance, nut height and bolt hole diameter
+	Depth  float64 // depth of the nut below the face of the part (0 for flush)
+	Slot   float64 // length of a side entry slot along +x (0 for no slot)
+	Hole   float64 // length of the bolt clearance hole below the nut (0 for no hole)
+}
+
+// NutTrap3D returns a pocket for a captive hex nut.
+// The nut dimensions are from the thread database, use Nut() for a dummy body.
+// Without a slot the nut drops in from the face of the part, with a slot
+// it slides in from the side and the nut sits at the given depth.
+func NutTrap3D(k *NutTrapParms) (sdf.SDF3, error) {
+	t, err := sdf.ThreadLookup(k.Thread)
+	if err != nil {
+		return nil, err
+	}
+	if k.Depth < 0 {
+		return nil, sdf.ErrMsg("Depth < 0")
+	}
+	if k.Slot < 0 {
+		return nil, sdf.ErrMsg("Slot < 0")
+	}
+	if k.Hole < 0 {
+		return nil, sdf.ErrMsg("Hole < 0")
+	}
+	if t.HexFlat2Flat+k.Fit <= 2*t.Radius {
+		return nil, sdf.ErrMsg("nut trap is smaller than the thread")
+	}
+
+	// hex prism with corners on the x-axis so a slot along x has the flat to flat width
+	f2f := t.HexFlat2Flat + k.Fit
+	r := f2f / (2.0 * math.Cos(sdf.DtoR(30)))
+	hex, err := sdf.Polygon2D(sdf.Nagon(6, r))
+	if err != nil {
+		return nil, err
+	}
+	var profile sdf.SDF2 = hex
+	if k.Slot > 0 {
+		slot := sdf.Box2D(v2.Vec{X: k.Slot, Y: f2f}, 0)
+		slot = sdf.Transform2D(slot, sdf.Translate2d(v2.Vec{X: 0.5 * k.Slot, Y: 0}))
+		profile = sdf.Union2D(hex, slot)
+	}
+	nh := t.HexHeight() + k.Fit
+
+	// the nut pocket runs up to the face of the part unless it is entered from the side
+	top := 0.1 * nh
+	if k.Slot > 0 {
+		top = -k.Depth
+	}
+	bottom := -k.Depth - nh
+	h := top - bottom
+	s := sdf.Extrude3D(profile, h)
+	s = sdf.Transform3D(s, sdf.Translate3d(v3.Vec{X: 0, Y: 0, Z: bottom + 0.5*h}))
+
+	if k.Hole > 0 || k.Slot > 0 {
+		// bolt clearance hole through the nut and beyond
+		r := t.Radius + 0.5*k.Fit
+		l := k.Depth + nh + k.Hole + 0.1*nh
+		hole, err := sdf.Cylinder3D(l, r, 0)
+		if err != nil {
+			return nil, err
+		}
+		hole = sdf.Transform3D(hole, sdf.Translate3d(v3.Vec{X: 0, Y: 0, Z: 0.1*nh - 0.5*l}))
+		s = sdf.Union3D(s, hole)
+	}
+	return s, nil
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,121 @@
+//-----------------------------------------------------------------------------
+/*
+
+Heat-Set Insert and Captive Nut Trap Tests
+
+*/
+//-----------------------------------------------------------------------------
+
+package obj
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gmlewis/sdfx/sdf"
+	v3 "github.com/gmlewis/sdfx/vec/v3"
+)
+
+//-----------------------------------------------------------------------------
+
+func Test_Insert3D_Errors(t *testing.T) {
+	if _, err := InsertLookup("M3x6"); err == nil {
+		t.Error("unknown insert: expected an error")
+	}
+	tests := []struct {
+		name string
+		k    InsertParms
+	}{
+		{"Outer", InsertParms{Thread: "M3x0.5", Outer: 0, Length: 4, Hole: 4}},
+		{"Length", InsertParms{Thread: "M3x0.5", Outer: 4.6, Length: 0, Hole: 4}},
+		{"Hole", InsertParms{Thread: "M3x0.5", Outer: 4.6, Length: 4, Hole: 4.6}},
+		{"Overfill", InsertParms{Thread: "M3x0.5", Outer: 4.6, Length: 4, Hole: 4, Overfill: -1}},
+	}
+	for _, test := range tests {
+		if _, err := Insert3D(&test.k); err == nil {
+			t.Errorf("%s: expected an insert error", test.name)
+		}
+		if _, err := InsertPocket3D(&test.k, 0); err == nil {
+			t.Errorf("%s: expected a pocket error", test.name)
+		}
+	}
+	k := InsertParms{Thread: "M3x7", Outer: 4.6, Length: 4, Hole: 4}
+	if _, err := Insert3D(&k); err == nil {
+		t.Error("unknown thread: expected an error")
+	}
+}
+
+func Test_Insert3D(t *testing.T) {
+	k, err := InsertLookup("M3x5.7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the pocket is the recommended hole, deeper than the insert
+	s, err := InsertPocket3D(k, 0.1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bb := s.BoundingBox()
+	r := 0.5 * (k.Hole + 0.1)
+	if math.Abs(bb.Max.X-r) > 1e-9 || math.Abs(bb.Min.Z+k.Length+k.Overfill) > 1e-9 || bb.Max.Z <= 0 {
+		t.Errorf("bounding box %v", bb)
+	}
+	insert, err := Insert3D(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bb := insert.BoundingBox(); math.Abs(bb.Min.Z+k.Length) > 1e-9 || math.Abs(bb.Max.Z) > 1e-9 {
+		t.Errorf("insert bounding box %v", bb)
+	}
+}
+
+func Test_NutTrap3D_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		k    NutTrapParms
+	}{
+		{"Thread", NutTrapParms{Thread: "M3x7"}},
+		{"Depth", NutTrapParms{Thread: "M3x0.5", Depth: -1}},
+		{"Slot", NutTrapParms{Thread: "M3x0.5", Slot: -1}},
+		{"Hole", NutTrapParms{Thread: "M3x0.5", Hole: -1}},
+		{"Fit", NutTrapParms{Thread: "M3x0.5", Fit: -3}},
+	}
+	for _, test := range tests {
+		if _, err := NutTrap3D(&test.k); err == nil {
+			t.Errorf("%s: expected an error", test.name)
+		}
+	}
+}
+
+func Test_NutTrap3D(t *testing.T) {
+	thread, err := sdf.ThreadLookup("M3x0.5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NutTrap3D(&NutTrapParms{Thread: "M3x0.5", Fit: 0.2, Depth: 2, Slot: 10, Hole: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the slot is the nut width across the flats
+	w := 0.5 * (thread.HexFlat2Flat + 0.2)
+	tests := []struct {
+		p      v3.Vec
+		inside bool
+	}{
+		{v3.Vec{X: 0, Y: w - 0.05, Z: -3}, true},  // nut flat
+		{v3.Vec{X: 0, Y: w + 0.05, Z: -3}, false}, // outside the nut flat
+		{v3.Vec{X: 5, Y: w - 0.05, Z: -3}, true},  // slot
+		{v3.Vec{X: 5, Y: w + 0.05, Z: -3}, false}, // outside the slot
+		{v3.Vec{X: 10.1, Y: 0, Z: -3}, false},     // past the end of the slot
+		{v3.Vec{X: 0, Y: 0, Z: -1}, true},         // bolt hole above the nut
+		{v3.Vec{X: 3, Y: 0, Z: -1}, false},        // above the slot
+		{v3.Vec{X: 0, Y: 0, Z: -6}, true},         // bolt hole below the nut
+	}
+	for _, test := range tests {
+		if d := s.Evaluate(test.p); (d < 0) != test.inside {
+			t.Errorf("%v: %f, inside %v (expected)", test.p, d, test.inside)
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,310 @@
+//-----------------------------------------------------------------------------
+/*
+
+Triangle Mesh SDF3
+
+Convert a closed triangle mesh into an SDF3.
+
+The distance is the exact distance to the closest triangle. A bounding volume
+hierarchy (BVH) of the triangles is used to find the closest triangle quickly.
+
+The sign is found using angle-weighted pseudonormals. The closest point on the
+mesh lies on a face, an edge or a vertex and the pseudonormal of that feature
+tells us if the point is inside or outside the mesh. This is correct for any
+closed, consistently wound (counter-clockwise when viewed from outside) mesh.
+See: Baerentzen, Aanaes, "Signed Distance Computation Using the Angle Weighted Pseudonormal", 2005.
+
+*/
+//-----------------------------------------------------------------------------
+
+package obj
+
+import (
+	"io"
+	"math"
+	"sort"
+
+	"github.com/gmlewis/sdfx/render"
+	"github.com/gmlewis/sdfx/sdf"
+	v3 "github.com/gmlewis/sdfx/vec/v3"
+)
+
+//-----------------------------------------------------------------------------
+
+// meshLeafSize is the maximum number of triangles in a BVH leaf node.
+const meshLeafSize = 4
+
+// meshStackSize is the size of the BVH search stack.
+// The BVH is split at the median so its depth is log2 of the triangle count.
+// The search pushes 2 nodes and pops 1 per level, so this is ample.
+const meshStackSize = 128
+
+// meshTriangle is a mesh triangle with pre-calculated sign information.
+type meshTriangle struct {
+	v  [3]v3.Vec // vertices
+	vi [3]int    // vertex indices
+	n  v3.Vec    // face normal
+	en [3]v3.Vec // edge pseudonormals (edge i is v[i] to v[i+1])
+}
+
+// meshNode is a BVH node.
+type meshNode struct {
+	bb          sdf.Box3 // bounding box of the node triangles
+	left, right int      // child node indices (0 for leaf nodes)
+	start, end  int      // triangle range for leaf nodes
+}
+
+// MeshSDF3 is an SDF3 for a closed triangle mesh.
+type MeshSDF3 struct {
+	triangle []meshTriangle // mesh triangles (in BVH order)
+	vn       []v3.Vec       // vertex pseudonormals
+	node     []meshNode     // BVH nodes (the root is node 0)
+	bb       sdf.Box3       // bounding box
+}
+
+// Mesh3D returns an SDF3 for a closed triangle mesh.
+// The mesh should be closed and the triangles wound counter-clockwise when viewed from outside.
+func Mesh3D(mesh []*render.Triangle3) (sdf.SDF3, error) {
+	// remove degenerate triangles
+	tris := make([]*render.Triangle3, 0, len(mesh))
+	for _, t := range mesh {
+		if t.V[1].Sub(t.V[0]).Cross(t.V[2].Sub(t.V[0])).Length() > 0 {
+			tris = append(tris, t)
+		}
+	}
+	if len(tris) == 0 {
+		return nil, sdf.ErrMsg("no triangles in mesh")
+	}
+
+	s := MeshSDF3{}
+	s.triangle = make([]meshTriangle, len(tris))
+
+	// index the vertices
+	vIndex := make(map[v3.Vec]int)
+	for i, t := range tris {
+		mt := &s.triangle[i]
+		mt.v = t.V
+		mt.n = t.Normal()
+		for j, v := range t.V {
+			k, ok := vIndex[v]
+			if !ok {
+				k = len(vIndex)
+				vIndex[v] = k
+			}
+			mt.vi[j] = k
+		}
+	}
+
+	// vertex pseudonormals: the sum of the face normals weighted by the face angle at the vertex
+	s.vn = make([]v3.Vec, len(vIndex))
+	for i := range s.triangle {
+		t := &s.triangle[i]
+		for j := 0; j < 3; j++ {
+			e0 := t.v[(j+1)%3].Sub(t.v[j]).Normalize()
+			e1 := t.v[(j+2)%3].Sub(t.v[j]).Normalize()
+			angle := math.Acos(sdf.Clamp(e0.Dot(e1), -1, 1))
+			s.vn[t.vi[j]] = s.vn[t.vi[j]].Add(t.n.MulScalar(angle))
+		}
+	}
+
+	// edge pseudonormals: the sum of the face normals on each side of the edge
+	type edgeKey [2]int
+	edgeNormal := make(map[edgeKey]v3.Vec)
+	key := func(a, b int) edgeKey {
+		if a > b {
+			a, b = b, a
+		}
+		return edgeKey{a, b}
+	}
+	for i := range s.triangle {
+		t := &s.triangle[i]
+		for j := 0; j < 3; j++ {
+			k := key(t.vi[j], t.vi[(j+1)%3])
+			edgeNormal[k] = edgeNormal[k].Add(t.n)
+		}
+	}
+	for i := range s.triangle {
+		t := &s.triangle[i]
+		for j := 0; j < 3; j++ {
+			t.en[j] = edgeNormal[key(t.vi[j], t.vi[(j+1)%3])]
+		}
+	}
+
+	// build the bounding volume hierarchy
+	s.node = make([]meshNode, 0, 2*len(s.triangle)/meshLeafSize+1)
+	s.build(0, len(s.triangle))
+	s.bb = s.node[0].bb
+	return &s, nil
+}
+
+// triangleBox returns the bounding box of a range of triangles.
+func (s *MeshSDF3) triangleBox(start, end int) sdf.Box3 {
+	bb := sdf.Box3{Min: s.triangle[start].v[0], Max: s.triangle[start].v[0]}
+	for i := start; i < end; i++ {
+		for _, v := range s.triangle[i].v {
+			bb = bb.Include(v)
+		}
+	}
+	return bb
+}
+
+// build recursively builds the BVH for a range of triangles, returns the node index.
+func (s *MeshSDF3) build(start, end int) int {
+	idx := len(s.node)
+	s.node = append(s.node, meshNode{bb: s.triangleBox(start, end), start: start, end: end})
+	if end-start <= meshLeafSize {
+		return idx
+	}
+	// split on the longest axis of the triangle centroids
+	cb := sdf.Box3{Min: centroid(&s.triangle[start]), Max: centroid(&s.triangle[start])}
+	for i := start; i < end; i++ {
+		cb = cb.Include(centroid(&s.triangle[i]))
+	}
+	size := cb.Size()
+	axis := 0
+	if size.Y > size.Get(axis) {
+		axis = 1
+	}
+	if size.Z > size.Get(axis) {
+		axis = 2
+	}
+	tris := s.triangle[start:end]
+	sort.Slice(tris, func(i, j int) bool {
+		return centroid(&tris[i]).Get(axis) < centroid(&tris[j]).Get(axis)
+	})
+	mid := (start + end) / 2
+	left := s.build(start, mid)
+	right := s.build(mid, end)
+	s.node[idx].left = left
+	s.node[idx].right = right
+	return idx
+}
+
+// centroid returns the centroid of a triangle.
+func centroid(t *meshTriangle) v3.Vec {
+	return t.v[0].Add(t.v[1]).Add(t.v[2]).DivScalar(3)
+}
+
+// boxDist2 returns the minimum distance squared from a point to a box.
+func boxDist2(bb sdf.Box3, p v3.Vec) float64 {
+	return p.Sub(p.Clamp(bb.Min, bb.Max)).Length2()
+}
+
+// Evaluate returns the minimum distance to a triangle mesh.
+func (s *MeshSDF3) Evaluate(p v3.Vec) float64 {
+	best := math.MaxFloat64
+	var bestT *meshTriangle
+	var bestC v3.Vec
+	var bestF int
+
+	// depth first search of the BVH, nearest child first
+	var stack [meshStackSize]int
+	sp := 1
+	for sp > 0 {
+		sp--
+		n := &s.node[stack[sp]]
+		if boxDist2(n.bb, p) >= best {
+			continue
+		}
+		if n.left == 0 {
+			// leaf node
+			for i := n.start; i < n.end; i++ {
+				t := &s.triangle[i]
+				c, f := closestTrianglePoint(p, t)
+				if d := p.Sub(c).Length2(); d < best {
+					best = d
+					bestT = t
+					bestC = c
+					bestF = f
+				}
+			}
+			continue
+		}
+		dl := boxDist2(s.node[n.left].bb, p)
+		dr := boxDist2(s.node[n.right].bb, p)
+		if dl < dr {
+			stack[sp], stack[sp+1] = n.right, n.left
+		} else {
+			stack[sp], stack[sp+1] = n.left, n.right
+		}
+		sp += 2
+	}
+
+	// work out the sign using the pseudonormal of the closest feature
+	var normal v3.Vec
+	switch {
+	case bestF < 3:
+		normal = s.vn[bestT.vi[bestF]]
+	case bestF < 6:
+		normal = bestT.en[bestF-3]
+	default:
+		normal = bestT.n
+	}
+	d := math.Sqrt(best)
+	if p.Sub(bestC).Dot(normal) < 0 {
+		return -d
+	}
+	return d
+}
+
+// BoundingBox returns the bounding box of a triangle mesh.
+func (s *MeshSDF3) BoundingBox() sdf.Box3 {
+	return s.bb
+}
+
+//-----------------------------------------------------------------------------
+
+// closestTrianglePoint returns the closest point on a triangle to p and the triangle feature it lies on.
+// feature: 0,1,2 = vertex i, 3,4,5 = edge i (v[i] to v[i+1]), 6 = face.
+// See: Ericson, "Real-Time Collision Detection", 5.1.5
+func closestTrianglePoint(p v3.Vec, t *meshTriangle) (v3.Vec, int) {
+	a, b, c := t.v[0], t.v[1], t.v[2]
+	ab := b.Sub(a)
+	ac := c.Sub(a)
+	ap := p.Sub(a)
+	d1 := ab.Dot(ap)
+	d2 := ac.Dot(ap)
+	if d1 <= 0 && d2 <= 0 {
+		return a, 0
+	}
+	bp := p.Sub(b)
+	d3 := ab.Dot(bp)
+	d4 := ac.Dot(bp)
+	if d3 >= 0 && d4 <= d3 {
+		return b, 1
+	}
+	vc := d1*d4 - d3*d2
+	if vc <= 0 && d1 >= 0 && d3 <= 0 {
+		v := d1 / (d1 - d3)
+		return a.Add(ab.MulScalar(v)), 3
+	}
+	cp := p.Sub(c)
+	d5 := ab.Dot(cp)
+	d6 := ac.Dot(cp)
+	if d6 >= 0 && d5 <= d6 {
+		return c, 2
+	}
+	vb := d5*d2 - d1*d6
+	if vb <= 0 && d2 >= 0 && d6 <= 0 {
+		w := d2 / (d2 - d6)
+		return a.Add(ac.MulScalar(w)), 5
+	}
+	va := d3*d6 - d5*d4
+	if va <= 0 && (d4-d3) >= 0 && (d5-d6) >= 0 {
+		w := (d4 - d3) / ((d4 - d3) + (d5 - d6))
+		return b.Add(c.Sub(b).MulScalar(w)), 4
+	}
+	denom := 1 / (va + vb + vc)
+	v := vb * denom
+	w := vc * denom
+	return a.Add(ab.MulScalar(v)).Add(ac.MulScalar(w)), 6
+}
+
+//-----------------------------------------------------------------------------
+
+// ImportSTLMesh converts an STL model (binary or ASCII) into an SDF3 using Mesh3D.
+func ImportSTLMesh(reader io.ReadSeeker) (sdf.SDF3, error) {
+	return importMesh(LoadSTL(reader))
+}
+
+//-----------------------------------------------------------------------------
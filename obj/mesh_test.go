@@ -0,0 +1,124 @@
+//-----------------------------------------------------------------------------
+/*
+
+Triangle Mesh SDF3 Tests
+
+The mesh distance is checked against the analytic SDFs of the meshed shapes.
+
+*/
+//-----------------------------------------------------------------------------
+
+package obj
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gmlewis/sdfx/render"
+	"github.com/gmlewis/sdfx/sdf"
+	v2 "github.com/gmlewis/sdfx/vec/v2"
+	v3 "github.com/gmlewis/sdfx/vec/v3"
+)
+
+//-----------------------------------------------------------------------------
+
+// cubeMesh returns the triangles of an axis aligned cube centered on the origin.
+// The triangles are wound counter-clockwise when viewed from outside.
+func cubeMesh(size float64) []*render.Triangle3 {
+	h := 0.5 * size
+	v := [8]v3.Vec{
+		{X: -h, Y: -h, Z: -h}, {X: h, Y: -h, Z: -h}, {X: h, Y: h, Z: -h}, {X: -h, Y: h, Z: -h},
+		{X: -h, Y: -h, Z: h}, {X: h, Y: -h, Z: h}, {X: h, Y: h, Z: h}, {X: -h, Y: h, Z: h},
+	}
+	quads := [6][4]int{
+		{0, 3, 2, 1}, // -z
+		{4, 5, 6, 7}, // +z
+		{0, 1, 5, 4}, // -y
+		{2, 3, 7, 6}, // +y
+		{0, 4, 7, 3}, // -x
+		{1, 2, 6, 5}, // +x
+	}
+	var mesh []*render.Triangle3
+	for _, q := range quads {
+		mesh = append(mesh, render.NewTriangle3(v[q[0]], v[q[1]], v[q[2]]))
+		mesh = append(mesh, render.NewTriangle3(v[q[0]], v[q[2]], v[q[3]]))
+	}
+	return mesh
+}
+
+func Test_Mesh3D_Box(t *testing.T) {
+	s, err := Mesh3D(cubeMesh(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	box, err := sdf.Box3D(v3.Vec{X: 2, Y: 2, Z: 2}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the mesh distance is exact
+	bb := sdf.NewBox3(v3.Vec{}, v3.Vec{X: 4, Y: 4, Z: 4})
+	for _, p := range bb.RandomSet(10000) {
+		if d0, d1 := s.Evaluate(p), box.Evaluate(p); math.Abs(d0-d1) > 1e-9 {
+			t.Errorf("%v %f (expected) %f (actual)", p, d1, d0)
+		}
+	}
+	// the sign close to faces, edges and vertices
+	const e = 1e-6
+	tests := []struct {
+		p      v3.Vec
+		inside bool
+	}{
+		{v3.Vec{X: 1 - e, Y: 0.3, Z: 0.2}, true},
+		{v3.Vec{X: 1 + e, Y: 0.3, Z: 0.2}, false},
+		{v3.Vec{X: 1 - e, Y: 1 - e, Z: 0.2}, true},
+		{v3.Vec{X: 1 + e, Y: 1 - e, Z: 0.2}, false},
+		{v3.Vec{X: 1 + e, Y: 1 + e, Z: 0.2}, false},
+		{v3.Vec{X: 1 - e, Y: -1 + e, Z: -1 + e}, true},
+		{v3.Vec{X: 1 + e, Y: -1 - e, Z: 0}, false},
+		{v3.Vec{X: 1 - e, Y: 1 - e, Z: 1 - e}, true},
+		{v3.Vec{X: 1 + e, Y: 1 + e, Z: 1 + e}, false},
+		{v3.Vec{X: -1 - e, Y: 1 - e, Z: -1 - e}, false},
+		{v3.Vec{X: -1 - e, Y: -1 - 2*e, Z: 1 + 3*e}, false},
+	}
+	for _, test := range tests {
+		if d := s.Evaluate(test.p); (d < 0) != test.inside {
+			t.Errorf("%v: distance %g, inside %v (expected)", test.p, d, test.inside)
+		}
+	}
+}
+
+func Test_Mesh3D_Torus(t *testing.T) {
+	// a torus has concave edges and vertices on the inside of the ring
+	circle, err := sdf.Circle2D(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	torus, err := sdf.Revolve3D(sdf.Transform2D(circle, sdf.Translate2d(v2.Vec{X: 3, Y: 0})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	triangles := render.ToTriangles(torus, render.NewMarchingCubesUniform(60))
+	mesh := make([]*render.Triangle3, len(triangles))
+	for i := range triangles {
+		mesh[i] = &triangles[i]
+	}
+	s, err := Mesh3D(mesh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the mesh approximates the surface to within a fraction of a cell
+	const tol = 0.1
+	bb := torus.BoundingBox().ScaleAboutCenter(1.2)
+	for _, p := range bb.RandomSet(10000) {
+		d0 := s.Evaluate(p)
+		d1 := torus.Evaluate(p)
+		if math.Abs(d1) > tol && (d0 < 0) != (d1 < 0) {
+			t.Errorf("%v: bad sign %f (expected) %f (actual)", p, d1, d0)
+		}
+		if math.Abs(d0-d1) > tol {
+			t.Errorf("%v: %f (expected) %f (actual)", p, d1, d0)
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
@@ -20,10 +20,15 @@ import (
 type NutParms struct {
 	Thread    string  // name of thread
 	Style     string  // head style "hex" or "knurl"
-	Tolerance float64 // add to internal thread radius
+	Class     string  // thread tolerance class (E.g. "6H", "2B"), "" for the nominal thread
+	Tolerance float64 // add to internal thread radius (after the Class offset)
+	Starts    int     // number of thread starts (0 for a single start, < 0 for left hand threads)
+	Chamfer   float64 // countersink length at both ends of the thread (0 for none)
 }
 
 // Nut returns a simple nut suitable for 3d printing.
+// The thread radius is the radius for the tolerance class plus the tolerance,
+// so Class gives a standard fit and Tolerance adds clearance for the printer.
 func Nut(k *NutParms) (sdf.SDF3, error) {
 	// validate parameters
 	t, err := sdf.ThreadLookup(k.Thread)
@@ -33,6 +38,9 @@ func Nut(k *NutParms) (sdf.SDF3, error) {
 	if k.Tolerance < 0 {
 		return nil, sdf.ErrMsg("Tolerance < 0")
 	}
+	if k.Chamfer < 0 {
+		return nil, sdf.ErrMsg("Chamfer < 0")
+	}
 
 	// nut body
 	var nut sdf.SDF3
@@ -51,11 +59,21 @@ func Nut(k *NutParms) (sdf.SDF3, error) {
 	}
 
 	// internal thread
-	isoThread, err := sdf.ISOThread(t.Radius+k.Tolerance, t.Pitch, false)
+	r, err := t.ThreadRadius(false, k.Class)
+	if err != nil {
+		return nil, err
+	}
+	threadProfile, err := t.ThreadProfile(r+k.Tolerance, false)
+	if err != nil {
+		return nil, err
+	}
+	thread, err := sdf.Screw3D(threadProfile, nh, t.Taper, t.Pitch, threadStarts(k.Starts))
 	if err != nil {
 		return nil, err
 	}
-	thread, err := sdf.Screw3D(isoThread, nh, t.Taper, t.Pitch, 1)
+	// countersink both ends
+	end := sdf.ScrewEnd{Chamfer: k.Chamfer}
+	thread, err = sdf.ScrewEnds3D(thread, false, end, end)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,293 @@
+//-----------------------------------------------------------------------------
+/*
+
+Timing Belt Pulleys
+
+The groove profiles are simplified versions of the manufacturer profiles.
+GT2 and HTD grooves are circular arcs, T series grooves are trapezoids.
+Check the fit against the belt being used, printed pulleys may need the
+groove dimensions adjusted.
+
+*/
+//-----------------------------------------------------------------------------
+
+package obj
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/gmlewis/sdfx/sdf"
+	v2 "github.com/gmlewis/sdfx/vec/v2"
+	v3 "github.com/gmlewis/sdfx/vec/v3"
+)
+
+//-----------------------------------------------------------------------------
+
+// BeltParms stores the parameters that define a timing belt tooth profile.
+type BeltParms struct {
+	Name         string  // name of belt
+	Pitch        float64 // distance between belt teeth
+	PLD          float64 // pitch line differential (radial distance from pitch line to pulley outside)
+	GrooveDepth  float64 // radial depth of the pulley groove
+	GrooveRadius float64 // radius of a round groove (GT2, HTD), 0 for a trapezoidal groove
+	GrooveWidth  float64 // width of a trapezoidal groove at the pulley outside
+	GrooveAngle  float64 // included angle of a trapezoidal groove (radians)
+}
+
+type beltDatabase map[string]*BeltParms
+
+var beltDB = initBeltLookup()
+
+// roundAdd adds a belt with round grooves to the database.
+func (m beltDatabase) roundAdd(
+	name string, // name of belt
+	pitch float64, // belt pitch
+	pld float64, // pitch line differential
+	depth float64, // groove depth
+	radius float64, // groove radius
+) {
+	m[name] = &BeltParms{
+		Name:         name,
+		Pitch:        pitch,
+		PLD:          pld,
+		GrooveDepth:  depth,
+		GrooveRadius: radius,
+	}
+}
+
+// trapezoidalAdd adds a belt with trapezoidal grooves to the database.
+func (m beltDatabase) trapezoidalAdd(
+	name string, // name of belt
+	pitch float64, // belt pitch
+	pld float64, // pitch line differential
+	depth float64, // groove depth
+	width float64, // groove width at the pulley outside
+	angle float64, // included angle of the groove (degrees)
+) {
+	m[name] = &BeltParms{
+		Name:        name,
+		Pitch:       pitch,
+		PLD:         pld,
+		GrooveDepth: depth,
+		GrooveWidth: width,
+		GrooveAngle: sdf.DtoR(angle),
+	}
+}
+
+// initBeltLookup adds a collection of named belts to the database.
+func initBeltLookup() beltDatabase {
+	m := make(beltDatabase)
+	// name, pitch, pld, depth, radius
+	m.roundAdd("gt2_2mm", 2, 0.254, 0.75, 0.555)
+	m.roundAdd("gt2_3mm", 3, 0.381, 1.14, 0.85)
+	m.roundAdd("htd_3m", 3, 0.381, 1.22, 0.86)
+	m.roundAdd("htd_5m", 5, 0.5715, 2.06, 1.49)
+	// name, pitch, pld, depth, width, angle
+	m.trapezoidalAdd("t2.5", 2.5, 0.3, 0.75, 1.75, 50)
+	m.trapezoidalAdd("t5", 5, 0.5, 1.25, 2.96, 50)
+	return m
+}
+
+// BeltLookup returns the parameters for a named timing belt.
+func BeltLookup(name string) (*BeltParms, error) {
+	k, ok := beltDB[name]
+	if !ok {
+		return nil, fmt.Errorf("belt \"%s\" not found", name)
+	}
+	return k, nil
+}
+
+//-----------------------------------------------------------------------------
+
+// PulleyParms stores the parameters that define a timing belt pulley.
+type PulleyParms struct {
+	Belt            *BeltParms // belt tooth profile
+	NumberTeeth     int        // number of pulley teeth
+	Width           float64    // width of the toothed section
+	FlangeHeight    float64    // radial height of the flanges above the pulley outside (0 for no flanges)
+	FlangeThickness float64    // thickness of the flanges
+	HubRadius       float64    // radius of the hub (0 for no hub)
+	HubLength       float64    // length of the hub
+	BoreRadius      float64    // radius of the shaft bore (0 for no bore)
+	SetScrewRadius  float64    // radius of the set screw hole (0 for no set screw)
+}
+
+// OutsideRadius returns the outside radius of the toothed section of the pulley.
+func (k *PulleyParms) OutsideRadius() float64 {
+	return k.PitchRadius() - k.Belt.PLD
+}
+
+// PitchRadius returns the radius of the belt pitch line on the pulley.
+func (k *PulleyParms) PitchRadius() float64 {
+	return float64(k.NumberTeeth) * k.Belt.Pitch / sdf.Tau
+}
+
+// pulleyGroove returns the 2D profile of a single groove on the +x axis.
+func pulleyGroove(
+	k *BeltParms, // belt parameters
+	r float64, // pulley outside radius
+) (sdf.SDF2, error) {
+	if k.GrooveRadius > 0 {
+		s, err := sdf.Circle2D(k.GrooveRadius)
+		if err != nil {
+			return nil, err
+		}
+		x := r - k.GrooveDepth + k.GrooveRadius
+		return sdf.Transform2D(s, sdf.Translate2d(v2.Vec{X: x, Y: 0})), nil
+	}
+	// extend the trapezoid beyond the pulley outside so the groove cuts cleanly
+	ext := k.GrooveDepth
+	t := math.Tan(0.5 * k.GrooveAngle)
+	w0 := 0.5*k.GrooveWidth - k.GrooveDepth*t
+	w1 := 0.5*k.GrooveWidth + ext*t
+	if w0 <= 0 {
+		return nil, sdf.ErrMsg("trapezoidal groove is pointed")
+	}
+	p := sdf.NewPolygon()
+	p.Add(r-k.GrooveDepth, -w0)
+	p.Add(r+ext, -w1)
+	p.Add(r+ext, w1)
+	p.Add(r-k.GrooveDepth, w0)
+	return sdf.Polygon2D(p.Vertices())
+}
+
+// Pulley2D returns the 2D profile of the toothed section of a timing belt pulley.
+func Pulley2D(k *PulleyParms) (sdf.SDF2, error) {
+	if k.Belt == nil {
+		return nil, sdf.ErrMsg("Belt == nil")
+	}
+	if k.Belt.Pitch <= 0 {
+		return nil, sdf.ErrMsg("Belt.Pitch <= 0")
+	}
+	if k.Belt.GrooveDepth <= 0 {
+		return nil, sdf.ErrMsg("Belt.GrooveDepth <= 0")
+	}
+	if k.NumberTeeth < 6 {
+		return nil, sdf.ErrMsg("NumberTeeth < 6")
+	}
+	r := k.OutsideRadius()
+	if r-k.Belt.GrooveDepth <= k.BoreRadius {
+		return nil, sdf.ErrMsg("bore radius is larger than the groove root")
+	}
+	// width of the groove at the pulley outside
+	w := k.Belt.GrooveWidth
+	if k.Belt.GrooveRadius > 0 {
+		d := k.Belt.GrooveRadius - k.Belt.GrooveDepth
+		w = 2 * math.Sqrt(math.Max(k.Belt.GrooveRadius*k.Belt.GrooveRadius-d*d, 0))
+	}
+	if w >= sdf.Tau*r/float64(k.NumberTeeth) {
+		return nil, sdf.ErrMsg("pulley grooves overlap, use more teeth")
+	}
+
+	groove, err := pulleyGroove(k.Belt, r)
+	if err != nil {
+		return nil, err
+	}
+	s, err := sdf.Circle2D(r)
+	if err != nil {
+		return nil, err
+	}
+	return sdf.Difference2D(s, sdf.RotateCopy2D(groove, k.NumberTeeth)), nil
+}
+
+// Pulley3D returns a timing belt pulley.
+// The pulley axis is the z-axis with the bottom of the hub (or flange) at z = 0.
+// The hub is below the toothed section and the set screw goes through the hub along the x-axis.
+func Pulley3D(k *PulleyParms) (sdf.SDF3, error) {
+	if k.Width <= 0 {
+		return nil, sdf.ErrMsg("Width <= 0")
+	}
+	if k.FlangeHeight < 0 {
+		return nil, sdf.ErrMsg("FlangeHeight < 0")
+	}
+	if k.FlangeHeight > 0 && k.FlangeThickness <= 0 {
+		return nil, sdf.ErrMsg("FlangeThickness <= 0")
+	}
+	if k.HubRadius < 0 {
+		return nil, sdf.ErrMsg("HubRadius < 0")
+	}
+	if k.HubRadius > 0 && k.HubLength <= 0 {
+		return nil, sdf.ErrMsg("HubLength <= 0")
+	}
+	if k.HubRadius > 0 && k.HubRadius <= k.BoreRadius {
+		return nil, sdf.ErrMsg("HubRadius <= BoreRadius")
+	}
+	if k.BoreRadius < 0 {
+		return nil, sdf.ErrMsg("BoreRadius < 0")
+	}
+	if k.SetScrewRadius < 0 {
+		return nil, sdf.ErrMsg("SetScrewRadius < 0")
+	}
+	profile, err := Pulley2D(k)
+	if err != nil {
+		return nil, err
+	}
+	r := k.OutsideRadius()
+
+	z := 0.0
+	var hub sdf.SDF3
+	if k.HubRadius > 0 {
+		hub, err = sdf.Cylinder3D(k.HubLength, k.HubRadius, 0)
+		if err != nil {
+			return nil, err
+		}
+		hub = sdf.Transform3D(hub, sdf.Translate3d(v3.Vec{X: 0, Y: 0, Z: 0.5 * k.HubLength}))
+		z += k.HubLength
+	}
+
+	var flange0, flange1 sdf.SDF3
+	if k.FlangeHeight > 0 {
+		flange0, err = sdf.Cylinder3D(k.FlangeThickness, r+k.FlangeHeight, 0)
+		if err != nil {
+			return nil, err
+		}
+		flange1 = sdf.Transform3D(flange0, sdf.Translate3d(v3.Vec{X: 0, Y: 0, Z: z + k.FlangeThickness + k.Width + 0.5*k.FlangeThickness}))
+		flange0 = sdf.Transform3D(flange0, sdf.Translate3d(v3.Vec{X: 0, Y: 0, Z: z + 0.5*k.FlangeThickness}))
+		z += k.FlangeThickness
+	}
+
+	teeth := sdf.Extrude3D(profile, k.Width)
+	teeth = sdf.Transform3D(teeth, sdf.Translate3d(v3.Vec{X: 0, Y: 0, Z: z + 0.5*k.Width}))
+	z += k.Width
+	if k.FlangeHeight > 0 {
+		z += k.FlangeThickness
+	}
+
+	s := sdf.Union3D(hub, flange0, teeth, flange1)
+
+	if k.BoreRadius > 0 {
+		bore, err := sdf.Cylinder3D(z, k.BoreRadius, 0)
+		if err != nil {
+			return nil, err
+		}
+		bore = sdf.Transform3D(bore, sdf.Translate3d(v3.Vec{X: 0, Y: 0, Z: 0.5 * z}))
+		s = sdf.Difference3D(s, bore)
+	}
+
+	if k.SetScrewRadius > 0 {
+		// radial hole from the axis to the outside of the hub (or toothed section)
+		l, h := r, k.Width
+		zOfs := z - 0.5*k.Width
+		if k.FlangeHeight > 0 {
+			zOfs -= k.FlangeThickness
+		}
+		if k.HubRadius > 0 {
+			l, h = k.HubRadius, k.HubLength
+			zOfs = 0.5 * k.HubLength
+		}
+		if 2*k.SetScrewRadius >= h {
+			return nil, sdf.ErrMsg("set screw is too large")
+		}
+		screw, err := sdf.Cylinder3D(l, k.SetScrewRadius, 0)
+		if err != nil {
+			return nil, err
+		}
+		m := sdf.Translate3d(v3.Vec{X: 0.5 * l, Y: 0, Z: zOfs}).Mul(sdf.RotateY(0.5 * sdf.Pi))
+		s = sdf.Difference3D(s, sdf.Transform3D(screw, m))
+	}
+
+	return s, nil
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,116 @@
+//-----------------------------------------------------------------------------
+/*
+
+Timing Belt Pulley Tests
+
+*/
+//-----------------------------------------------------------------------------
+
+package obj
+
+import (
+	"math"
+	"testing"
+
+	v2 "github.com/gmlewis/sdfx/vec/v2"
+)
+
+//-----------------------------------------------------------------------------
+
+func gt2PulleyParms(t *testing.T) PulleyParms {
+	t.Helper()
+	belt, err := BeltLookup("gt2_2mm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return PulleyParms{
+		Belt:            belt,
+		NumberTeeth:     20,
+		Width:           7,
+		FlangeHeight:    1,
+		FlangeThickness: 1,
+		HubRadius:       6,
+		HubLength:       6,
+		BoreRadius:      2.5,
+		SetScrewRadius:  1.5,
+	}
+}
+
+func Test_Pulley3D_Errors(t *testing.T) {
+	if _, err := BeltLookup("gt3"); err == nil {
+		t.Error("unknown belt: expected an error")
+	}
+	tests := []struct {
+		name string
+		set  func(k *PulleyParms)
+	}{
+		{"Belt", func(k *PulleyParms) { k.Belt = nil }},
+		{"NumberTeeth", func(k *PulleyParms) { k.NumberTeeth = 5 }},
+		{"BoreRadius", func(k *PulleyParms) { k.BoreRadius = 6 }},
+		{"BoreRadius < 0", func(k *PulleyParms) { k.BoreRadius = -1 }},
+		{"Width", func(k *PulleyParms) { k.Width = 0 }},
+		{"FlangeHeight", func(k *PulleyParms) { k.FlangeHeight = -1 }},
+		{"FlangeThickness", func(k *PulleyParms) { k.FlangeThickness = 0 }},
+		{"HubRadius", func(k *PulleyParms) { k.HubRadius = 2 }},
+		{"HubLength", func(k *PulleyParms) { k.HubLength = 0 }},
+		{"SetScrewRadius", func(k *PulleyParms) { k.SetScrewRadius = -1 }},
+		{"set screw too large", func(k *PulleyParms) { k.SetScrewRadius = 3 }},
+	}
+	for _, test := range tests {
+		k := gt2PulleyParms(t)
+		test.set(&k)
+		if _, err := Pulley3D(&k); err == nil {
+			t.Errorf("%s: expected an error", test.name)
+		}
+	}
+	// the grooves of a small pulley overlap
+	k := gt2PulleyParms(t)
+	k.Belt = &BeltParms{Pitch: 2, PLD: 0.254, GrooveDepth: 0.75, GrooveRadius: 1.5}
+	k.BoreRadius = 0
+	if _, err := Pulley2D(&k); err == nil {
+		t.Error("groove overlap: expected an error")
+	}
+}
+
+func Test_Pulley3D(t *testing.T) {
+	// a 20 tooth GT2 pulley has a 12.73mm pitch diameter and a 12.22mm outside diameter
+	k := gt2PulleyParms(t)
+	if r := k.PitchRadius(); math.Abs(2*r-12.732) > 1e-3 {
+		t.Errorf("12.732 (expected) %f (actual) pitch diameter", 2*r)
+	}
+	r := k.OutsideRadius()
+	if math.Abs(2*r-12.224) > 1e-3 {
+		t.Errorf("12.224 (expected) %f (actual) outside diameter", 2*r)
+	}
+
+	// the grooves are on the +x axis and then every tooth pitch
+	profile, err := Pulley2D(&k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pitch := 2 * math.Pi / float64(k.NumberTeeth)
+	for i := 0; i < k.NumberTeeth; i++ {
+		a := float64(i) * pitch
+		groove := v2.Vec{X: math.Cos(a), Y: math.Sin(a)}.MulScalar(r - k.Belt.GrooveDepth + 0.05)
+		if d := profile.Evaluate(groove); d <= 0 {
+			t.Errorf("groove %d: %f", i, d)
+		}
+		tooth := v2.Vec{X: math.Cos(a + 0.5*pitch), Y: math.Sin(a + 0.5*pitch)}.MulScalar(r - 0.05)
+		if d := profile.Evaluate(tooth); d >= 0 {
+			t.Errorf("tooth %d: %f", i, d)
+		}
+	}
+
+	// hub, flange, teeth and flange are stacked from z = 0
+	s, err := Pulley3D(&k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bb := s.BoundingBox()
+	h := k.HubLength + 2*k.FlangeThickness + k.Width
+	if math.Abs(bb.Min.Z) > 1e-9 || math.Abs(bb.Max.Z-h) > 1e-9 || math.Abs(bb.Max.X-(r+k.FlangeHeight)) > 1e-9 {
+		t.Errorf("bounding box %v", bb)
+	}
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,309 @@
+//-----------------------------------------------------------------------------
+/*
+
+Compliant Joints: Cantilever Snap-Fits and Living Hinges
+
+Snap-fit design follows the usual cantilever beam analysis, see:
+"Snap-Fit Joints for Plastics - A Design Guide", Bayer MaterialScience.
+Strains are geometric, forces need the elastic modulus of the material.
+Units are mm, MPa and N.
+
+*/
+//-----------------------------------------------------------------------------
+
+package obj
+
+import (
+	"math"
+
+	"github.com/gmlewis/sdfx/sdf"
+	v2 "github.com/gmlewis/sdfx/vec/v2"
+	v3 "github.com/gmlewis/sdfx/vec/v3"
+)
+
+//-----------------------------------------------------------------------------
+// Cantilever Snap-Fits
+
+// SnapFitParms defines the parameters for a cantilever snap-fit hook.
+type SnapFitParms struct {
+	Length         float64 // length of the beam from the root to the hook
+	Thickness      float64 // thickness of the beam at the root
+	Taper          float64 // beam thickness at the hook / thickness at the root (0 for 1, no taper)
+	Width          float64 // width of the beam
+	Undercut       float64 // depth of the hook (the beam deflection needed to engage it)
+	EntryAngle     float64 // angle of the entry face to the beam axis (radians)
+	RetentionAngle float64 // angle of the retention face to the beam axis (radians, Pi/2 is permanent)
+	Clearance      float64 // clearance between the hook and the catch
+	Modulus        float64 // elastic modulus of the material (MPa, 0 for no force calculations)
+	Friction       float64 // coefficient of friction between the hook and the catch
+	MaxStrain      float64 // permissible strain of the material (0 for no check)
+}
+
+// SnapFitStress is the result of a snap-fit analysis.
+type SnapFitStress struct {
+	Strain          float64 // maximum strain in the beam at full deflection
+	Stress          float64 // maximum stress in the beam at full deflection (MPa)
+	DeflectionForce float64 // force at the hook to deflect the beam by the undercut (N)
+	MatingForce     float64 // force to push the hook into the catch (N)
+	SeparationForce float64 // force to pull the hook out of the catch (N, +Inf when self-locking)
+}
+
+// validate checks the snap-fit parameters.
+func (k *SnapFitParms) validate() error {
+	if k.Length <= 0 {
+		return sdf.ErrMsg("Length <= 0")
+	}
+	if k.Thickness <= 0 {
+		return sdf.ErrMsg("Thickness <= 0")
+	}
+	if k.Taper < 0 || k.Taper > 1 {
+		return sdf.ErrMsg("Taper must be in [0, 1]")
+	}
+	if k.Width <= 0 {
+		return sdf.ErrMsg("Width <= 0")
+	}
+	if k.Undercut <= 0 {
+		return sdf.ErrMsg("Undercut <= 0")
+	}
+	if k.EntryAngle <= 0 || k.EntryAngle >= 0.5*sdf.Pi {
+		return sdf.ErrMsg("EntryAngle must be in (0, Pi/2)")
+	}
+	if k.RetentionAngle <= 0 || k.RetentionAngle > 0.5*sdf.Pi {
+		return sdf.ErrMsg("RetentionAngle must be in (0, Pi/2]")
+	}
+	if k.Clearance < 0 {
+		return sdf.ErrMsg("Clearance < 0")
+	}
+	if k.Modulus < 0 {
+		return sdf.ErrMsg("Modulus < 0")
+	}
+	if k.Friction < 0 {
+		return sdf.ErrMsg("Friction < 0")
+	}
+	if k.MaxStrain < 0 {
+		return sdf.ErrMsg("MaxStrain < 0")
+	}
+	if k.Friction*math.Tan(k.EntryAngle) >= 1 {
+		return sdf.ErrMsg("the hook locks on entry, reduce EntryAngle or Friction")
+	}
+	return nil
+}
+
+// tipThickness returns the thickness of the beam at the hook.
+func (k *SnapFitParms) tipThickness() float64 {
+	if k.Taper == 0 {
+		return k.Thickness
+	}
+	return k.Taper * k.Thickness
+}
+
+// thickness returns the beam thickness at distance x from the root.
+func (k *SnapFitParms) thickness(x float64) float64 {
+	return k.Thickness + (k.tipThickness()-k.Thickness)*x/k.Length
+}
+
+// insertionForce returns the force along the beam axis to move a deflecting force
+// p over a face at the given angle to the axis.
+func insertionForce(p, mu, angle float64) float64 {
+	t := math.Tan(angle)
+	d := 1 - mu*t
+	if d <= 0 {
+		return math.Inf(1)
+	}
+	return p * (mu + t) / d
+}
+
+// Stress returns the strain, stress and forces for a snap-fit at full deflection.
+func (k *SnapFitParms) Stress() (*SnapFitStress, error) {
+	if err := k.validate(); err != nil {
+		return nil, err
+	}
+	// Tip deflection y under a tip load P is y = P/E * j, where j = integral((L-x)^2/I(x)).
+	// The strain at x is 6 * P * (L-x) / (E * w * h(x)^2).
+	const n = 256
+	l := k.Length
+	w := k.Width
+	dx := l / n
+	j := 0.0
+	for i := 0; i < n; i++ {
+		x := (float64(i) + 0.5) * dx
+		h := k.thickness(x)
+		j += (l - x) * (l - x) * 12 / (w * h * h * h) * dx
+	}
+	y := k.Undercut
+	strain := 0.0
+	for i := 0; i <= n; i++ {
+		x := float64(i) * dx
+		h := k.thickness(x)
+		strain = math.Max(strain, 6*y*(l-x)/(w*h*h*j))
+	}
+	s := SnapFitStress{Strain: strain}
+	if k.Modulus > 0 {
+		p := y * k.Modulus / j
+		s.Stress = strain * k.Modulus
+		s.DeflectionForce = p
+		s.MatingForce = insertionForce(p, k.Friction, k.EntryAngle)
+		s.SeparationForce = insertionForce(p, k.Friction, k.RetentionAngle)
+	}
+	if k.MaxStrain > 0 && strain > k.MaxStrain {
+		return &s, sdf.ErrMsg("strain exceeds MaxStrain, use a longer or thinner beam")
+	}
+	return &s, nil
+}
+
+// hookHeights returns the heights of the retention and entry faces along the beam axis.
+func (k *SnapFitParms) hookHeights() (float64, float64) {
+	return k.Undercut / math.Tan(k.RetentionAngle), k.Undercut / math.Tan(k.EntryAngle)
+}
+
+// SnapFitHook3D returns a cantilever snap-fit hook.
+// The root of the beam is on the z = 0 plane and the beam runs along +z.
+// The back of the beam is on the x = 0 plane, the hook projects along +x and
+// the beam deflects towards -x as it engages the catch.
+// The beam is centered on y = 0.
+func SnapFitHook3D(k *SnapFitParms) (sdf.SDF3, error) {
+	if _, err := k.Stress(); err != nil {
+		return nil, err
+	}
+	h0 := k.Thickness
+	h1 := k.tipThickness()
+	dr, de := k.hookHeights()
+	l := k.Length
+
+	// beam and hook profile in the x/z plane
+	p := sdf.NewPolygon()
+	p.Add(0, 0)
+	p.Add(h0, 0)
+	p.Add(h1, l)
+	p.Add(h1+k.Undercut, l+dr)
+	p.Add(h1, l+dr+de)
+	p.Add(0, l+dr+de)
+	profile, err := sdf.Polygon2D(p.Vertices())
+	if err != nil {
+		return nil, err
+	}
+	s := sdf.Extrude3D(profile, k.Width)
+	return sdf.Transform3D(s, sdf.RotateX(0.5*sdf.Pi)), nil
+}
+
+// SnapFitCatch3D returns the window in a wall that the hook engages.
+// The catch is in the frame of the assembled hook: the inside face of the wall
+// rests against the front of the beam and the wall extends along +x.
+// Subtract the catch from the wall.
+func SnapFitCatch3D(
+	k *SnapFitParms, // snap-fit parameters
+	wall float64, // wall thickness
+) (sdf.SDF3, error) {
+	if err := k.validate(); err != nil {
+		return nil, err
+	}
+	if wall <= 0 {
+		return nil, sdf.ErrMsg("wall <= 0")
+	}
+	if wall < k.Undercut {
+		return nil, sdf.ErrMsg("wall is thinner than the hook undercut")
+	}
+	h1 := k.tipThickness()
+	dr, de := k.hookHeights()
+	c := k.Clearance
+	// extend the window through the wall to avoid coincident surfaces
+	ext := 0.1 * wall
+	size := v3.Vec{
+		X: wall + 2*ext,
+		Y: k.Width + 2*c,
+		Z: dr + de + 2*c,
+	}
+	s, err := sdf.Box3D(size, 0)
+	if err != nil {
+		return nil, err
+	}
+	ofs := v3.Vec{
+		X: h1 + 0.5*wall,
+		Y: 0,
+		Z: k.Length - c + 0.5*size.Z,
+	}
+	return sdf.Transform3D(s, sdf.Translate3d(ofs)), nil
+}
+
+//-----------------------------------------------------------------------------
+// Living Hinges
+
+// LivingHingeParms defines the parameters for a living hinge joining two flat-printed panels.
+type LivingHingeParms struct {
+	Length    float64 // length of the thin hinge web (across the fold)
+	Width     float64 // width of the hinge (along the fold axis)
+	Thickness float64 // thickness of the hinge web
+	Panel     float64 // thickness of the joined panels
+	Land      float64 // length of the full thickness land on each side of the web
+	Fillet    float64 // fillet radius between the web and the lands
+	Angle     float64 // maximum fold angle (radians, 0 for Pi)
+	MaxStrain float64 // permissible strain of the material (0 for no check)
+}
+
+// bendLength returns the length of the hinge web that bends.
+// The fillets stiffen the ends of the web.
+func (k *LivingHingeParms) bendLength() float64 {
+	return k.Length - 2*k.Fillet
+}
+
+// Strain returns the strain in the hinge web folded through the given angle.
+// The web between the fillets is assumed to bend into a circular arc.
+func (k *LivingHingeParms) Strain(angle float64) float64 {
+	return 0.5 * k.Thickness * angle / k.bendLength()
+}
+
+// LivingHinge3D returns a living hinge.
+// The hinge is printed flat with the web on the z = 0 plane, the fold axis
+// is along y and the lands extend to x = +/-(Length/2 + Land).
+func LivingHinge3D(k *LivingHingeParms) (sdf.SDF3, error) {
+	if k.Length <= 0 {
+		return nil, sdf.ErrMsg("Length <= 0")
+	}
+	if k.Width <= 0 {
+		return nil, sdf.ErrMsg("Width <= 0")
+	}
+	if k.Thickness <= 0 {
+		return nil, sdf.ErrMsg("Thickness <= 0")
+	}
+	if k.Panel <= k.Thickness {
+		return nil, sdf.ErrMsg("Panel <= Thickness")
+	}
+	if k.Land <= 0 {
+		return nil, sdf.ErrMsg("Land <= 0")
+	}
+	if k.Fillet < 0 {
+		return nil, sdf.ErrMsg("Fillet < 0")
+	}
+	if 2*k.Fillet >= k.Length {
+		return nil, sdf.ErrMsg("fillets are longer than the hinge web")
+	}
+	if k.Fillet > k.Panel-k.Thickness {
+		return nil, sdf.ErrMsg("Fillet > Panel - Thickness")
+	}
+	if k.Angle < 0 || k.Angle > sdf.Pi {
+		return nil, sdf.ErrMsg("Angle must be in [0, Pi]")
+	}
+	if k.MaxStrain < 0 {
+		return nil, sdf.ErrMsg("MaxStrain < 0")
+	}
+	angle := k.Angle
+	if angle == 0 {
+		angle = sdf.Pi
+	}
+	if k.MaxStrain > 0 && k.Strain(angle) > k.MaxStrain {
+		return nil, sdf.ErrMsg("strain exceeds MaxStrain, use a longer or thinner hinge")
+	}
+
+	// profile in the x/z plane: a slab with a groove cut down to the web
+	x := 0.5*k.Length + k.Land
+	slab := sdf.Box2D(v2.Vec{X: 2 * x, Y: k.Panel}, 0)
+	slab = sdf.Transform2D(slab, sdf.Translate2d(v2.Vec{X: 0, Y: 0.5 * k.Panel}))
+	// the groove extends above the panel so only the bottom corners are filleted
+	groove := sdf.Box2D(v2.Vec{X: k.Length, Y: 2 * (k.Panel - k.Thickness)}, k.Fillet)
+	groove = sdf.Transform2D(groove, sdf.Translate2d(v2.Vec{X: 0, Y: k.Panel}))
+	profile := sdf.Difference2D(slab, groove)
+	s := sdf.Extrude3D(profile, k.Width)
+	return sdf.Transform3D(s, sdf.RotateX(0.5*sdf.Pi)), nil
+}
+
+//-----------------------------------------------------------------------------
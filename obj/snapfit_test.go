@@ -0,0 +1,90 @@
+//-----------------------------------------------------------------------------
+/*
+
+Compliant Joint Tests
+
+*/
+//-----------------------------------------------------------------------------
+
+package obj
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gmlewis/sdfx/sdf"
+)
+
+//-----------------------------------------------------------------------------
+
+func Test_SnapFit_Stress(t *testing.T) {
+	k := SnapFitParms{
+		Length:         15,
+		Thickness:      2,
+		Width:          6,
+		Undercut:       1.2,
+		EntryAngle:     sdf.DtoR(30),
+		RetentionAngle: sdf.DtoR(90),
+		Modulus:        2200,
+		Friction:       0.3,
+	}
+	s, err := k.Stress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A uniform cantilever with tip deflection y has a root strain of 1.5*h*y/L^2
+	// and a tip force of E*w*h^3*y/(4*L^3).
+	l, h, y := k.Length, k.Thickness, k.Undercut
+	strain := 1.5 * h * y / (l * l)
+	force := k.Modulus * k.Width * h * h * h * y / (4 * l * l * l)
+	tests := []struct {
+		name     string
+		expected float64
+		actual   float64
+	}{
+		{"strain", strain, s.Strain},
+		{"stress", k.Modulus * strain, s.Stress},
+		{"deflection force", force, s.DeflectionForce},
+		{"mating force", force * (0.3 + math.Tan(k.EntryAngle)) / (1 - 0.3*math.Tan(k.EntryAngle)), s.MatingForce},
+	}
+	for _, test := range tests {
+		if math.Abs(test.actual-test.expected) > 1e-4*test.expected {
+			t.Errorf("%s: %g (expected) %g (actual)", test.name, test.expected, test.actual)
+		}
+	}
+	// a 90 degree retention face is self-locking
+	if !math.IsInf(s.SeparationForce, 1) {
+		t.Errorf("separation force %g", s.SeparationForce)
+	}
+	// the strain limit is checked
+	k.MaxStrain = 0.9 * strain
+	if _, err := k.Stress(); err == nil {
+		t.Error("expected a strain error")
+	}
+}
+
+func Test_LivingHinge_Strain(t *testing.T) {
+	k := LivingHingeParms{
+		Length:    4,
+		Width:     10,
+		Thickness: 0.4,
+		Panel:     2,
+		Land:      3,
+		Fillet:    0.5,
+	}
+	// the web between the fillets bends through the fold angle
+	if e, x := 0.5*k.Thickness*sdf.Pi/(k.Length-2*k.Fillet), k.Strain(sdf.Pi); math.Abs(e-x) > 1e-12 {
+		t.Errorf("%f (expected) %f (actual)", e, x)
+	}
+	// LivingHinge3D checks the same strain
+	k.MaxStrain = 1.01 * k.Strain(sdf.Pi)
+	if _, err := LivingHinge3D(&k); err != nil {
+		t.Error(err)
+	}
+	k.MaxStrain = 0.99 * k.Strain(sdf.Pi)
+	if _, err := LivingHinge3D(&k); err == nil {
+		t.Error("expected a strain error")
+	}
+}
+
+//-----------------------------------------------------------------------------
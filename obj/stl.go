@@ -66,6 +66,8 @@ func (t *triMeshSdf) BoundingBox() sdf.Box3 {
 //
 // WARNING: It will only work on non-intersecting closed-surface(s) meshes.
 // NOTE: Fix using blender for intersecting surfaces: Edit mode > P > By loose parts > Add boolean modifier to join them
+//
+// See Mesh3D for an exact (and usually faster) alternative.
 func ImportTriMesh(tris chan *render.Triangle3, numNeighbors, minChildren, maxChildren int) sdf.SDF3 {
 	m := &triMeshSdf{
 		rtree:        nil,
@@ -0,0 +1,170 @@
+//-----------------------------------------------------------------------------
+/*
+
+Worm Gears
+
+A worm is a screw with an involute rack profile in its axial section.
+The worm wheel is a helical gear with the helix angle equal to the lead angle
+of the worm and a throat that wraps around the worm.
+
+*/
+//-----------------------------------------------------------------------------
+
+package obj
+
+import (
+	"math"
+
+	"github.com/gmlewis/sdfx/sdf"
+	v2 "github.com/gmlewis/sdfx/vec/v2"
+)
+
+//-----------------------------------------------------------------------------
+
+// WormGearParms defines the parameters for a matched worm and worm wheel.
+type WormGearParms struct {
+	Module        float64 // axial module of the worm (transverse module of the wheel)
+	Starts        int     // number of thread starts on the worm
+	Ratio         int     // gear ratio (wheel teeth / worm starts)
+	PressureAngle float64 // axial pressure angle (radians)
+	Diameter      float64 // pitch diameter of the worm
+	Length        float64 // length of the worm
+	FaceWidth     float64 // face width of the wheel
+	Backlash      float64 // backlash expressed as per-tooth distance at pitch circumference
+	Clearance     float64 // additional root clearance
+	Facets        int     // number of facets for involute flank
+}
+
+// validate checks the worm gear parameters.
+func (k *WormGearParms) validate() error {
+	if k.Module <= 0 {
+		return sdf.ErrMsg("Module <= 0")
+	}
+	if k.Starts <= 0 {
+		return sdf.ErrMsg("Starts <= 0")
+	}
+	if k.Ratio <= 0 {
+		return sdf.ErrMsg("Ratio <= 0")
+	}
+	if k.PressureAngle <= 0 {
+		return sdf.ErrMsg("PressureAngle <= 0")
+	}
+	if k.Diameter <= 2*(2*k.Module+k.Clearance) {
+		return sdf.ErrMsg("Diameter is too small for the thread depth")
+	}
+	if k.Length <= 0 {
+		return sdf.ErrMsg("Length <= 0")
+	}
+	if k.FaceWidth <= 0 {
+		return sdf.ErrMsg("FaceWidth <= 0")
+	}
+	if k.Backlash < 0 {
+		return sdf.ErrMsg("Backlash < 0")
+	}
+	if k.Clearance < 0 {
+		return sdf.ErrMsg("Clearance < 0")
+	}
+	if k.Facets <= 0 {
+		return sdf.ErrMsg("Facets <= 0")
+	}
+	return nil
+}
+
+// WheelTeeth returns the number of teeth on the worm wheel.
+func (k *WormGearParms) WheelTeeth() int {
+	return k.Ratio * k.Starts
+}
+
+// LeadAngle returns the lead angle of the worm at the pitch diameter.
+func (k *WormGearParms) LeadAngle() float64 {
+	return math.Atan(float64(k.Starts) * k.Module / k.Diameter)
+}
+
+// CenterDistance returns the distance between the worm and wheel axes.
+func (k *WormGearParms) CenterDistance() float64 {
+	return 0.5 * (k.Diameter + float64(k.WheelTeeth())*k.Module)
+}
+
+//-----------------------------------------------------------------------------
+
+// Worm3D returns a worm (right hand thread).
+// The worm is centered on the origin with its axis along z.
+func Worm3D(k *WormGearParms) (sdf.SDF3, error) {
+	if err := k.validate(); err != nil {
+		return nil, err
+	}
+	pitch := sdf.Pi * k.Module // axial pitch
+	r := 0.5 * k.Diameter
+	rTip := r + k.Module
+	rRoot := r - k.Module - k.Clearance
+	t := math.Tan(k.PressureAngle)
+	// half widths of the tooth at the pitch line and tip, and of the gap at the root
+	w := 0.25*pitch - 0.5*k.Backlash
+	wTip := w - k.Module*t
+	wRoot := 0.5*pitch - (w + (k.Module+k.Clearance)*t)
+	if wTip <= 0 {
+		return nil, sdf.ErrMsg("worm teeth are pointed, reduce PressureAngle")
+	}
+	if wRoot <= 0 {
+		return nil, sdf.ErrMsg("worm tooth gap is closed, reduce PressureAngle or Clearance")
+	}
+
+	rack := sdf.NewPolygon()
+	rack.Add(pitch, 0)
+	rack.Add(pitch, rTip)
+	rack.Add(pitch-wTip, rTip)
+	rack.Add(pitch/2+wRoot, rRoot)
+	rack.Add(pitch/2-wRoot, rRoot)
+	rack.Add(wTip, rTip)
+	rack.Add(-wTip, rTip)
+	rack.Add(-pitch/2+wRoot, rRoot)
+	rack.Add(-pitch/2-wRoot, rRoot)
+	rack.Add(-pitch+wTip, rTip)
+	rack.Add(-pitch, rTip)
+	rack.Add(-pitch, 0)
+	profile, err := sdf.Polygon2D(rack.Vertices())
+	if err != nil {
+		return nil, err
+	}
+	return sdf.Screw3D(profile, k.Length, 0, pitch, k.Starts)
+}
+
+// WormWheel3D returns a worm wheel to mate with the worm from Worm3D.
+// The wheel is centered on the origin with its axis along z.
+// The worm sits at CenterDistance along the x-axis with its axis parallel to the y-axis.
+func WormWheel3D(k *WormGearParms) (sdf.SDF3, error) {
+	if err := k.validate(); err != nil {
+		return nil, err
+	}
+	// the wheel is a helical gear with a helix angle equal to the worm lead angle
+	lead := k.LeadAngle()
+	c := math.Cos(lead)
+	wheel, err := HelicalGear3D(&HelicalGearParms{
+		Gear: InvoluteGearParms{
+			NumberTeeth:   k.WheelTeeth(),
+			Module:        k.Module * c,
+			PressureAngle: math.Atan(math.Tan(k.PressureAngle) * c),
+			Backlash:      k.Backlash * c,
+			Clearance:     k.Clearance,
+			Facets:        k.Facets,
+		},
+		HelixAngle: lead,
+		Width:      k.FaceWidth,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// the throat: remove a torus of the worm root radius, centred on the worm axis
+	a := k.CenterDistance()
+	throat, err := sdf.Circle2D(0.5*k.Diameter - k.Module)
+	if err != nil {
+		return nil, err
+	}
+	torus, err := sdf.Revolve3D(sdf.Transform2D(throat, sdf.Translate2d(v2.Vec{X: a, Y: 0})))
+	if err != nil {
+		return nil, err
+	}
+	return sdf.Difference3D(wheel, torus), nil
+}
+
+//-----------------------------------------------------------------------------
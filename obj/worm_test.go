@@ -0,0 +1,123 @@
+//-----------------------------------------------------------------------------
+/*
+
+Worm Gear Tests
+
+*/
+//-----------------------------------------------------------------------------
+
+package obj
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gmlewis/sdfx/sdf"
+	v3 "github.com/gmlewis/sdfx/vec/v3"
+)
+
+//-----------------------------------------------------------------------------
+
+func wormGearParms() WormGearParms {
+	return WormGearParms{
+		Module:        1,
+		Starts:        2,
+		Ratio:         15,
+		PressureAngle: sdf.DtoR(20),
+		Diameter:      12,
+		Length:        20,
+		FaceWidth:     6,
+		Facets:        5,
+	}
+}
+
+func Test_Worm3D_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		set  func(k *WormGearParms)
+	}{
+		{"Module", func(k *WormGearParms) { k.Module = 0 }},
+		{"Starts", func(k *WormGearParms) { k.Starts = 0 }},
+		{"Ratio", func(k *WormGearParms) { k.Ratio = 0 }},
+		{"PressureAngle", func(k *WormGearParms) { k.PressureAngle = 0 }},
+		{"Diameter", func(k *WormGearParms) { k.Diameter = 4 }},
+		{"Length", func(k *WormGearParms) { k.Length = 0 }},
+		{"FaceWidth", func(k *WormGearParms) { k.FaceWidth = 0 }},
+		{"Backlash", func(k *WormGearParms) { k.Backlash = -0.1 }},
+		{"Clearance", func(k *WormGearParms) { k.Clearance = -0.1 }},
+		{"Facets", func(k *WormGearParms) { k.Facets = 0 }},
+	}
+	for _, test := range tests {
+		k := wormGearParms()
+		test.set(&k)
+		if _, err := Worm3D(&k); err == nil {
+			t.Errorf("%s: expected a worm error", test.name)
+		}
+		if _, err := WormWheel3D(&k); err == nil {
+			t.Errorf("%s: expected a wheel error", test.name)
+		}
+	}
+	// the worm thread profile is checked
+	k := wormGearParms()
+	k.PressureAngle = sdf.DtoR(45)
+	if _, err := Worm3D(&k); err == nil {
+		t.Error("pointed teeth: expected an error")
+	}
+}
+
+func Test_Worm3D(t *testing.T) {
+	k := wormGearParms()
+	if k.WheelTeeth() != 30 {
+		t.Errorf("30 wheel teeth (expected) %d (actual)", k.WheelTeeth())
+	}
+	// tan(lead angle) = starts * module / diameter
+	if lead := k.LeadAngle(); math.Abs(math.Tan(lead)-1.0/6.0) > 1e-12 {
+		t.Errorf("lead angle %f", sdf.RtoD(lead))
+	}
+	if a := k.CenterDistance(); math.Abs(a-21) > 1e-12 {
+		t.Errorf("21 (expected) %f (actual)", a)
+	}
+
+	worm, err := Worm3D(&k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the root is solid and the tips are clear
+	r := 0.5 * k.Diameter
+	for z := -8.0; z <= 8; z += 0.5 {
+		if d := worm.Evaluate(v3.Vec{X: r - k.Module - 0.1, Y: 0, Z: z}); d >= 0 {
+			t.Errorf("root at z = %f: %f", z, d)
+		}
+		if d := worm.Evaluate(v3.Vec{X: r + k.Module + 0.1, Y: 0, Z: z}); d <= 0 {
+			t.Errorf("tip at z = %f: %f", z, d)
+		}
+	}
+	// the pitch line alternates between thread and gap every quarter of the axial pitch (2 starts)
+	pitch := sdf.Pi * k.Module
+	inside := 0
+	for i := 0; i < 8; i++ {
+		z := (float64(i) + 0.5) * 0.25 * pitch
+		if worm.Evaluate(v3.Vec{X: r, Y: 0, Z: z}) < 0 {
+			inside++
+		}
+	}
+	if inside != 4 {
+		t.Errorf("4 (expected) %d (actual) pitch line points inside the thread", inside)
+	}
+
+	// the wheel pitch circle touches the worm pitch cylinder
+	wheel, err := WormWheel3D(&k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rw := k.CenterDistance() - r
+	tip := rw + k.Module*math.Cos(k.LeadAngle())
+	if bb := wheel.BoundingBox(); !bb.Max.Equals(v3.Vec{X: tip, Y: tip, Z: 0.5 * k.FaceWidth}, 1e-9) {
+		t.Errorf("bounding box %v", bb)
+	}
+	if math.Abs(rw-0.5*float64(k.WheelTeeth())*k.Module) > 1e-12 {
+		t.Errorf("wheel pitch radius %f", rw)
+	}
+}
+
+//-----------------------------------------------------------------------------
@@ -86,3 +86,36 @@ func write3MF(wg *sync.WaitGroup, path string) (chan<- []*Triangle3, error) {
 }
 
 //-----------------------------------------------------------------------------
+
+// SaveMesh3MF writes an indexed triangle mesh to a 3MF file.
+func SaveMesh3MF(path string, m *Mesh) error {
+	f, err := go3mf.CreateWriter(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var model go3mf.Model
+	var mesh go3mf.Mesh
+
+	// add the mesh to the model
+	obj := &go3mf.Object{Mesh: &mesh}
+	obj.ID = model.Resources.UnusedID()
+	model.Resources.Objects = append(model.Resources.Objects, obj)
+	model.Build.Items = append(model.Build.Items, &go3mf.Item{ObjectID: obj.ID})
+
+	// the mesh is already indexed, copy the vertices and triangles
+	mesh.Vertices.Vertex = make([]go3mf.Point3D, len(m.Vertex))
+	for i, v := range m.Vertex {
+		mesh.Vertices.Vertex[i] = toPoint3D(v)
+	}
+	mesh.Triangles.Triangle = make([]go3mf.Triangle, len(m.Triangle))
+	for i, t := range m.Triangle {
+		mesh.Triangles.Triangle[i] = go3mf.Triangle{V1: uint32(t[0]), V2: uint32(t[1]), V3: uint32(t[2])}
+	}
+
+	// encode and write out the file
+	return f.Encode(&model)
+}
+
+//-----------------------------------------------------------------------------
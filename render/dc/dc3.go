@@ -0,0 +1,747 @@
+//-----------------------------------------------------------------------------
+/*
+
+Adaptive Dual Contouring
+
+Convert an SDF3 to a triangle mesh.
+
+1) An octree subdivides space, skipping the octants that are too far from the
+surface to contain any of it, down to the finest cells that the surface passes
+through.
+
+2) Within each cell the edges crossed by the surface are grouped into
+components, one for each sheet of the surface passing through the cell. Each
+component gets a vertex placed by minimising a QEF (quadratic error function)
+of the surface planes at its edge crossings. This keeps sharp edges and corners.
+
+3) Going back up the octree, the cells of an octant are collapsed into a single
+vertex if the surface in the octant is a single flat enough sheet. The QEFs of
+the cells are summed, and the RMS distance from the collapsed vertex to the
+surface planes must be less than Simplify times the cell size. Flat regions end
+up with a few large triangles and curved regions keep the finest cells.
+
+4) Each grid edge crossed by the surface produces a quad joining the vertices
+of the 4 cells around the edge. Quads with collapsed vertices become triangles
+(2 cells in the same octant) or are dropped (3 or 4 cells in the same octant).
+
+The components are found by pairing the crossed edges on each cell face. The
+pairing only depends on the values at the face corners (ambiguous faces use the
+asymptotic decider) so neighbouring cells agree on it. This makes each vertex
+the centre of a closed fan of quads, so the mesh is always closed and manifold.
+
+An octant is only collapsed if this is topologically safe. The octant must have
+a single sheet for its corner values, and its 2x2x2 children must have the same
+crossed edges and face pairings on the octant boundary. Inside the octant the
+sheets of the children must join into a single disk. The collapsed mesh is then
+closed and manifold like the uncollapsed mesh.
+
+See: Ju, Losasso, Schaefer, Warren, "Dual Contouring of Hermite Data", 2002.
+See: Schaefer, Ju, Warren, "Manifold Dual Contouring", 2007.
+
+*/
+//-----------------------------------------------------------------------------
+
+package dc
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/gmlewis/sdfx/render"
+	"github.com/gmlewis/sdfx/sdf"
+	"github.com/gmlewis/sdfx/vec/conv"
+	v3 "github.com/gmlewis/sdfx/vec/v3"
+	"github.com/gmlewis/sdfx/vec/v3i"
+)
+
+//-----------------------------------------------------------------------------
+
+// DualContouring renders using adaptive octree dual contouring.
+// Sharp edges and corners are preserved and the output mesh is manifold.
+type DualContouring struct {
+	meshCells int // number of cells on the longest axis of bounding box. e.g 200
+	// RCond [0, 1) is the relative singular value below which the QEF is treated as flat.
+	// Lower values keep shallower features sharp but can be unstable on curved surfaces.
+	RCond float64
+	// Simplify is the RMS QEF error (relative to the cell size) below which cells are collapsed.
+	// Zero disables the collapse, giving a mesh at the finest resolution.
+	Simplify float64
+}
+
+// NewDualContouring returns a Render3 object.
+func NewDualContouring(meshCells int) *DualContouring {
+	return &DualContouring{
+		meshCells: meshCells,
+		RCond:     0.1,
+		Simplify:  0.02,
+	}
+}
+
+// grid returns the origin, cell size and number of cells of the sampling grid.
+// The grid is a cell larger than the bounding box so the surface can't cross its boundary.
+func (r *DualContouring) grid(s sdf.SDF3) (v3.Vec, float64, v3i.Vec) {
+	bb := s.BoundingBox()
+	size := bb.Size()
+	resolution := size.MaxComponent() / float64(r.meshCells)
+	cells := conv.V3ToV3i(size.DivScalar(resolution).Ceil().AddScalar(1))
+	origin := bb.Center().Sub(conv.V3iToV3(cells).MulScalar(0.5 * resolution))
+	return origin, resolution, cells
+}
+
+// Info returns a string describing the rendered volume.
+func (r *DualContouring) Info(s sdf.SDF3) string {
+	_, resolution, cells := r.grid(s)
+	return fmt.Sprintf("%dx%dx%d, resolution %.2f", cells.X, cells.Y, cells.Z, resolution)
+}
+
+// Render produces a 3d triangle mesh over the bounding volume of an sdf3.
+func (r *DualContouring) Render(s sdf.SDF3, output chan<- []*render.Triangle3) {
+	origin, resolution, cells := r.grid(s)
+	a := &adc{
+		s:          s,
+		origin:     origin,
+		resolution: resolution,
+		cells:      cells,
+		rCond:      r.RCond,
+		simplify:   r.Simplify * resolution,
+		cache:      make(map[v3i.Vec]float64),
+		leaf:       make(map[v3i.Vec]*adcCell),
+	}
+	// find the cells containing the surface
+	n := 1
+	for n < max(cells.X, cells.Y, cells.Z) {
+		n <<= 1
+	}
+	a.subdivide(v3i.Vec{}, n)
+	// place the vertices and generate the quads
+	for _, c := range a.order {
+		a.placeVertices(c)
+	}
+	// collapse the flat regions
+	if a.simplify > 0 {
+		if cl, ok := a.collapse(v3i.Vec{}, n); ok && cl != nil {
+			a.cluster(cl)
+		}
+	}
+	for _, c := range a.order {
+		if t := a.quads(c); len(t) > 0 {
+			output <- t
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+// Corner i of a cell is at offset (i>>2&1, i>>1&1, i&1).
+// Edge e of a cell is along axis e/4, the other two coordinates are given by e%4.
+
+// adcEdge is the pair of corners for each cell edge.
+var adcEdge [12][2]int
+
+// adcFace is the 4 edges (in order around the face) for each cell face.
+// Face f is normal to axis f/2, at coordinate f%2.
+var adcFace [6][4]int
+
+// adcFaceCorner is the 4 corners (in order around the face) for each cell face.
+var adcFaceCorner [6][4]int
+
+// adcCorner returns the corner index for a cell offset.
+func adcCorner(x, y, z int) int {
+	return x<<2 | y<<1 | z
+}
+
+// adcEdgeIndex returns the index of the edge along an axis at the given (cyclic) u, v coordinates.
+func adcEdgeIndex(axis, u, v int) int {
+	return axis*4 + u + 2*v
+}
+
+// adcOffset returns a 3d offset from coordinates along the axis and its cyclic u and v axes.
+func adcOffset(axis, a, u, v int) [3]int {
+	var p [3]int
+	p[axis] = a
+	p[(axis+1)%3] = u
+	p[(axis+2)%3] = v
+	return p
+}
+
+func init() {
+	for axis := 0; axis < 3; axis++ {
+		for v := 0; v < 2; v++ {
+			for u := 0; u < 2; u++ {
+				e := adcEdgeIndex(axis, u, v)
+				p0 := adcOffset(axis, 0, u, v)
+				p1 := adcOffset(axis, 1, u, v)
+				adcEdge[e] = [2]int{adcCorner(p0[0], p0[1], p0[2]), adcCorner(p1[0], p1[1], p1[2])}
+			}
+		}
+	}
+	for axis := 0; axis < 3; axis++ {
+		for w := 0; w < 2; w++ {
+			f := axis*2 + w
+			// the face lies in the plane of the u and v axes
+			ua := (axis + 1) % 3
+			va := (axis + 2) % 3
+			// corners (u,v) = (0,0), (1,0), (1,1), (0,1)
+			uv := [4][2]int{{0, 0}, {1, 0}, {1, 1}, {0, 1}}
+			for i, c := range uv {
+				p := adcOffset(axis, w, c[0], c[1])
+				adcFaceCorner[f][i] = adcCorner(p[0], p[1], p[2])
+			}
+			// edges between consecutive corners
+			// (an edge along ua has cyclic axes (va, axis), an edge along va has cyclic axes (axis, ua))
+			adcFace[f][0] = adcEdgeIndex(ua, 0, w)
+			adcFace[f][1] = adcEdgeIndex(va, w, 1)
+			adcFace[f][2] = adcEdgeIndex(ua, 1, w)
+			adcFace[f][3] = adcEdgeIndex(va, w, 0)
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+// adcCell is a grid cell that contains the surface.
+type adcCell struct {
+	i      v3i.Vec     // cell index
+	value  [8]float64  // corner values
+	inside uint8       // corner inside bits
+	vertex [12]int     // vertex index for each crossed edge (component vertex)
+	sheets int         // number of surface components
+	qef    dcQefSolver // QEF for a single component
+}
+
+// adc is the state of an adaptive dual contouring render.
+type adc struct {
+	s          sdf.SDF3
+	origin     v3.Vec              // grid origin
+	resolution float64             // cell size
+	cells      v3i.Vec             // number of cells
+	rCond      float64             // QEF singular value threshold
+	simplify   float64             // RMS QEF error for collapsing cells
+	cache      map[v3i.Vec]float64 // grid point values
+	leaf       map[v3i.Vec]*adcCell
+	order      []*adcCell // surface cells in the order they were found
+	vertex     []v3.Vec   // vertex positions
+}
+
+// point returns the position of a grid point.
+func (a *adc) point(i v3i.Vec) v3.Vec {
+	return a.origin.Add(conv.V3iToV3(i).MulScalar(a.resolution))
+}
+
+// evaluate returns the SDF value at a grid point.
+func (a *adc) evaluate(i v3i.Vec) float64 {
+	if d, ok := a.cache[i]; ok {
+		return d
+	}
+	d := a.s.Evaluate(a.point(i))
+	a.cache[i] = d
+	return d
+}
+
+// subdivide recursively finds the cells that contain the surface.
+func (a *adc) subdivide(i v3i.Vec, n int) {
+	if i.X >= a.cells.X || i.Y >= a.cells.Y || i.Z >= a.cells.Z {
+		// outside the grid
+		return
+	}
+	// skip the octant if the surface is further away than the corners
+	h := 0.5 * float64(n) * a.resolution
+	center := a.point(i).AddScalar(h)
+	if math.Abs(a.s.Evaluate(center)) >= h*math.Sqrt(3) {
+		return
+	}
+	if n > 1 {
+		n >>= 1
+		for j := 0; j < 8; j++ {
+			a.subdivide(i.Add(v3i.Vec{(j >> 2 & 1) * n, (j >> 1 & 1) * n, (j & 1) * n}), n)
+		}
+		return
+	}
+	c := &adcCell{i: i}
+	for j := 0; j < 8; j++ {
+		c.value[j] = a.evaluate(i.Add(v3i.Vec{j >> 2 & 1, j >> 1 & 1, j & 1}))
+		if c.value[j] < 0 {
+			c.inside |= 1 << j
+		}
+	}
+	if c.inside == 0 || c.inside == 255 {
+		return
+	}
+	a.leaf[i] = c
+	a.order = append(a.order, c)
+}
+
+//-----------------------------------------------------------------------------
+
+// adcFacePairs returns the pairs of crossed edges joined by the surface on a face.
+// The corner values are in order around the face, and edge k joins corner k and k+1.
+func adcFacePairs(v [4]float64) [][2]int {
+	var crossed []int
+	for k := 0; k < 4; k++ {
+		if (v[k] < 0) != (v[(k+1)%4] < 0) {
+			crossed = append(crossed, k)
+		}
+	}
+	switch len(crossed) {
+	case 2:
+		return [][2]int{{crossed[0], crossed[1]}}
+	case 4:
+		// ambiguous face: corners 0 and 2 have the same sign, as do 1 and 3
+		// asymptotic decider: the sign of the bilinear interpolant at the saddle point
+		saddle := (v[0]*v[2] - v[1]*v[3]) / (v[0] + v[2] - v[1] - v[3])
+		if (saddle < 0) == (v[0] < 0) {
+			// corners 0 and 2 are connected, separate corners 1 and 3
+			return [][2]int{{0, 1}, {2, 3}}
+		}
+		// separate corners 0 and 2
+		return [][2]int{{3, 0}, {1, 2}}
+	}
+	return nil
+}
+
+// crossed returns true if the surface crosses a cell edge.
+func (c *adcCell) crossed(e int) bool {
+	return (c.inside>>adcEdge[e][0])&1 != (c.inside>>adcEdge[e][1])&1
+}
+
+// components groups the crossed edges of a cell into surface components.
+// It returns the component number for each crossed edge (-1 for edges not crossed).
+func (c *adcCell) components() ([12]int, int) {
+	// union-find over the edges
+	var parent [12]int
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		for parent[i] != i {
+			parent[i] = parent[parent[i]]
+			i = parent[i]
+		}
+		return i
+	}
+	union := func(i, j int) {
+		parent[find(i)] = find(j)
+	}
+	for f := 0; f < 6; f++ {
+		fc := adcFaceCorner[f]
+		for _, pair := range adcFacePairs([4]float64{c.value[fc[0]], c.value[fc[1]], c.value[fc[2]], c.value[fc[3]]}) {
+			union(adcFace[f][pair[0]], adcFace[f][pair[1]])
+		}
+	}
+	var comp [12]int
+	n := 0
+	index := make(map[int]int)
+	for e := 0; e < 12; e++ {
+		comp[e] = -1
+		if !c.crossed(e) {
+			continue
+		}
+		r := find(e)
+		k, ok := index[r]
+		if !ok {
+			k = n
+			index[r] = k
+			n++
+		}
+		comp[e] = k
+	}
+	return comp, n
+}
+
+// zeroCrossing returns the point on a cell edge where the SDF is zero.
+func (a *adc) zeroCrossing(p0, p1 v3.Vec, d0, d1 float64) v3.Vec {
+	// false position (Illinois variant)
+	t0, t1 := 0.0, 1.0
+	side := 0
+	t := 0.5
+	for i := 0; i < 8; i++ {
+		t = (t0*d1 - t1*d0) / (d1 - d0)
+		d := a.s.Evaluate(p0.Add(p1.Sub(p0).MulScalar(t)))
+		if d == 0 {
+			break
+		}
+		if (d < 0) == (d0 < 0) {
+			t0, d0 = t, d
+			if side == -1 {
+				d1 /= 2
+			}
+			side = -1
+		} else {
+			t1, d1 = t, d
+			if side == 1 {
+				d0 /= 2
+			}
+			side = 1
+		}
+	}
+	return p0.Add(p1.Sub(p0).MulScalar(t))
+}
+
+// placeVertices places a vertex for each surface component of a cell.
+func (a *adc) placeVertices(c *adcCell) {
+	comp, n := c.components()
+	qef := make([]dcQefSolver, n)
+	base := a.point(c.i)
+	for e := 0; e < 12; e++ {
+		if comp[e] < 0 {
+			continue
+		}
+		k0, k1 := adcEdge[e][0], adcEdge[e][1]
+		p0 := base.Add(v3.Vec{X: float64(k0 >> 2 & 1), Y: float64(k0 >> 1 & 1), Z: float64(k0 & 1)}.MulScalar(a.resolution))
+		p1 := base.Add(v3.Vec{X: float64(k1 >> 2 & 1), Y: float64(k1 >> 1 & 1), Z: float64(k1 & 1)}.MulScalar(a.resolution))
+		p := a.zeroCrossing(p0, p1, c.value[k0], c.value[k1])
+		qef[comp[e]].Add(p, sdf.Normal3(a.s, p, a.resolution*1e-3))
+	}
+	c.sheets = n
+	if n == 1 {
+		c.qef = qef[0]
+	}
+	// keep the vertices inside the cell to avoid folded triangles
+	cellMax := base.AddScalar(a.resolution)
+	index := make([]int, n)
+	for k := range qef {
+		p := qef[k].Solve(a.rCond)
+		if math.IsNaN(p.X) || math.IsNaN(p.Y) || math.IsNaN(p.Z) {
+			p = qef[k].MassPoint()
+		}
+		index[k] = len(a.vertex)
+		a.vertex = append(a.vertex, p.Clamp(base, cellMax))
+	}
+	for e := 0; e < 12; e++ {
+		c.vertex[e] = -1
+		if comp[e] >= 0 {
+			c.vertex[e] = index[comp[e]]
+		}
+	}
+}
+
+// quads generates the triangles for the crossed edges at the far corner of a cell.
+// Each grid edge is the far edge of exactly one cell, so each quad is made once.
+func (a *adc) quads(c *adcCell) []*render.Triangle3 {
+	var t []*render.Triangle3
+	for axis := 0; axis < 3; axis++ {
+		e := adcEdgeIndex(axis, 1, 1)
+		if !c.crossed(e) {
+			continue
+		}
+		// the 4 cells around the edge, counter-clockwise about the axis
+		var v [4]int
+		ok := true
+		for k, uv := range [4][2]int{{0, 0}, {1, 0}, {1, 1}, {0, 1}} {
+			o := adcOffset(axis, 0, uv[0], uv[1])
+			n, found := a.leaf[c.i.Add(v3i.Vec{o[0], o[1], o[2]})]
+			if !found {
+				ok = false
+				break
+			}
+			// the edge is at (1-u, 1-v) within the neighbouring cell
+			v[k] = n.vertex[adcEdgeIndex(axis, 1-uv[0], 1-uv[1])]
+		}
+		if !ok {
+			continue
+		}
+		// the surface normal points along the axis if the low end of the edge is inside
+		if (c.inside>>adcEdge[e][0])&1 == 0 {
+			v[1], v[3] = v[3], v[1]
+		}
+		// drop the repeated vertices of collapsed cells
+		var p []v3.Vec
+		for k := range v {
+			if v[k] != v[(k+3)%4] {
+				p = append(p, a.vertex[v[k]])
+			}
+		}
+		switch len(p) {
+		case 0, 1, 2:
+			continue
+		case 3:
+			t = append(t, render.NewTriangle3(p[0], p[1], p[2]))
+			continue
+		}
+		// split the quad on the shorter diagonal
+		if p[0].Sub(p[2]).Length2() <= p[1].Sub(p[3]).Length2() {
+			t = append(t, render.NewTriangle3(p[0], p[1], p[2]), render.NewTriangle3(p[0], p[2], p[3]))
+		} else {
+			t = append(t, render.NewTriangle3(p[0], p[1], p[3]), render.NewTriangle3(p[1], p[2], p[3]))
+		}
+	}
+	return t
+}
+
+//-----------------------------------------------------------------------------
+
+// adcCluster is a group of cells collapsed into a single vertex.
+type adcCluster struct {
+	cells []*adcCell
+	qef   dcQefSolver
+	p     v3.Vec // vertex position
+}
+
+// collapse returns the cluster for the cells of an octant, nil if the octant has no surface.
+// It returns false if the octant can't be collapsed, in which case the clusters
+// within it have been given their vertices.
+func (a *adc) collapse(i v3i.Vec, n int) (*adcCluster, bool) {
+	if n == 1 {
+		c, ok := a.leaf[i]
+		switch {
+		case !ok:
+			return nil, true
+		case c.sheets != 1:
+			return nil, false
+		}
+		return &adcCluster{cells: []*adcCell{c}, qef: c.qef}, true
+	}
+	h := n >> 1
+	var child [8]*adcCluster
+	ok := true
+	for j := range child {
+		var k bool
+		child[j], k = a.collapse(i.Add(v3i.Vec{(j >> 2 & 1) * h, (j >> 1 & 1) * h, (j & 1) * h}), h)
+		ok = ok && k
+	}
+	cl := &adcCluster{}
+	if ok {
+		for _, c := range child {
+			if c != nil {
+				cl.cells = append(cl.cells, c.cells...)
+				cl.qef.AddSolver(&c.qef)
+			}
+		}
+		if len(cl.cells) == 0 {
+			return nil, true
+		}
+	}
+	if ok && a.safe(i, n) {
+		base := a.point(i)
+		p := cl.qef.Solve(a.rCond)
+		if math.IsNaN(p.X) || math.IsNaN(p.Y) || math.IsNaN(p.Z) {
+			p = cl.qef.MassPoint()
+		}
+		cl.p = p.Clamp(base, base.AddScalar(float64(n)*a.resolution))
+		e := math.Max(cl.qef.getErrorPos(&cl.p), 0)
+		if math.Sqrt(e/float64(cl.qef.numPoints)) <= a.simplify {
+			return cl, true
+		}
+	}
+	for _, c := range child {
+		if c != nil {
+			a.cluster(c)
+		}
+	}
+	return nil, false
+}
+
+// cluster gives the cells of a cluster a shared vertex.
+func (a *adc) cluster(cl *adcCluster) {
+	if len(cl.cells) == 1 {
+		// a single cell keeps its own vertex
+		return
+	}
+	k := len(a.vertex)
+	a.vertex = append(a.vertex, cl.p)
+	for _, c := range cl.cells {
+		for e := range c.vertex {
+			if c.vertex[e] >= 0 {
+				c.vertex[e] = k
+			}
+		}
+	}
+}
+
+// safe returns true if collapsing an octant keeps the topology of the surface.
+// The octant is sampled at the corners of its 2x2x2 children.
+func (a *adc) safe(i v3i.Vec, n int) bool {
+	h := n >> 1
+	var v [3][3][3]float64
+	sample := func(p [3]int) float64 {
+		return v[p[0]][p[1]][p[2]]
+	}
+	for x := 0; x < 3; x++ {
+		for y := 0; y < 3; y++ {
+			for z := 0; z < 3; z++ {
+				v[x][y][z] = a.evaluate(i.Add(v3i.Vec{x * h, y * h, z * h}))
+			}
+		}
+	}
+	// cells for the octant and its children
+	cell := func(x, y, z, size int) *adcCell {
+		c := &adcCell{}
+		for j := range c.value {
+			c.value[j] = v[x+(j>>2&1)*size][y+(j>>1&1)*size][z+(j&1)*size]
+			if c.value[j] < 0 {
+				c.inside |= 1 << j
+			}
+		}
+		return c
+	}
+	// the octant has a single sheet
+	octant := cell(0, 0, 0, 2)
+	if octant.inside == 0 || octant.inside == 255 {
+		return false
+	}
+	if _, m := octant.components(); m != 1 {
+		return false
+	}
+	// the midpoint of an uncrossed octant edge has the same sign as its ends
+	for e := range adcEdge {
+		if octant.crossed(e) {
+			continue
+		}
+		axis, u, w := e/4, e%2, e/2%2
+		if (sample(adcOffset(axis, 1, 2*u, 2*w)) < 0) != (octant.value[adcEdge[e][0]] < 0) {
+			return false
+		}
+	}
+	// the children have the same face pairings on the octant faces
+	for f := range adcFace {
+		axis, w := f/2, 2*(f%2)
+		var g [3][3]float64
+		for a := 0; a < 3; a++ {
+			for b := 0; b < 3; b++ {
+				g[a][b] = sample(adcOffset(axis, w, a, b))
+			}
+		}
+		if !adcFaceSafe(g) {
+			return false
+		}
+	}
+	// the sheets of the children join into a disk
+	var parent [8]int
+	for j := range parent {
+		parent[j] = j
+	}
+	find := func(j int) int {
+		for parent[j] != j {
+			j = parent[j]
+		}
+		return j
+	}
+	sheets, arcs, edges := 0, 0, 0
+	for j := 0; j < 8; j++ {
+		c := cell(j>>2&1, j>>1&1, j&1, 1)
+		if c.inside != 0 && c.inside != 255 {
+			sheets++
+		}
+	}
+	for axis := 0; axis < 3; axis++ {
+		// the faces between the children on the mid-plane normal to the axis
+		for a := 0; a < 2; a++ {
+			for b := 0; b < 2; b++ {
+				face := [4]float64{
+					sample(adcOffset(axis, 1, a, b)),
+					sample(adcOffset(axis, 1, a+1, b)),
+					sample(adcOffset(axis, 1, a+1, b+1)),
+					sample(adcOffset(axis, 1, a, b+1)),
+				}
+				if k := len(adcFacePairs(face)); k > 0 {
+					arcs += k
+					p0 := adcOffset(axis, 0, a, b)
+					p1 := adcOffset(axis, 1, a, b)
+					parent[find(adcCorner(p0[0], p0[1], p0[2]))] = find(adcCorner(p1[0], p1[1], p1[2]))
+				}
+			}
+		}
+		// the edges from the centre to the octant faces
+		for w := 0; w < 3; w += 2 {
+			if (sample(adcOffset(axis, w, 1, 1)) < 0) != (v[1][1][1] < 0) {
+				edges++
+			}
+		}
+	}
+	// the euler characteristic of a disk is 1
+	if sheets-arcs+edges != 1 {
+		return false
+	}
+	// and it is connected
+	root := -1
+	for j := 0; j < 8; j++ {
+		c := cell(j>>2&1, j>>1&1, j&1, 1)
+		if c.inside == 0 || c.inside == 255 {
+			continue
+		}
+		if root < 0 {
+			root = find(j)
+		} else if find(j) != root {
+			return false
+		}
+	}
+	return true
+}
+
+// adcFaceSafe returns true if the 2x2 sub-faces of a face join its crossed edges
+// in the same way as the face itself. g[a][b] are the values on a 3x3 grid.
+func adcFaceSafe(g [3][3]float64) bool {
+	// Sub-edges along the first face axis are a+2b, along the second axis 6+b+2a.
+	var parent [12]int
+	for j := range parent {
+		parent[j] = j
+	}
+	find := func(j int) int {
+		for parent[j] != j {
+			j = parent[j]
+		}
+		return j
+	}
+	crossed := func(a0, b0, a1, b1 int) bool {
+		return (g[a0][b0] < 0) != (g[a1][b1] < 0)
+	}
+	for a := 0; a < 2; a++ {
+		for b := 0; b < 2; b++ {
+			edge := [4]int{a + 2*b, 6 + b + 2*(a+1), a + 2*(b+1), 6 + b + 2*a}
+			for _, pair := range adcFacePairs([4]float64{g[a][b], g[a+1][b], g[a+1][b+1], g[a][b+1]}) {
+				parent[find(edge[pair[0]])] = find(edge[pair[1]])
+			}
+		}
+	}
+	// the crossed half of each face edge (-1 if not crossed)
+	// face edge k: bottom (b=0), right (a=2), top (b=2), left (a=0)
+	half := func(a0, b0, a1, b1, a2, b2, e0, e1 int) int {
+		switch {
+		case crossed(a0, b0, a1, b1):
+			return e0
+		case crossed(a1, b1, a2, b2):
+			return e1
+		}
+		return -1
+	}
+	halves := [4]int{
+		half(0, 0, 1, 0, 2, 0, 0, 1),
+		half(2, 0, 2, 1, 2, 2, 10, 11),
+		half(0, 2, 1, 2, 2, 2, 4, 5),
+		half(0, 0, 0, 1, 0, 2, 6, 7),
+	}
+	// the pairs joined by the sub-faces are the pairs joined by the face
+	// A sheet that crosses a face twice would join its vertex to the neighbouring
+	// vertex with two pairs of quads, so these faces are not collapsed.
+	face := [4]float64{g[0][0], g[2][0], g[2][2], g[0][2]}
+	pairs := adcFacePairs(face)
+	if len(pairs) > 1 {
+		return false
+	}
+	joined := make(map[int]bool)
+	for _, pair := range pairs {
+		e0, e1 := halves[pair[0]], halves[pair[1]]
+		if e0 < 0 || e1 < 0 || find(e0) != find(e1) {
+			return false
+		}
+		joined[find(e0)] = true
+	}
+	if len(joined) != len(pairs) {
+		return false
+	}
+	// with no closed loops inside the face
+	for a := 0; a < 2; a++ {
+		for b := 0; b < 3; b++ {
+			if crossed(a, b, a+1, b) && !joined[find(a+2*b)] {
+				return false
+			}
+			if crossed(b, a, b, a+1) && !joined[find(6+a+2*b)] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,129 @@
+//-----------------------------------------------------------------------------
+/*
+
+Dual Contouring Tests
+
+The renderers are checked against the volume of a fine marching cubes mesh.
+
+*/
+//-----------------------------------------------------------------------------
+
+package dc
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gmlewis/sdfx/render"
+	"github.com/gmlewis/sdfx/sdf"
+	v3 "github.com/gmlewis/sdfx/vec/v3"
+	"github.com/gmlewis/sdfx/vec/v3i"
+)
+
+//-----------------------------------------------------------------------------
+
+// volume returns the volume enclosed by a mesh.
+func volume(m *render.Mesh) float64 {
+	v := 0.0
+	for _, t := range m.Triangle {
+		v += m.Vertex[t[0]].Dot(m.Vertex[t[1]].Cross(m.Vertex[t[2]]))
+	}
+	return v / 6
+}
+
+// testSDF3 returns the shapes used to compare the renderers.
+func testSDF3(t *testing.T) map[string]sdf.SDF3 {
+	box, err := sdf.Box3D(v3.Vec{X: 10, Y: 8, Z: 6}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sphere, err := sdf.Sphere3D(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cylinder, err := sdf.Cylinder3D(10, 2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return map[string]sdf.SDF3{
+		"box":        box,
+		"sphere":     sphere,
+		"difference": sdf.Difference3D(box, cylinder),
+	}
+}
+
+// compareVolume checks the volume of a rendering against marching cubes.
+func compareVolume(t *testing.T, r render.Render3) {
+	for name, s := range testSDF3(t) {
+		mc := volume(render.ToMesh(s, render.NewMarchingCubesUniform(80)))
+		v := volume(render.ToMesh(s, r))
+		if math.Abs(v-mc) > 0.02*mc {
+			t.Errorf("%s: volume %g, marching cubes volume %g", name, v, mc)
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_DualContouringV1(t *testing.T) {
+	compareVolume(t, NewDualContouringV1Render3(64, -1, 0, false))
+}
+
+func Test_DualContouringV1_Simplify(t *testing.T) {
+	compareVolume(t, NewDualContouringV1Render3(64, 1e-6, 0, true))
+}
+
+func Test_DualContouring(t *testing.T) {
+	compareVolume(t, NewDualContouring(64))
+	// the mesh is closed and sharp corners are kept
+	box, err := sdf.Box3D(v3.Vec{X: 10, Y: 8, Z: 6}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := render.ToMesh(box, NewDualContouring(20))
+	if !m.Watertight() {
+		t.Error("mesh is not watertight")
+	}
+	if v := volume(m); math.Abs(v-480) > 1e-6 {
+		t.Errorf("box volume %g, expected 480", v)
+	}
+}
+
+func Test_DualContouring_Simplify(t *testing.T) {
+	box, err := sdf.Box3D(v3.Vec{X: 10, Y: 8, Z: 6}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sphere, err := sdf.Sphere3D(0.6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	holes := sdf.Difference3D(box, sdf.Array3D(sphere, v3i.Vec{X: 6, Y: 5, Z: 4}, v3.Vec{X: 1.6, Y: 1.6, Z: 1.6}))
+	for name, s := range map[string]sdf.SDF3{"box": box, "holes": holes} {
+		uniform := NewDualContouring(64)
+		uniform.Simplify = 0
+		m0 := render.ToMesh(s, uniform)
+		m1 := render.ToMesh(s, NewDualContouring(64))
+		// the flat faces are collapsed into large triangles
+		if len(m1.Triangle) > len(m0.Triangle)/2 {
+			t.Errorf("%s: %d triangles, %d without simplification", name, len(m1.Triangle), len(m0.Triangle))
+		}
+		if !m1.Watertight() {
+			t.Errorf("%s: mesh is not watertight", name)
+		}
+		v0, v1 := volume(m0), volume(m1)
+		if math.Abs(v1-v0) > 1e-3*v0 {
+			t.Errorf("%s: volume %g, %g without simplification", name, v1, v0)
+		}
+	}
+	// a box is a few triangles per face
+	m := render.ToMesh(box, NewDualContouring(64))
+	if len(m.Triangle) > 200 {
+		t.Errorf("box: %d triangles", len(m.Triangle))
+	}
+	if v := volume(m); math.Abs(v-480) > 1e-6 {
+		t.Errorf("box volume %g, expected 480", v)
+	}
+}
+
+//-----------------------------------------------------------------------------
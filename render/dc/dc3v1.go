@@ -57,6 +57,13 @@ func (m *DualContouringV1) Info(s sdf.SDF3, meshCells int) string {
 
 // Render produces a 3d triangle mesh over the bounding volume of an sdf3.
 func (m *DualContouringV1) Render(s sdf.SDF3, meshCells int, output chan<- *render.Triangle3) {
+	for _, t := range m.mesh(s, meshCells) {
+		output <- t
+	}
+}
+
+// mesh returns the triangles of the dual contoured sdf3.
+func (m *DualContouringV1) mesh(s sdf.SDF3, meshCells int) []*render.Triangle3 {
 	if m.RCond == 0 {
 		m.RCond = 1e-3
 	}
@@ -72,7 +79,35 @@ func (m *DualContouringV1) Render(s sdf.SDF3, meshCells int, output chan<- *rend
 		dcOctreeRootNode.Simplify(s, m.Simplify)
 	}
 	// Generate the final mesh
-	dcOctreeRootNode.GenerateMesh(output)
+	return dcOctreeRootNode.GenerateMesh()
+}
+
+//-----------------------------------------------------------------------------
+
+// DualContouringV1Render3 adapts DualContouringV1 to the render.Render3 interface.
+type DualContouringV1Render3 struct {
+	*DualContouringV1
+	meshCells int // number of cells on the longest axis of bounding box. e.g 200
+}
+
+// NewDualContouringV1Render3 returns a Render3 object using DualContouringV1.
+func NewDualContouringV1Render3(meshCells int, simplify float64, RCond float64, lockVertices bool) *DualContouringV1Render3 {
+	return &DualContouringV1Render3{
+		DualContouringV1: NewDualContouringV1(simplify, RCond, lockVertices),
+		meshCells:        meshCells,
+	}
+}
+
+// Info returns a string describing the rendered volume.
+func (r *DualContouringV1Render3) Info(s sdf.SDF3) string {
+	return r.DualContouringV1.Info(s, r.meshCells)
+}
+
+// Render produces a 3d triangle mesh over the bounding volume of an sdf3.
+func (r *DualContouringV1Render3) Render(s sdf.SDF3, output chan<- []*render.Triangle3) {
+	if triangles := r.mesh(s, r.meshCells); len(triangles) > 0 {
+		output <- triangles
+	}
 }
 
 //-----------------------------------------------------------------------------
@@ -510,13 +545,14 @@ func dcContourProcessEdge(node [4]*dcOctree, dir int, indexBuffer *[]int) {
 	}
 }
 
-func (node *dcOctree) GenerateMesh(output chan<- *render.Triangle3) {
+func (node *dcOctree) GenerateMesh() []*render.Triangle3 {
 	vertexBuffer := new([]v3.Vec)
 	indexBuffer := new([]int)
 	// Populate buffers
 	node.generateVertexIndices(vertexBuffer)
 	node.contourCellProc(indexBuffer)
 	// Return triangles
+	triangles := make([]*render.Triangle3, 0, len(*indexBuffer)/3)
 	for tri := 0; tri < len(*indexBuffer)/3; tri++ {
 		triangle := &render.Triangle3{
 			V: [3]v3.Vec{
@@ -526,8 +562,9 @@ func (node *dcOctree) GenerateMesh(output chan<- *render.Triangle3) {
 			},
 		}
 		//log.Println("Outputting triangle:", triangle)
-		output <- triangle
+		triangles = append(triangles, triangle)
 	}
+	return triangles
 }
 
 // dcQefSolver is used for vertex position estimation (sharp edges!)
@@ -0,0 +1,496 @@
+//-----------------------------------------------------------------------------
+/*
+
+Mesh Decimation
+
+Reduce the number of triangles in a mesh using quadric error edge collapse.
+
+Each vertex has a quadric that measures the sum of squared distances to the
+planes of the triangles around it. Edges are collapsed in order of the error
+introduced by moving the merged vertex to the point that minimises the sum of
+the quadrics at each end of the edge.
+
+See: Garland, Heckbert, "Surface Simplification Using Quadric Error Metrics", 1997.
+
+Marching cubes produces many small coplanar triangles. These collapse with no
+error, so large flat or gently curved areas are reduced to a few triangles.
+
+*/
+//-----------------------------------------------------------------------------
+
+package render
+
+import (
+	"container/heap"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/gmlewis/sdfx/sdf"
+	v3 "github.com/gmlewis/sdfx/vec/v3"
+)
+
+//-----------------------------------------------------------------------------
+
+// quadric is a symmetric 4x4 matrix stored as its upper triangle.
+// [0 1 2 3]
+// [. 4 5 6]
+// [. . 7 8]
+// [. . . 9]
+type quadric [10]float64
+
+// planeQuadric returns the quadric for the plane n.p + d = 0.
+func planeQuadric(n v3.Vec, d float64) quadric {
+	return quadric{
+		n.X * n.X, n.X * n.Y, n.X * n.Z, n.X * d,
+		n.Y * n.Y, n.Y * n.Z, n.Y * d,
+		n.Z * n.Z, n.Z * d,
+		d * d,
+	}
+}
+
+// add returns the sum of two quadrics.
+func (q quadric) add(r quadric) quadric {
+	for i := range q {
+		q[i] += r[i]
+	}
+	return q
+}
+
+// scale returns the quadric multiplied by a scalar.
+func (q quadric) scale(k float64) quadric {
+	for i := range q {
+		q[i] *= k
+	}
+	return q
+}
+
+// error returns the quadric error at a point.
+func (q quadric) error(p v3.Vec) float64 {
+	return q[0]*p.X*p.X + 2*q[1]*p.X*p.Y + 2*q[2]*p.X*p.Z + 2*q[3]*p.X +
+		q[4]*p.Y*p.Y + 2*q[5]*p.Y*p.Z + 2*q[6]*p.Y +
+		q[7]*p.Z*p.Z + 2*q[8]*p.Z +
+		q[9]
+}
+
+// minimum returns the point that minimises the quadric error.
+// It returns false if the minimum is not unique.
+func (q quadric) minimum() (v3.Vec, bool) {
+	// solve A.p = -b by Cramer's rule
+	a00, a01, a02 := q[0], q[1], q[2]
+	a11, a12 := q[4], q[5]
+	a22 := q[7]
+	c00 := a11*a22 - a12*a12
+	c01 := a02*a12 - a01*a22
+	c02 := a01*a12 - a02*a11
+	det := a00*c00 + a01*c01 + a02*c02
+	if math.Abs(det) < 1e-12 {
+		return v3.Vec{}, false
+	}
+	c11 := a00*a22 - a02*a02
+	c12 := a01*a02 - a00*a12
+	c22 := a00*a11 - a01*a01
+	b := v3.Vec{-q[3], -q[6], -q[8]}
+	return v3.Vec{
+		c00*b.X + c01*b.Y + c02*b.Z,
+		c01*b.X + c11*b.Y + c12*b.Z,
+		c02*b.X + c12*b.Y + c22*b.Z,
+	}.DivScalar(det), true
+}
+
+//-----------------------------------------------------------------------------
+
+// collapse is a candidate edge collapse.
+type collapse struct {
+	v0, v1     int     // edge vertices (v1 is merged into v0)
+	rev0, rev1 int     // vertex revisions when the collapse was calculated
+	cost       float64 // quadric error of the collapse
+	p          v3.Vec  // position of the merged vertex
+}
+
+// collapseHeap is a min-heap of edge collapses ordered by cost.
+type collapseHeap []*collapse
+
+func (h collapseHeap) Len() int           { return len(h) }
+func (h collapseHeap) Less(i, j int) bool { return h[i].cost < h[j].cost }
+func (h collapseHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *collapseHeap) Push(x any)        { *h = append(*h, x.(*collapse)) }
+func (h *collapseHeap) Pop() any {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
+
+// boundaryWeight scales the quadrics that hold boundary edges in place.
+const boundaryWeight = 1000.0
+
+// decimator holds the mesh state during decimation.
+type decimator struct {
+	vertex   []v3.Vec     // vertex positions
+	q        []quadric    // vertex quadrics
+	rev      []int        // vertex revision (incremented when a vertex changes)
+	removed  []bool       // vertex has been merged into another vertex
+	vt       [][]int      // triangles using each vertex
+	triangle [][3]int     // triangles
+	dead     []bool       // triangle has been removed
+	count    int          // number of live triangles
+	heap     collapseHeap // candidate edge collapses
+}
+
+// newDecimator returns the decimation state for a mesh.
+func newDecimator(m *Mesh) *decimator {
+	d := &decimator{
+		vertex:   append([]v3.Vec(nil), m.Vertex...),
+		q:        make([]quadric, len(m.Vertex)),
+		rev:      make([]int, len(m.Vertex)),
+		removed:  make([]bool, len(m.Vertex)),
+		vt:       make([][]int, len(m.Vertex)),
+		triangle: append([][3]int(nil), m.Triangle...),
+		dead:     make([]bool, len(m.Triangle)),
+		count:    len(m.Triangle),
+	}
+	// accumulate the triangle plane quadrics at each vertex
+	edges := make(map[[2]int]int)
+	for i, t := range d.triangle {
+		n := d.normal(t)
+		q := planeQuadric(n, -n.Dot(d.vertex[t[0]]))
+		for j, k := range t {
+			d.q[k] = d.q[k].add(q)
+			d.vt[k] = append(d.vt[k], i)
+			edges[edgeKey(k, t[(j+1)%3])]++
+		}
+	}
+	// boundary edges have a single triangle, constrain them with a perpendicular plane
+	for _, t := range d.triangle {
+		n := d.normal(t)
+		for j := 0; j < 3; j++ {
+			a, b := t[j], t[(j+1)%3]
+			if edges[edgeKey(a, b)] != 1 {
+				continue
+			}
+			bn := d.vertex[b].Sub(d.vertex[a]).Cross(n).Normalize()
+			q := planeQuadric(bn, -bn.Dot(d.vertex[a])).scale(boundaryWeight)
+			d.q[a] = d.q[a].add(q)
+			d.q[b] = d.q[b].add(q)
+		}
+	}
+	return d
+}
+
+// pushAll adds the collapses for all the edges of the mesh to the heap.
+func (d *decimator) pushAll() {
+	d.heap = d.heap[:0]
+	done := make(map[[2]int]bool)
+	for i, t := range d.triangle {
+		if d.dead[i] {
+			continue
+		}
+		for j, k := range t {
+			e := edgeKey(k, t[(j+1)%3])
+			if !done[e] {
+				done[e] = true
+				d.push(e[0], e[1])
+			}
+		}
+	}
+}
+
+// edgeKey returns an order independent key for an edge.
+func edgeKey(a, b int) [2]int {
+	if a > b {
+		a, b = b, a
+	}
+	return [2]int{a, b}
+}
+
+// normal returns the unit normal of a triangle.
+func (d *decimator) normal(t [3]int) v3.Vec {
+	a, b, c := d.vertex[t[0]], d.vertex[t[1]], d.vertex[t[2]]
+	return b.Sub(a).Cross(c.Sub(a)).Normalize()
+}
+
+// cost returns the sum of the squared distances from a point to the planes of an edge's quadrics.
+func (d *decimator) cost(v0, v1 int, p v3.Vec) float64 {
+	return math.Max(d.q[v0].add(d.q[v1]).error(p), 0)
+}
+
+// push calculates the collapse of an edge and adds it to the heap.
+func (d *decimator) push(v0, v1 int) {
+	q := d.q[v0].add(d.q[v1])
+	a, b := d.vertex[v0], d.vertex[v1]
+	mid := a.Add(b).MulScalar(0.5)
+	p, ok := q.minimum()
+	// don't trust a minimum that is far from the edge
+	if !ok || p.Sub(mid).Length() > b.Sub(a).Length() {
+		// use the best of the end and mid points
+		p = mid
+		for _, x := range []v3.Vec{a, b} {
+			if q.error(x) < q.error(p) {
+				p = x
+			}
+		}
+	}
+	heap.Push(&d.heap, &collapse{
+		v0:   v0,
+		v1:   v1,
+		rev0: d.rev[v0],
+		rev1: d.rev[v1],
+		cost: d.cost(v0, v1, p),
+		p:    p,
+	})
+}
+
+// neighbours returns the vertices connected to a vertex.
+func (d *decimator) neighbours(v int) []int {
+	var n []int
+	for _, i := range d.vt[v] {
+		for _, k := range d.triangle[i] {
+			if k != v && !slices.Contains(n, k) {
+				n = append(n, k)
+			}
+		}
+	}
+	return n
+}
+
+// valid returns true if an edge collapse keeps the mesh manifold and doesn't fold over any triangles.
+func (d *decimator) valid(c *collapse) bool {
+	// link condition: the only common neighbours are the opposite vertices of the edge triangles
+	shared := 0
+	for _, i := range d.vt[c.v0] {
+		if d.hasEdge(d.triangle[i], c.v0, c.v1) {
+			shared++
+		}
+	}
+	n0 := d.neighbours(c.v0)
+	common := 0
+	for _, k := range d.neighbours(c.v1) {
+		if slices.Contains(n0, k) {
+			common++
+		}
+	}
+	if common != shared {
+		return false
+	}
+	// the triangles that remain must not flip
+	for _, v := range []int{c.v0, c.v1} {
+		for _, i := range d.vt[v] {
+			t := d.triangle[i]
+			if d.hasEdge(t, c.v0, c.v1) {
+				// this triangle is removed
+				continue
+			}
+			var pts [3]v3.Vec
+			for j, k := range t {
+				pts[j] = d.vertex[k]
+				if k == v {
+					pts[j] = c.p
+				}
+			}
+			n := pts[1].Sub(pts[0]).Cross(pts[2].Sub(pts[0]))
+			if n.Length() == 0 || n.Dot(d.normal(t)) <= 0 {
+				return false
+			}
+			if v == c.v1 && d.duplicate(t, c.v0, c.v1) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// duplicate returns true if moving a triangle from v1 to v0 would duplicate a triangle of v0.
+func (d *decimator) duplicate(t [3]int, v0, v1 int) bool {
+	var a, b int
+	for j, k := range t {
+		if k == v1 {
+			a, b = t[(j+1)%3], t[(j+2)%3]
+		}
+	}
+	for _, i := range d.vt[v0] {
+		t0 := d.triangle[i]
+		if !d.hasEdge(t0, v0, v1) && d.hasEdge(t0, a, b) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasEdge returns true if a triangle uses both vertices.
+func (d *decimator) hasEdge(t [3]int, v0, v1 int) bool {
+	return (t[0] == v0 || t[1] == v0 || t[2] == v0) && (t[0] == v1 || t[1] == v1 || t[2] == v1)
+}
+
+// fallback tries the end and mid points of the edge when the optimal collapse is not valid.
+// It returns true (with the collapse updated) if one of them is valid.
+func (d *decimator) fallback(c *collapse, maxCost float64) bool {
+	a, b := d.vertex[c.v0], d.vertex[c.v1]
+	p := c.p
+	ok := false
+	for _, x := range []v3.Vec{a, b, a.Add(b).MulScalar(0.5)} {
+		cost := d.cost(c.v0, c.v1, x)
+		if cost > maxCost || (ok && cost >= c.cost) {
+			continue
+		}
+		c.p = x
+		if d.valid(c) {
+			p = x
+			c.cost = cost
+			ok = true
+		}
+	}
+	c.p = p
+	return ok
+}
+
+// apply merges the second vertex of an edge into the first.
+func (d *decimator) apply(c *collapse) {
+	v0, v1 := c.v0, c.v1
+	d.vertex[v0] = c.p
+	d.q[v0] = d.q[v0].add(d.q[v1])
+	d.rev[v0]++
+	d.removed[v1] = true
+	// move the triangles of v1 to v0, removing those on the edge
+	vt := make([]int, 0, len(d.vt[v0])+len(d.vt[v1]))
+	for _, i := range d.vt[v0] {
+		if t := d.triangle[i]; d.hasEdge(t, v0, v1) {
+			d.dead[i] = true
+			d.count--
+			// remove the triangle from the opposite vertex
+			for _, k := range t {
+				if k != v0 && k != v1 {
+					d.vt[k] = slices.DeleteFunc(d.vt[k], func(j int) bool { return j == i })
+				}
+			}
+			continue
+		}
+		vt = append(vt, i)
+	}
+	for _, i := range d.vt[v1] {
+		if d.dead[i] {
+			continue
+		}
+		t := &d.triangle[i]
+		for j := range t {
+			if t[j] == v1 {
+				t[j] = v0
+			}
+		}
+		vt = append(vt, i)
+	}
+	d.vt[v0] = vt
+	d.vt[v1] = nil
+	// the edges around v0 have changed
+	for _, k := range d.neighbours(v0) {
+		d.push(v0, k)
+	}
+}
+
+// run collapses edges until the triangle count or error limit is reached.
+func (d *decimator) run(target int, maxError float64) {
+	maxCost := math.Inf(1)
+	if maxError > 0 {
+		maxCost = maxError * maxError
+	}
+	// Rejected collapses may become valid as the mesh changes around them,
+	// so keep making passes over all the edges while progress is made.
+	for d.count > target {
+		d.pushAll()
+		n := d.count
+		for d.count > target && d.heap.Len() > 0 {
+			c := heap.Pop(&d.heap).(*collapse)
+			if d.removed[c.v0] || d.removed[c.v1] || c.rev0 != d.rev[c.v0] || c.rev1 != d.rev[c.v1] {
+				// stale
+				continue
+			}
+			if c.cost > maxCost {
+				break
+			}
+			if d.valid(c) || d.fallback(c, maxCost) {
+				d.apply(c)
+			}
+		}
+		if d.count == n {
+			break
+		}
+	}
+}
+
+// mesh returns the decimated mesh.
+// It is built with AddVertex so the result welds any vertices added later.
+func (d *decimator) mesh(tolerance float64) *Mesh {
+	m := NewMesh(tolerance)
+	index := make([]int, len(d.vertex))
+	for i := range index {
+		index[i] = -1
+	}
+	for i, t := range d.triangle {
+		if d.dead[i] {
+			continue
+		}
+		var mt [3]int
+		for j, k := range t {
+			if index[k] < 0 {
+				index[k] = m.AddVertex(d.vertex[k])
+			}
+			mt[j] = index[k]
+		}
+		if mt[0] == mt[1] || mt[1] == mt[2] || mt[2] == mt[0] {
+			continue
+		}
+		m.Triangle = append(m.Triangle, mt)
+	}
+	return m
+}
+
+//-----------------------------------------------------------------------------
+
+// Decimate returns a simplified copy of the mesh.
+// Edges are collapsed until the mesh has no more than target triangles, or the
+// next collapse would move the surface by more than maxError. The error is the
+// sum of the squared distances to the original triangle planes, so the limit is conservative.
+// A target of 0 decimates by error alone and a maxError of 0 decimates by count alone.
+func (m *Mesh) Decimate(target int, maxError float64) *Mesh {
+	if target <= 0 && maxError <= 0 {
+		// nothing to stop the collapse, leave the mesh alone
+		return m
+	}
+	d := newDecimator(m)
+	d.run(target, maxError)
+	return d.mesh(m.tolerance)
+}
+
+//-----------------------------------------------------------------------------
+
+// Decimator renders with another renderer and then simplifies the mesh.
+// Use it as the rendering method for ToSTL, To3MF, etc.
+type Decimator struct {
+	r        Render3 // the underlying renderer
+	target   int     // target triangle count (0 = no target)
+	maxError float64 // maximum surface error (0 = no limit)
+}
+
+// NewDecimator returns a Render3 object that decimates the output of another renderer.
+// See Mesh.Decimate for the meaning of target and maxError.
+func NewDecimator(r Render3, target int, maxError float64) *Decimator {
+	return &Decimator{
+		r:        r,
+		target:   target,
+		maxError: maxError,
+	}
+}
+
+// Info returns a string describing the rendered volume.
+func (r *Decimator) Info(s sdf.SDF3) string {
+	return fmt.Sprintf("%s, decimate target %d max error %g", r.r.Info(s), r.target, r.maxError)
+}
+
+// Render produces a decimated 3d triangle mesh over the bounding volume of an sdf3.
+func (r *Decimator) Render(s sdf.SDF3, output chan<- []*Triangle3) {
+	m := ToMesh(s, r.r)
+	output <- m.Decimate(r.target, r.maxError).Triangles()
+}
+
+//-----------------------------------------------------------------------------
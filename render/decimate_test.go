@@ -0,0 +1,129 @@
+//-----------------------------------------------------------------------------
+/*
+
+Mesh Decimation Tests
+
+*/
+//-----------------------------------------------------------------------------
+
+package render
+
+import (
+	"testing"
+
+	"github.com/gmlewis/sdfx/sdf"
+	v3 "github.com/gmlewis/sdfx/vec/v3"
+)
+
+//-----------------------------------------------------------------------------
+
+// bipyramid returns a closed mesh of two tetrahedra joined on the face (a, b, c).
+func bipyramid() *Mesh {
+	a := v3.Vec{X: 1, Y: 0, Z: 0}
+	b := v3.Vec{X: -0.5, Y: 0.866, Z: 0}
+	c := v3.Vec{X: -0.5, Y: -0.866, Z: 0}
+	n := v3.Vec{X: 0, Y: 0, Z: 1}
+	s := v3.Vec{X: 0, Y: 0, Z: -1}
+	return NewMeshFromTriangles([]*Triangle3{
+		NewTriangle3(a, b, n),
+		NewTriangle3(b, c, n),
+		NewTriangle3(c, a, n),
+		NewTriangle3(b, a, s),
+		NewTriangle3(c, b, s),
+		NewTriangle3(a, c, s),
+	}, 0)
+}
+
+func Test_Decimate_Count(t *testing.T) {
+	s, err := sdf.Sphere3D(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := ToMesh(s, NewMarchingCubesUniform(40))
+	if !m.Watertight() {
+		t.Fatal("sphere mesh is not watertight")
+	}
+	for _, target := range []int{2000, 500, 100} {
+		d := m.Decimate(target, 0)
+		if n := len(d.Triangle); n > target || n == 0 {
+			t.Errorf("target %d: %d triangles", target, n)
+		}
+		if !d.Watertight() {
+			t.Errorf("target %d: decimated mesh is not watertight", target)
+		}
+		// the surface stays close to the sphere
+		for _, v := range d.Vertex {
+			if x := s.Evaluate(v); x < -1 || x > 1 {
+				t.Errorf("target %d: vertex %v is %f from the surface", target, v, x)
+				break
+			}
+		}
+	}
+}
+
+func Test_Decimate_Error(t *testing.T) {
+	// the flat faces of a box collapse with no error
+	s, err := sdf.Box3D(v3.Vec{X: 10, Y: 8, Z: 6}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := ToMesh(s, NewMarchingCubesUniform(40))
+	d := m.Decimate(0, 1e-3)
+	if len(d.Triangle) >= len(m.Triangle)/4 {
+		t.Errorf("%d triangles decimated to %d", len(m.Triangle), len(d.Triangle))
+	}
+	if !d.Watertight() {
+		t.Error("decimated mesh is not watertight")
+	}
+	// no limits leaves the mesh alone
+	if d := m.Decimate(0, 0); len(d.Triangle) != len(m.Triangle) {
+		t.Errorf("%d triangles (expected) %d (actual)", len(m.Triangle), len(d.Triangle))
+	}
+}
+
+func Test_Decimate_Link(t *testing.T) {
+	m := bipyramid()
+	// The equator vertices have 3 common neighbours but only share 2 triangles.
+	// Collapsing an equator edge would leave a non-manifold mesh.
+	d := newDecimator(m)
+	a, b := m.AddVertex(v3.Vec{X: 1, Y: 0, Z: 0}), m.AddVertex(v3.Vec{X: -0.5, Y: 0.866, Z: 0})
+	mid := d.vertex[a].Add(d.vertex[b]).MulScalar(0.5)
+	if d.valid(&collapse{v0: a, v1: b, p: mid}) {
+		t.Error("equator collapse should fail the link condition")
+	}
+	// collapsing a pole into the equator leaves a tetrahedron
+	n := m.AddVertex(v3.Vec{X: 0, Y: 0, Z: 1})
+	if !d.valid(&collapse{v0: a, v1: n, p: d.vertex[a]}) {
+		t.Error("pole collapse should be valid")
+	}
+	// a tetrahedron can't be reduced any further
+	x := m.Decimate(1, 0)
+	if len(x.Triangle) != 4 {
+		t.Errorf("4 triangles (expected) %d (actual)", len(x.Triangle))
+	}
+	if !x.Watertight() {
+		t.Error("decimated mesh is not watertight")
+	}
+}
+
+func Test_Decimate_Weld(t *testing.T) {
+	// decimating the bipyramid leaves a tetrahedron
+	m := bipyramid().Decimate(1, 0)
+	if len(m.Vertex) != 4 || len(m.Triangle) != 4 {
+		t.Fatalf("%d vertices, %d triangles", len(m.Vertex), len(m.Triangle))
+	}
+	// a triangle added to the decimated mesh welds to its vertices
+	tri := NewTriangle3(m.Vertex[0], m.Vertex[2], m.Vertex[1])
+	m.AddTriangle(tri)
+	if len(m.Vertex) != 4 {
+		t.Errorf("%d vertices after adding a triangle, expected 4", len(m.Vertex))
+	}
+	if got := m.Triangle[len(m.Triangle)-1]; got != [3]int{0, 2, 1} {
+		t.Errorf("added triangle %v, expected [0 2 1]", got)
+	}
+	if i := m.AddVertex(m.Vertex[3]); i != 3 {
+		t.Errorf("vertex index %d, expected 3", i)
+	}
+}
+
+//-----------------------------------------------------------------------------
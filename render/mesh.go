@@ -0,0 +1,192 @@
+//-----------------------------------------------------------------------------
+/*
+
+Indexed Triangle Meshes
+
+The renderers produce triangle soup: each triangle has its own copy of its
+vertices. A Mesh welds coincident vertices together so that each vertex is
+stored once and the triangles refer to vertices by index.
+
+*/
+//-----------------------------------------------------------------------------
+
+package render
+
+import (
+	"math"
+	"strconv"
+	"sync"
+
+	"github.com/gmlewis/sdfx/sdf"
+	v3 "github.com/gmlewis/sdfx/vec/v3"
+	"github.com/gmlewis/sdfx/vec/v3i"
+)
+
+//-----------------------------------------------------------------------------
+
+// weldTolerance is the vertex welding tolerance for rendered meshes,
+// relative to the size of the bounding box.
+const weldTolerance = 1e-9
+
+// normalEpsilon is the sampling distance for vertex normals,
+// relative to the size of the bounding box.
+const normalEpsilon = 1e-5
+
+// Mesh is an indexed triangle mesh.
+type Mesh struct {
+	Vertex    []v3.Vec          // vertices
+	Triangle  [][3]int          // triangles (counter-clockwise vertex indices)
+	tolerance float64           // vertex welding tolerance
+	exact     map[v3.Vec]int    // vertex index (exact welding)
+	grid      map[v3i.Vec][]int // vertex index (tolerance welding)
+}
+
+// NewMesh returns an empty mesh.
+// Vertices closer than the tolerance are welded into a single vertex.
+// A tolerance of 0 only welds identical vertices.
+func NewMesh(tolerance float64) *Mesh {
+	m := &Mesh{
+		tolerance: tolerance,
+	}
+	if tolerance > 0 {
+		m.grid = make(map[v3i.Vec][]int)
+	} else {
+		m.exact = make(map[v3.Vec]int)
+	}
+	return m
+}
+
+// NewMeshFromTriangles returns a mesh built from a set of triangles.
+func NewMeshFromTriangles(triangles []*Triangle3, tolerance float64) *Mesh {
+	m := NewMesh(tolerance)
+	m.AddTriangles(triangles)
+	return m
+}
+
+// cell returns the welding grid cell for a vertex.
+func (m *Mesh) cell(v v3.Vec) v3i.Vec {
+	return v3i.Vec{
+		int(math.Floor(v.X / m.tolerance)),
+		int(math.Floor(v.Y / m.tolerance)),
+		int(math.Floor(v.Z / m.tolerance)),
+	}
+}
+
+// AddVertex adds a vertex to the mesh and returns its index.
+// If the mesh already has a vertex within tolerance its index is returned.
+func (m *Mesh) AddVertex(v v3.Vec) int {
+	if m.tolerance <= 0 {
+		if i, ok := m.exact[v]; ok {
+			return i
+		}
+		i := len(m.Vertex)
+		m.exact[v] = i
+		m.Vertex = append(m.Vertex, v)
+		return i
+	}
+	// look for a vertex in this and the neighbouring grid cells
+	c := m.cell(v)
+	t2 := m.tolerance * m.tolerance
+	for dx := -1; dx <= 1; dx++ {
+		for dy := -1; dy <= 1; dy++ {
+			for dz := -1; dz <= 1; dz++ {
+				for _, i := range m.grid[c.Add(v3i.Vec{dx, dy, dz})] {
+					if m.Vertex[i].Sub(v).Length2() <= t2 {
+						return i
+					}
+				}
+			}
+		}
+	}
+	i := len(m.Vertex)
+	m.grid[c] = append(m.grid[c], i)
+	m.Vertex = append(m.Vertex, v)
+	return i
+}
+
+// AddTriangle adds a triangle to the mesh.
+// Triangles that collapse to a line or point after welding are discarded.
+func (m *Mesh) AddTriangle(t *Triangle3) {
+	a := m.AddVertex(t.V[0])
+	b := m.AddVertex(t.V[1])
+	c := m.AddVertex(t.V[2])
+	if a == b || b == c || c == a {
+		return
+	}
+	m.Triangle = append(m.Triangle, [3]int{a, b, c})
+}
+
+// AddTriangles adds a set of triangles to the mesh.
+func (m *Mesh) AddTriangles(triangles []*Triangle3) {
+	for _, t := range triangles {
+		m.AddTriangle(t)
+	}
+}
+
+// Triangles returns the mesh as a set of triangles.
+func (m *Mesh) Triangles() []*Triangle3 {
+	ts := make([]*Triangle3, len(m.Triangle))
+	for i, t := range m.Triangle {
+		ts[i] = NewTriangle3(m.Vertex[t[0]], m.Vertex[t[1]], m.Vertex[t[2]])
+	}
+	return ts
+}
+
+// Normals returns the vertex normals of a mesh rendered from an SDF3.
+func (m *Mesh) Normals(s sdf.SDF3) []v3.Vec {
+	eps := s.BoundingBox().Size().MaxComponent() * normalEpsilon
+	n := make([]v3.Vec, len(m.Vertex))
+	for i, v := range m.Vertex {
+		n[i] = sdf.Normal3(s, v, eps)
+	}
+	return n
+}
+
+//-----------------------------------------------------------------------------
+
+// Watertight returns true if the mesh is closed and consistently wound.
+// That is: every edge is shared by exactly two triangles that use it in opposite directions.
+func (m *Mesh) Watertight() bool {
+	edges := make(map[[2]int]int)
+	for _, t := range m.Triangle {
+		for j := 0; j < 3; j++ {
+			edges[[2]int{t[j], t[(j+1)%3]}]++
+		}
+	}
+	for e, n := range edges {
+		if n != 1 || edges[[2]int{e[1], e[0]}] != 1 {
+			return false
+		}
+	}
+	return true
+}
+
+//-----------------------------------------------------------------------------
+
+// writeMesh writes a stream of triangles to a mesh.
+func writeMesh(wg *sync.WaitGroup, m *Mesh) chan<- []*Triangle3 {
+	// External code writes triangles to this channel.
+	// This goroutine reads the channel and adds the triangles to the mesh.
+	c := make(chan []*Triangle3)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		// read triangles from the channel and add them to the mesh
+		for ts := range c {
+			m.AddTriangles(ts)
+		}
+	}()
+
+	return c
+}
+
+//-----------------------------------------------------------------------------
+
+// formatFloat formats a mesh coordinate for a text file.
+// Coordinates are written with the same (32-bit) precision as binary files.
+func formatFloat(x float64) string {
+	return strconv.FormatFloat(x, 'g', -1, 32)
+}
+
+//-----------------------------------------------------------------------------
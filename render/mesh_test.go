@@ -0,0 +1,78 @@
+//-----------------------------------------------------------------------------
+/*
+
+Indexed Triangle Mesh Tests
+
+*/
+//-----------------------------------------------------------------------------
+
+package render
+
+import (
+	"testing"
+
+	"github.com/gmlewis/sdfx/sdf"
+	v3 "github.com/gmlewis/sdfx/vec/v3"
+)
+
+//-----------------------------------------------------------------------------
+
+func Test_Mesh_Weld(t *testing.T) {
+	// exact welding only merges identical vertices
+	m := NewMesh(0)
+	a := m.AddVertex(v3.Vec{X: 1, Y: 2, Z: 3})
+	if b := m.AddVertex(v3.Vec{X: 1, Y: 2, Z: 3}); b != a {
+		t.Errorf("identical vertices: %d != %d", b, a)
+	}
+	if b := m.AddVertex(v3.Vec{X: 1, Y: 2, Z: 3 + 1e-12}); b == a {
+		t.Error("distinct vertices were welded")
+	}
+
+	// tolerance welding merges close vertices, including across grid cells
+	const tol = 1e-3
+	m = NewMesh(tol)
+	a = m.AddVertex(v3.Vec{X: 0.9999 * tol, Y: 0, Z: 0})
+	tests := []struct {
+		v    v3.Vec
+		weld bool
+	}{
+		{v3.Vec{X: 1.0001 * tol, Y: 0, Z: 0}, true},
+		{v3.Vec{X: 0.5 * tol, Y: 0.5 * tol, Z: -0.5 * tol}, true},
+		{v3.Vec{X: -0.1 * tol, Y: 0, Z: 0}, false},
+		{v3.Vec{X: 0.9999 * tol, Y: 1.1 * tol, Z: 0}, false},
+	}
+	for _, test := range tests {
+		if b := m.AddVertex(test.v); (b == a) != test.weld {
+			t.Errorf("%v: weld %v (expected) %v (actual)", test.v, test.weld, b == a)
+		}
+	}
+
+	// triangles that collapse after welding are discarded
+	m = NewMesh(tol)
+	m.AddTriangle(NewTriangle3(v3.Vec{}, v3.Vec{X: 0.1 * tol}, v3.Vec{Y: 1}))
+	m.AddTriangle(NewTriangle3(v3.Vec{}, v3.Vec{X: 1}, v3.Vec{Y: 1}))
+	if len(m.Triangle) != 1 || len(m.Vertex) != 3 {
+		t.Errorf("%d triangles, %d vertices (expected 1, 3)", len(m.Triangle), len(m.Vertex))
+	}
+}
+
+func Test_ToMesh(t *testing.T) {
+	s, err := sdf.Sphere3D(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := ToMesh(s, NewMarchingCubesUniform(40))
+	if !m.Watertight() {
+		t.Fatal("sphere mesh is not watertight")
+	}
+	// Euler characteristic of a sphere: V - E + F = 2, with E = 3F/2
+	if v, f := len(m.Vertex), len(m.Triangle); 2*v-f != 4 {
+		t.Errorf("%d vertices, %d triangles: not a closed sphere", v, f)
+	}
+	// the welded mesh has the same triangles as the rendering
+	if n := len(ToTriangles(s, NewMarchingCubesUniform(40))); n != len(m.Triangle) {
+		t.Errorf("%d triangles (expected) %d (actual)", n, len(m.Triangle))
+	}
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,80 @@
+//-----------------------------------------------------------------------------
+/*
+
+Output a 3D triangle mesh to a Wavefront OBJ file.
+
+https://en.wikipedia.org/wiki/Wavefront_.obj_file
+
+Only the geometry is written: vertices, optional vertex normals and faces.
+
+*/
+//-----------------------------------------------------------------------------
+
+package render
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+
+	"github.com/gmlewis/sdfx/sdf"
+	v3 "github.com/gmlewis/sdfx/vec/v3"
+)
+
+//-----------------------------------------------------------------------------
+
+// SaveMeshOBJ writes an indexed triangle mesh to an OBJ file.
+// The vertex normals are optional (nil for none).
+func SaveMeshOBJ(path string, m *Mesh, normals []v3.Vec) error {
+	if normals != nil && len(normals) != len(m.Vertex) {
+		return sdf.ErrMsg("the number of normals must match the number of vertices")
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	buf := bufio.NewWriter(file)
+	fmt.Fprintf(buf, "# sdfx\n")
+	for _, v := range m.Vertex {
+		fmt.Fprintf(buf, "v %s %s %s\n", formatFloat(v.X), formatFloat(v.Y), formatFloat(v.Z))
+	}
+	for _, n := range normals {
+		fmt.Fprintf(buf, "vn %s %s %s\n", formatFloat(n.X), formatFloat(n.Y), formatFloat(n.Z))
+	}
+	// OBJ indices start at 1
+	for _, t := range m.Triangle {
+		if normals != nil {
+			fmt.Fprintf(buf, "f %d//%d %d//%d %d//%d\n", t[0]+1, t[0]+1, t[1]+1, t[1]+1, t[2]+1, t[2]+1)
+		} else {
+			fmt.Fprintf(buf, "f %d %d %d\n", t[0]+1, t[1]+1, t[2]+1)
+		}
+	}
+
+	return buf.Flush()
+}
+
+//-----------------------------------------------------------------------------
+
+// ToOBJ renders an SDF3 to an OBJ file.
+func ToOBJ(
+	s sdf.SDF3, // sdf3 to render
+	path string, // path to filename
+	r Render3, // rendering method
+	normals bool, // write vertex normals
+) {
+	fmt.Printf("rendering %s (%s)\n", path, r.Info(s))
+	m := ToMesh(s, r)
+	var n []v3.Vec
+	if normals {
+		n = m.Normals(s)
+	}
+	// write the mesh to an OBJ file
+	if err := SaveMeshOBJ(path, m, n); err != nil {
+		fmt.Printf("%s", err)
+	}
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,133 @@
+//-----------------------------------------------------------------------------
+/*
+
+Output a 3D triangle mesh to a PLY (Polygon File Format) file.
+
+http://paulbourke.net/dataformats/ply/
+
+Files can be ASCII or binary (little endian). Vertex positions and normals are
+written as 32-bit floats and faces as a list of 32-bit vertex indices.
+
+*/
+//-----------------------------------------------------------------------------
+
+package render
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"os"
+
+	"github.com/gmlewis/sdfx/sdf"
+	v3 "github.com/gmlewis/sdfx/vec/v3"
+)
+
+//-----------------------------------------------------------------------------
+
+// PLYFormat is the encoding of a PLY file.
+type PLYFormat int
+
+const (
+	// PLYASCII is a text PLY file.
+	PLYASCII PLYFormat = iota
+	// PLYBinary is a binary little endian PLY file.
+	PLYBinary
+)
+
+// SaveMeshPLY writes an indexed triangle mesh to a PLY file.
+// The vertex normals are optional (nil for none).
+func SaveMeshPLY(path string, m *Mesh, normals []v3.Vec, format PLYFormat) error {
+	if normals != nil && len(normals) != len(m.Vertex) {
+		return sdf.ErrMsg("the number of normals must match the number of vertices")
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	buf := bufio.NewWriter(file)
+
+	// header
+	fmt.Fprintf(buf, "ply\n")
+	switch format {
+	case PLYASCII:
+		fmt.Fprintf(buf, "format ascii 1.0\n")
+	case PLYBinary:
+		fmt.Fprintf(buf, "format binary_little_endian 1.0\n")
+	default:
+		return sdf.ErrMsg("unknown PLY format")
+	}
+	fmt.Fprintf(buf, "comment sdfx\n")
+	fmt.Fprintf(buf, "element vertex %d\n", len(m.Vertex))
+	fmt.Fprintf(buf, "property float x\nproperty float y\nproperty float z\n")
+	if normals != nil {
+		fmt.Fprintf(buf, "property float nx\nproperty float ny\nproperty float nz\n")
+	}
+	fmt.Fprintf(buf, "element face %d\n", len(m.Triangle))
+	fmt.Fprintf(buf, "property list uchar int vertex_indices\n")
+	fmt.Fprintf(buf, "end_header\n")
+
+	if format == PLYASCII {
+		for i, v := range m.Vertex {
+			fmt.Fprintf(buf, "%s %s %s", formatFloat(v.X), formatFloat(v.Y), formatFloat(v.Z))
+			if normals != nil {
+				n := normals[i]
+				fmt.Fprintf(buf, " %s %s %s", formatFloat(n.X), formatFloat(n.Y), formatFloat(n.Z))
+			}
+			fmt.Fprintf(buf, "\n")
+		}
+		for _, t := range m.Triangle {
+			fmt.Fprintf(buf, "3 %d %d %d\n", t[0], t[1], t[2])
+		}
+		return buf.Flush()
+	}
+
+	for i, v := range m.Vertex {
+		if err := binary.Write(buf, binary.LittleEndian, toFloat32(v)); err != nil {
+			return err
+		}
+		if normals != nil {
+			if err := binary.Write(buf, binary.LittleEndian, toFloat32(normals[i])); err != nil {
+				return err
+			}
+		}
+	}
+	type plyFace struct {
+		N uint8
+		V [3]int32
+	}
+	for _, t := range m.Triangle {
+		f := plyFace{3, [3]int32{int32(t[0]), int32(t[1]), int32(t[2])}}
+		if err := binary.Write(buf, binary.LittleEndian, &f); err != nil {
+			return err
+		}
+	}
+	return buf.Flush()
+}
+
+//-----------------------------------------------------------------------------
+
+// ToPLY renders an SDF3 to a PLY file.
+func ToPLY(
+	s sdf.SDF3, // sdf3 to render
+	path string, // path to filename
+	r Render3, // rendering method
+	format PLYFormat, // ASCII or binary
+	normals bool, // write vertex normals
+) {
+	fmt.Printf("rendering %s (%s)\n", path, r.Info(s))
+	m := ToMesh(s, r)
+	var n []v3.Vec
+	if normals {
+		n = m.Normals(s)
+	}
+	// write the mesh to a PLY file
+	if err := SaveMeshPLY(path, m, n, format); err != nil {
+		fmt.Printf("%s", err)
+	}
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,248 @@
+//-----------------------------------------------------------------------------
+/*
+
+PLY and OBJ Output Tests
+
+*/
+//-----------------------------------------------------------------------------
+
+package render
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	v3 "github.com/gmlewis/sdfx/vec/v3"
+)
+
+//-----------------------------------------------------------------------------
+
+// testTetrahedron returns a tetrahedron mesh with a normal for each vertex.
+func testTetrahedron() (*Mesh, []v3.Vec) {
+	a := v3.Vec{X: 0.5, Y: -1.25, Z: 0}
+	b := v3.Vec{X: 3, Y: 0, Z: 0.125}
+	c := v3.Vec{X: 0, Y: 2.75, Z: 0}
+	d := v3.Vec{X: 0, Y: 0, Z: -4}
+	m := NewMeshFromTriangles([]*Triangle3{
+		NewTriangle3(a, c, b),
+		NewTriangle3(a, b, d),
+		NewTriangle3(b, c, d),
+		NewTriangle3(c, a, d),
+	}, 0)
+	centre := a.Add(b).Add(c).Add(d).DivScalar(4)
+	normals := make([]v3.Vec, len(m.Vertex))
+	for i, v := range m.Vertex {
+		normals[i] = v.Sub(centre).Normalize()
+	}
+	return m, normals
+}
+
+// plyHeader reads a PLY header and returns its lines.
+func plyHeader(r *bufio.Reader) ([]string, error) {
+	var lines []string
+	for {
+		s, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		s = strings.TrimSuffix(s, "\n")
+		lines = append(lines, s)
+		if s == "end_header" {
+			return lines, nil
+		}
+	}
+}
+
+func Test_SaveMeshPLY(t *testing.T) {
+	m, normals := testTetrahedron()
+	if len(m.Vertex) != 4 || len(m.Triangle) != 4 {
+		t.Fatalf("bad test mesh: %d vertices, %d triangles", len(m.Vertex), len(m.Triangle))
+	}
+	dir := t.TempDir()
+	tests := []struct {
+		format  PLYFormat
+		normals []v3.Vec
+		name    string
+	}{
+		{PLYASCII, nil, "ascii 1.0"},
+		{PLYASCII, normals, "ascii 1.0"},
+		{PLYBinary, nil, "binary_little_endian 1.0"},
+		{PLYBinary, normals, "binary_little_endian 1.0"},
+	}
+	for i, test := range tests {
+		path := filepath.Join(dir, fmt.Sprintf("test%d.ply", i))
+		if err := SaveMeshPLY(path, m, test.normals, test.format); err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := bufio.NewReader(bytes.NewReader(data))
+		header, err := plyHeader(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the header declares the format, the elements and their properties
+		expect := []string{
+			"ply",
+			"format " + test.name,
+			"comment sdfx",
+			"element vertex 4",
+			"property float x",
+			"property float y",
+			"property float z",
+		}
+		if test.normals != nil {
+			expect = append(expect, "property float nx", "property float ny", "property float nz")
+		}
+		expect = append(expect, "element face 4", "property list uchar int vertex_indices", "end_header")
+		if strings.Join(header, "\n") != strings.Join(expect, "\n") {
+			t.Errorf("%d: header\n%s\nexpected\n%s", i, strings.Join(header, "\n"), strings.Join(expect, "\n"))
+			continue
+		}
+		// read back the vertices (and normals) and the faces
+		vertex := make([][6]float32, len(m.Vertex))
+		face := make([][3]int32, len(m.Triangle))
+		nv := 3
+		if test.normals != nil {
+			nv = 6
+		}
+		if test.format == PLYASCII {
+			for j := range vertex {
+				s, _ := r.ReadString('\n')
+				f := strings.Fields(s)
+				if len(f) != nv {
+					t.Fatalf("%d: vertex line %q", i, s)
+				}
+				for k := range f {
+					var x float32
+					fmt.Sscan(f[k], &x)
+					vertex[j][k] = x
+				}
+			}
+			for j := range face {
+				var n int
+				s, _ := r.ReadString('\n')
+				fmt.Sscan(s, &n, &face[j][0], &face[j][1], &face[j][2])
+				if n != 3 {
+					t.Fatalf("%d: face line %q", i, s)
+				}
+			}
+		} else {
+			for j := range vertex {
+				if err := binary.Read(r, binary.LittleEndian, vertex[j][:nv]); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for j := range face {
+				var n uint8
+				if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+					t.Fatal(err)
+				}
+				if err := binary.Read(r, binary.LittleEndian, face[j][:]); err != nil {
+					t.Fatal(err)
+				}
+				if n != 3 {
+					t.Fatalf("%d: face %d has %d vertices", i, j, n)
+				}
+			}
+		}
+		if rest, _ := r.ReadString(0); rest != "" {
+			t.Errorf("%d: %d bytes after the faces", i, len(rest))
+		}
+		for j, v := range m.Vertex {
+			got := v3.Vec{X: float64(vertex[j][0]), Y: float64(vertex[j][1]), Z: float64(vertex[j][2])}
+			if !got.Equals(v, 1e-6) {
+				t.Errorf("%d: vertex %d is %v, expected %v", i, j, got, v)
+			}
+			if test.normals != nil {
+				got = v3.Vec{X: float64(vertex[j][3]), Y: float64(vertex[j][4]), Z: float64(vertex[j][5])}
+				if !got.Equals(test.normals[j], 1e-6) {
+					t.Errorf("%d: normal %d is %v, expected %v", i, j, got, test.normals[j])
+				}
+			}
+		}
+		for j, tri := range m.Triangle {
+			if int(face[j][0]) != tri[0] || int(face[j][1]) != tri[1] || int(face[j][2]) != tri[2] {
+				t.Errorf("%d: face %d is %v, expected %v", i, j, face[j], tri)
+			}
+		}
+	}
+	// bad parameters
+	if err := SaveMeshPLY(filepath.Join(dir, "bad.ply"), m, normals[:2], PLYASCII); err == nil {
+		t.Error("expected an error for the wrong number of normals")
+	}
+	if err := SaveMeshPLY(filepath.Join(dir, "bad.ply"), m, nil, PLYFormat(2)); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
+
+func Test_SaveMeshOBJ(t *testing.T) {
+	m, normals := testTetrahedron()
+	dir := t.TempDir()
+	for i, n := range [][]v3.Vec{nil, normals} {
+		path := filepath.Join(dir, fmt.Sprintf("test%d.obj", i))
+		if err := SaveMeshOBJ(path, m, n); err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var vertex, normal []v3.Vec
+		var face [][3]int
+		for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+			f := strings.Fields(line)
+			switch f[0] {
+			case "v", "vn":
+				var v v3.Vec
+				fmt.Sscan(strings.Join(f[1:], " "), &v.X, &v.Y, &v.Z)
+				if f[0] == "v" {
+					vertex = append(vertex, v)
+				} else {
+					normal = append(normal, v)
+				}
+			case "f":
+				// OBJ indices start at 1, the vertex and normal indices are the same
+				var tri [3]int
+				for k, s := range f[1:] {
+					idx := strings.Split(s, "//")
+					if n != nil && (len(idx) != 2 || idx[0] != idx[1]) {
+						t.Fatalf("%d: face %q", i, line)
+					}
+					fmt.Sscan(idx[0], &tri[k])
+					tri[k]--
+				}
+				face = append(face, tri)
+			}
+		}
+		if len(vertex) != len(m.Vertex) || len(normal) != len(n) || len(face) != len(m.Triangle) {
+			t.Fatalf("%d: %d vertices, %d normals, %d faces", i, len(vertex), len(normal), len(face))
+		}
+		for j, v := range m.Vertex {
+			if !vertex[j].Equals(v, 1e-6) {
+				t.Errorf("%d: vertex %d is %v, expected %v", i, j, vertex[j], v)
+			}
+		}
+		for j := range normal {
+			if math.Abs(normal[j].Length()-1) > 1e-6 || !normal[j].Equals(n[j], 1e-6) {
+				t.Errorf("%d: normal %d is %v, expected %v", i, j, normal[j], n[j])
+			}
+		}
+		for j, tri := range m.Triangle {
+			if face[j] != tri {
+				t.Errorf("%d: face %d is %v, expected %v", i, j, face[j], tri)
+			}
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
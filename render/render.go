@@ -52,6 +52,30 @@ func ToTriangles(
 
 //-----------------------------------------------------------------------------
 
+// ToMesh renders an SDF3 to an indexed triangle mesh.
+// Vertices closer than a small fraction of the bounding box size are welded together.
+// Use SaveMeshSTL or SaveMesh3MF to write a welded mesh. ToSTL and To3MF stream
+// the triangles to the file without holding the whole mesh in memory.
+func ToMesh(
+	s sdf.SDF3, // sdf3 to render
+	r Render3, // rendering method
+) *Mesh {
+	m := NewMesh(s.BoundingBox().Size().MaxComponent() * weldTolerance)
+	var wg sync.WaitGroup
+	// To write the triangles.
+	output := writeMesh(&wg, m)
+	// Run the renderer.
+	r.Render(s, output)
+	// Stop the writer reading on the channel.
+	close(output)
+	// Wait for the write to complete.
+	wg.Wait()
+	// return the mesh
+	return m
+}
+
+//-----------------------------------------------------------------------------
+
 // ToSTL renders an SDF3 to an STL file.
 func ToSTL(
 	s sdf.SDF3, // sdf3 to render
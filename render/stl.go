@@ -14,6 +14,8 @@ import (
 	"fmt"
 	"os"
 	"sync"
+
+	v3 "github.com/gmlewis/sdfx/vec/v3"
 )
 
 //-----------------------------------------------------------------------------
@@ -70,6 +72,42 @@ func SaveSTL(path string, mesh []*Triangle3) error {
 	return buf.Flush()
 }
 
+// SaveMeshSTL writes an indexed triangle mesh to an STL file.
+func SaveMeshSTL(path string, m *Mesh) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	buf := bufio.NewWriter(file)
+	header := STLHeader{}
+	header.Count = uint32(len(m.Triangle))
+	if err := binary.Write(buf, binary.LittleEndian, &header); err != nil {
+		return err
+	}
+
+	var d STLTriangle
+	for _, t := range m.Triangle {
+		v1, v2, v3 := m.Vertex[t[0]], m.Vertex[t[1]], m.Vertex[t[2]]
+		n := NewTriangle3(v1, v2, v3).Normal()
+		d.Normal = toFloat32(n)
+		d.Vertex1 = toFloat32(v1)
+		d.Vertex2 = toFloat32(v2)
+		d.Vertex3 = toFloat32(v3)
+		if err := binary.Write(buf, binary.LittleEndian, &d); err != nil {
+			return err
+		}
+	}
+
+	return buf.Flush()
+}
+
+// toFloat32 converts a 3D float vector to an STL vector.
+func toFloat32(a v3.Vec) [3]float32 {
+	return [3]float32{float32(a.X), float32(a.Y), float32(a.Z)}
+}
+
 //-----------------------------------------------------------------------------
 
 // writeSTL writes a stream of triangles to an STL file.
@@ -0,0 +1,403 @@
+//-----------------------------------------------------------------------------
+/*
+
+Mesh Validation
+
+Check a triangle mesh for problems that will upset a slicer:
+
+* open edges (used by one triangle, the mesh has holes)
+* non-manifold edges (used by more than two triangles)
+* inconsistent winding (an edge used twice in the same direction)
+* degenerate triangles (repeated vertices or no area)
+* self-intersections (triangles that cross each other)
+
+*/
+//-----------------------------------------------------------------------------
+
+package render
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/gmlewis/sdfx/sdf"
+	v3 "github.com/gmlewis/sdfx/vec/v3"
+	"github.com/gmlewis/sdfx/vec/v3i"
+)
+
+//-----------------------------------------------------------------------------
+
+// MeshEdge is an edge of a mesh.
+type MeshEdge struct {
+	V0, V1 int // vertex indices
+	Count  int // number of triangles using the edge
+}
+
+// MeshReport is the result of validating a mesh.
+type MeshReport struct {
+	m                *Mesh      // the validated mesh
+	OpenEdges        []MeshEdge // edges used by a single triangle
+	NonManifoldEdges []MeshEdge // edges used by more than two triangles
+	WindingErrors    []MeshEdge // edges used twice in the same direction
+	Degenerate       []int      // degenerate triangles
+	Intersections    [][2]int   // pairs of intersecting triangles
+}
+
+// OK returns true if no problems were found.
+func (r *MeshReport) OK() bool {
+	return len(r.OpenEdges) == 0 &&
+		len(r.NonManifoldEdges) == 0 &&
+		len(r.WindingErrors) == 0 &&
+		len(r.Degenerate) == 0 &&
+		len(r.Intersections) == 0
+}
+
+// reportLimit is the maximum number of locations listed for each problem.
+const reportLimit = 10
+
+// String returns a summary of the report with the locations of the first few problems.
+func (r *MeshReport) String() string {
+	var sb strings.Builder
+	m := r.m
+	fmt.Fprintf(&sb, "%d triangles, %d vertices\n", len(m.Triangle), len(m.Vertex))
+	edges := func(name string, es []MeshEdge) {
+		fmt.Fprintf(&sb, "%s: %d\n", name, len(es))
+		for i, e := range es {
+			if i == reportLimit {
+				fmt.Fprintf(&sb, "  ...\n")
+				break
+			}
+			fmt.Fprintf(&sb, "  %v %v (%d triangles)\n", m.Vertex[e.V0], m.Vertex[e.V1], e.Count)
+		}
+	}
+	triangle := func(i int) string {
+		t := m.Triangle[i]
+		return fmt.Sprintf("%d %v %v %v", i, m.Vertex[t[0]], m.Vertex[t[1]], m.Vertex[t[2]])
+	}
+	edges("open edges", r.OpenEdges)
+	edges("non-manifold edges", r.NonManifoldEdges)
+	edges("inconsistent winding", r.WindingErrors)
+	fmt.Fprintf(&sb, "degenerate triangles: %d\n", len(r.Degenerate))
+	for i, k := range r.Degenerate {
+		if i == reportLimit {
+			fmt.Fprintf(&sb, "  ...\n")
+			break
+		}
+		fmt.Fprintf(&sb, "  %s\n", triangle(k))
+	}
+	fmt.Fprintf(&sb, "self-intersections: %d\n", len(r.Intersections))
+	for i, k := range r.Intersections {
+		if i == reportLimit {
+			fmt.Fprintf(&sb, "  ...\n")
+			break
+		}
+		fmt.Fprintf(&sb, "  %s\n  %s\n", triangle(k[0]), triangle(k[1]))
+	}
+	return sb.String()
+}
+
+//-----------------------------------------------------------------------------
+
+// Validate checks a mesh for open edges, non-manifold edges, inconsistent winding,
+// degenerate triangles and self-intersections.
+// Triangles that share an edge are not tested for intersection.
+func Validate(m *Mesh) *MeshReport {
+	r := &MeshReport{m: m}
+	if len(m.Triangle) == 0 {
+		return r
+	}
+
+	// the tolerance is relative to the size of the mesh
+	bb := sdf.Box3{Min: m.Vertex[m.Triangle[0][0]], Max: m.Vertex[m.Triangle[0][0]]}
+	for _, t := range m.Triangle {
+		for _, k := range t {
+			bb = bb.Include(m.Vertex[k])
+		}
+	}
+	tolerance := bb.Size().MaxComponent() * weldTolerance
+
+	// count the uses of each directed edge
+	directed := make(map[[2]int]int)
+	var order [][2]int
+	for _, t := range m.Triangle {
+		for j := 0; j < 3; j++ {
+			e := edgeKey(t[j], t[(j+1)%3])
+			if directed[e]+directed[[2]int{e[1], e[0]}] == 0 {
+				order = append(order, e)
+			}
+			directed[[2]int{t[j], t[(j+1)%3]}]++
+		}
+	}
+	for _, e := range order {
+		fwd := directed[e]
+		rev := directed[[2]int{e[1], e[0]}]
+		me := MeshEdge{e[0], e[1], fwd + rev}
+		switch {
+		case me.Count == 1:
+			r.OpenEdges = append(r.OpenEdges, me)
+		case me.Count > 2:
+			r.NonManifoldEdges = append(r.NonManifoldEdges, me)
+		case fwd != 1:
+			r.WindingErrors = append(r.WindingErrors, me)
+		}
+	}
+
+	// degenerate triangles
+	for i, t := range m.Triangle {
+		tri := NewTriangle3(m.Vertex[t[0]], m.Vertex[t[1]], m.Vertex[t[2]])
+		if t[0] == t[1] || t[1] == t[2] || t[2] == t[0] || tri.Degenerate(tolerance) || zeroArea(tri, tolerance) {
+			r.Degenerate = append(r.Degenerate, i)
+		}
+	}
+
+	r.Intersections = selfIntersections(m, tolerance)
+	return r
+}
+
+// zeroArea returns true if the height of a triangle is within tolerance.
+func zeroArea(t *Triangle3, tolerance float64) bool {
+	l := 0.0
+	for i := 0; i < 3; i++ {
+		l = math.Max(l, t.V[(i+1)%3].Sub(t.V[i]).Length())
+	}
+	area2 := t.V[1].Sub(t.V[0]).Cross(t.V[2].Sub(t.V[0])).Length()
+	return area2 <= tolerance*l
+}
+
+//-----------------------------------------------------------------------------
+
+// selfIntersections returns the pairs of intersecting triangles.
+// Triangles that share an edge are neighbours and are not tested.
+// Candidate pairs are found with a uniform grid sized to the triangles.
+func selfIntersections(m *Mesh, tolerance float64) [][2]int {
+	n := len(m.Triangle)
+	tri := make([][3]v3.Vec, n)
+	bb := make([]sdf.Box3, n)
+	size := 0.0
+	for i, t := range m.Triangle {
+		tri[i] = [3]v3.Vec{m.Vertex[t[0]], m.Vertex[t[1]], m.Vertex[t[2]]}
+		bb[i] = sdf.Box3{Min: tri[i][0], Max: tri[i][0]}.Include(tri[i][1]).Include(tri[i][2])
+		size += bb[i].Size().MaxComponent()
+	}
+	// the cell size is the mean triangle size
+	size /= float64(n)
+	if size == 0 {
+		size = 1
+	}
+	cell := func(p v3.Vec) v3i.Vec {
+		return v3i.Vec{
+			int(math.Floor(p.X / size)),
+			int(math.Floor(p.Y / size)),
+			int(math.Floor(p.Z / size)),
+		}
+	}
+	grid := make(map[v3i.Vec][]int)
+	for i := range bb {
+		lo, hi := cell(bb[i].Min), cell(bb[i].Max)
+		for x := lo.X; x <= hi.X; x++ {
+			for y := lo.Y; y <= hi.Y; y++ {
+				for z := lo.Z; z <= hi.Z; z++ {
+					c := v3i.Vec{x, y, z}
+					grid[c] = append(grid[c], i)
+				}
+			}
+		}
+	}
+
+	var result [][2]int
+	for c, list := range grid {
+		for i, a := range list {
+			for _, b := range list[i+1:] {
+				if bb[b].Min.X > bb[a].Max.X || bb[b].Max.X < bb[a].Min.X ||
+					bb[b].Min.Y > bb[a].Max.Y || bb[b].Max.Y < bb[a].Min.Y ||
+					bb[b].Min.Z > bb[a].Max.Z || bb[b].Max.Z < bb[a].Min.Z {
+					continue
+				}
+				// test each pair once, in the cell with the corner of the box overlap
+				if cell(bb[a].Min.Max(bb[b].Min)) != c {
+					continue
+				}
+				var hit bool
+				switch v := sharedVertices(m.Triangle[a], m.Triangle[b]); len(v) {
+				case 0:
+					hit = trianglesIntersect(tri[a], tri[b], tolerance)
+				case 1:
+					hit = vertexTrianglesIntersect(tri[a], tri[b], v[0][0], v[0][1])
+				}
+				if hit {
+					result = append(result, [2]int{min(a, b), max(a, b)})
+				}
+			}
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i][0] != result[j][0] {
+			return result[i][0] < result[j][0]
+		}
+		return result[i][1] < result[j][1]
+	})
+	return result
+}
+
+// sharedVertices returns the positions (in a and b) of the common vertices of two triangles.
+func sharedVertices(a, b [3]int) [][2]int {
+	var v [][2]int
+	for i := range a {
+		for j := range b {
+			if a[i] == b[j] {
+				v = append(v, [2]int{i, j})
+			}
+		}
+	}
+	return v
+}
+
+// vertexTrianglesIntersect returns true if two triangles that share a single vertex
+// (a[i] == b[j]) intersect anywhere other than the shared vertex.
+func vertexTrianglesIntersect(a, b [3]v3.Vec, i, j int) bool {
+	// directions with a smaller angle between them are parallel
+	const parallel = 1e-9
+	v := a[i]
+	a1, a2 := a[(i+1)%3].Sub(v), a[(i+2)%3].Sub(v)
+	b1, b2 := b[(j+1)%3].Sub(v), b[(j+2)%3].Sub(v)
+	na := a1.Cross(a2)
+	nb := b1.Cross(b2)
+	d := na.Cross(nb)
+	if d.Length() <= parallel*na.Length()*nb.Length() {
+		// Coplanar: the triangles overlap if the corners at the shared vertex overlap.
+		// That is if an edge of one is inside the corner of the other, or the corners are the same.
+		sameDir := func(x, y v3.Vec) bool {
+			return x.Cross(y).Length() <= parallel*x.Length()*y.Length() && x.Dot(y) > 0
+		}
+		same := (sameDir(a1, b1) && sameDir(a2, b2)) || (sameDir(a1, b2) && sameDir(a2, b1))
+		return same || inCorner(b1, a1, a2, na) || inCorner(b2, a1, a2, na) ||
+			inCorner(a1, b1, b2, nb) || inCorner(a2, b1, b2, nb)
+	}
+	// The planes meet in a line through the shared vertex. Each triangle meets the line
+	// in a segment starting at the vertex, they intersect if both go the same way.
+	return (inCornerClosed(d, a1, a2, na) && inCornerClosed(d, b1, b2, nb)) ||
+		(inCornerClosed(d.Neg(), a1, a2, na) && inCornerClosed(d.Neg(), b1, b2, nb))
+}
+
+// inCorner returns true if the direction d (in the plane of e1, e2) is strictly between e1 and e2.
+// n is e1 x e2.
+func inCorner(d, e1, e2, n v3.Vec) bool {
+	return d.Cross(e2).Dot(n) > 0 && e1.Cross(d).Dot(n) > 0
+}
+
+// inCornerClosed returns true if the direction d (in the plane of e1, e2) is between e1 and e2,
+// including along e1 or e2.
+func inCornerClosed(d, e1, e2, n v3.Vec) bool {
+	return d.Cross(e2).Dot(n) >= 0 && e1.Cross(d).Dot(n) >= 0
+}
+
+// trianglesIntersect returns true if two triangles intersect.
+func trianglesIntersect(a, b [3]v3.Vec, tolerance float64) bool {
+	na := a[1].Sub(a[0]).Cross(a[2].Sub(a[0])).Normalize()
+	nb := b[1].Sub(b[0]).Cross(b[2].Sub(b[0])).Normalize()
+	// if all of one triangle is on one side of the plane of the other there is no intersection
+	side := func(n, p v3.Vec, t [3]v3.Vec) (bool, bool) {
+		var pos, neg int
+		for _, v := range t {
+			d := n.Dot(v.Sub(p))
+			if d > tolerance {
+				pos++
+			} else if d < -tolerance {
+				neg++
+			}
+		}
+		return pos == 3 || neg == 3, pos == 0 && neg == 0
+	}
+	if apart, _ := side(na, a[0], b); apart {
+		return false
+	}
+	apart, coplanar := side(nb, b[0], a)
+	if apart {
+		return false
+	}
+	if coplanar {
+		return coplanarIntersect(a, b, na)
+	}
+	// the triangles intersect if an edge of one crosses the other
+	for i := 0; i < 3; i++ {
+		if segmentTriangle(a[i], a[(i+1)%3], b) || segmentTriangle(b[i], b[(i+1)%3], a) {
+			return true
+		}
+	}
+	return false
+}
+
+// segmentTriangle returns true if the segment p0-p1 crosses the triangle.
+// See: Moller, Trumbore, "Fast, Minimum Storage Ray/Triangle Intersection", 1997.
+func segmentTriangle(p0, p1 v3.Vec, t [3]v3.Vec) bool {
+	dir := p1.Sub(p0)
+	e1 := t[1].Sub(t[0])
+	e2 := t[2].Sub(t[0])
+	h := dir.Cross(e2)
+	det := e1.Dot(h)
+	if math.Abs(det) <= 1e-12*dir.Length()*e1.Length()*e2.Length() {
+		// parallel (a segment in the plane of the triangle is found by the other triangle's edges)
+		return false
+	}
+	s := p0.Sub(t[0])
+	u := s.Dot(h) / det
+	if u < 0 || u > 1 {
+		return false
+	}
+	q := s.Cross(e1)
+	v := dir.Dot(q) / det
+	if v < 0 || u+v > 1 {
+		return false
+	}
+	k := e2.Dot(q) / det
+	return k >= 0 && k <= 1
+}
+
+// coplanarIntersect returns true if two coplanar triangles overlap.
+func coplanarIntersect(a, b [3]v3.Vec, n v3.Vec) bool {
+	// project onto the plane most perpendicular to the normal
+	n = n.Abs()
+	project := func(p v3.Vec) [2]float64 {
+		switch {
+		case n.X >= n.Y && n.X >= n.Z:
+			return [2]float64{p.Y, p.Z}
+		case n.Y >= n.Z:
+			return [2]float64{p.Z, p.X}
+		}
+		return [2]float64{p.X, p.Y}
+	}
+	var a2, b2 [3][2]float64
+	for i := range a {
+		a2[i] = project(a[i])
+		b2[i] = project(b[i])
+	}
+	cross := func(o, p, q [2]float64) float64 {
+		return (p[0]-o[0])*(q[1]-o[1]) - (p[1]-o[1])*(q[0]-o[0])
+	}
+	// proper edge crossings
+	for i := 0; i < 3; i++ {
+		p0, p1 := a2[i], a2[(i+1)%3]
+		for j := 0; j < 3; j++ {
+			q0, q1 := b2[j], b2[(j+1)%3]
+			d0 := cross(p0, p1, q0)
+			d1 := cross(p0, p1, q1)
+			d2 := cross(q0, q1, p0)
+			d3 := cross(q0, q1, p1)
+			if d0*d1 < 0 && d2*d3 < 0 {
+				return true
+			}
+		}
+	}
+	// one triangle inside the other
+	inside := func(p [2]float64, t [3][2]float64) bool {
+		d0 := cross(t[0], t[1], p)
+		d1 := cross(t[1], t[2], p)
+		d2 := cross(t[2], t[0], p)
+		return (d0 > 0 && d1 > 0 && d2 > 0) || (d0 < 0 && d1 < 0 && d2 < 0)
+	}
+	return inside(a2[0], b2) || inside(b2[0], a2)
+}
+
+//-----------------------------------------------------------------------------
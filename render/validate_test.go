@@ -0,0 +1,113 @@
+//-----------------------------------------------------------------------------
+/*
+
+Mesh Validation Tests
+
+*/
+//-----------------------------------------------------------------------------
+
+package render
+
+import (
+	"testing"
+
+	"github.com/gmlewis/sdfx/sdf"
+	v3 "github.com/gmlewis/sdfx/vec/v3"
+)
+
+//-----------------------------------------------------------------------------
+
+func Test_Validate_Closed(t *testing.T) {
+	if r := Validate(bipyramid()); !r.OK() {
+		t.Errorf("bipyramid:\n%s", r)
+	}
+	s, err := sdf.Sphere3D(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := Validate(ToMesh(s, NewMarchingCubesUniform(30))); !r.OK() {
+		t.Errorf("sphere:\n%s", r)
+	}
+	// flat faces have fans of coplanar triangles around each vertex
+	box, err := sdf.Box3D(v3.Vec{X: 10, Y: 8, Z: 6}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cylinder, err := sdf.Cylinder3D(10, 2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := Validate(ToMesh(sdf.Difference3D(box, cylinder), NewMarchingCubesUniform(50))); !r.OK() {
+		t.Errorf("box:\n%s", r)
+	}
+}
+
+func Test_Validate_Edges(t *testing.T) {
+	// a hole
+	m := bipyramid()
+	m.Triangle = m.Triangle[1:]
+	r := Validate(m)
+	if len(r.OpenEdges) != 3 || len(r.NonManifoldEdges) != 0 || len(r.WindingErrors) != 0 {
+		t.Errorf("hole:\n%s", r)
+	}
+
+	// a fin on an edge
+	m = bipyramid()
+	m.AddTriangle(NewTriangle3(v3.Vec{X: 1}, v3.Vec{X: -0.5, Y: 0.866}, v3.Vec{X: 2, Y: 2}))
+	r = Validate(m)
+	if len(r.NonManifoldEdges) != 1 || len(r.OpenEdges) != 2 {
+		t.Errorf("fin:\n%s", r)
+	}
+	e := r.NonManifoldEdges[0]
+	if e.Count != 3 || m.Vertex[e.V0].Add(m.Vertex[e.V1]) != (v3.Vec{X: 0.5, Y: 0.866}) {
+		t.Errorf("fin: bad edge %v", e)
+	}
+
+	// a flipped triangle
+	m = bipyramid()
+	m.Triangle[0] = [3]int{m.Triangle[0][0], m.Triangle[0][2], m.Triangle[0][1]}
+	r = Validate(m)
+	if len(r.WindingErrors) != 3 || len(r.OpenEdges) != 0 {
+		t.Errorf("flipped:\n%s", r)
+	}
+
+	// a degenerate triangle
+	m = NewMesh(0)
+	m.AddTriangle(NewTriangle3(v3.Vec{}, v3.Vec{X: 1}, v3.Vec{X: 2}))
+	if r := Validate(m); len(r.Degenerate) != 1 {
+		t.Errorf("degenerate:\n%s", r)
+	}
+}
+
+func Test_Validate_Intersections(t *testing.T) {
+	a := NewTriangle3(v3.Vec{}, v3.Vec{X: 2}, v3.Vec{Y: 2})
+	tests := []struct {
+		name string
+		b    *Triangle3
+		hit  bool
+	}{
+		// no shared vertices
+		{"crossing", NewTriangle3(v3.Vec{X: 0.5, Y: 0.5, Z: -1}, v3.Vec{X: 0.5, Y: 0.5, Z: 1}, v3.Vec{X: 3, Y: 3}), true},
+		{"apart", NewTriangle3(v3.Vec{X: 3, Y: 3, Z: -1}, v3.Vec{X: 3, Y: 3, Z: 1}, v3.Vec{X: 4, Y: 4}), false},
+		{"coplanar overlap", NewTriangle3(v3.Vec{X: 0.2, Y: 0.2}, v3.Vec{X: 3, Y: 0.2}, v3.Vec{X: 0.2, Y: 3}), true},
+		// a shared vertex
+		{"vertex crossing", NewTriangle3(v3.Vec{}, v3.Vec{X: 1, Y: 1, Z: -1}, v3.Vec{X: 1, Y: 1, Z: 1}), true},
+		{"vertex touching", NewTriangle3(v3.Vec{}, v3.Vec{X: -1, Y: -1, Z: -1}, v3.Vec{X: -1, Y: -1, Z: 1}), false},
+		{"vertex coplanar overlap", NewTriangle3(v3.Vec{}, v3.Vec{X: 1, Y: 0.5}, v3.Vec{X: 0.5, Y: 1}), true},
+		{"vertex coplanar apart", NewTriangle3(v3.Vec{}, v3.Vec{X: -1}, v3.Vec{Y: -1}), false},
+		{"vertex shallow crossing", NewTriangle3(v3.Vec{}, v3.Vec{X: 1, Y: 0.3, Z: 0.01}, v3.Vec{X: 0.3, Y: 1, Z: -0.01}), true},
+		// a shared edge is not tested
+		{"edge", NewTriangle3(v3.Vec{X: 2}, v3.Vec{}, v3.Vec{X: 1, Y: 0.5}), false},
+	}
+	for _, test := range tests {
+		m := NewMesh(0)
+		m.AddTriangle(a)
+		m.AddTriangle(test.b)
+		r := Validate(m)
+		if hit := len(r.Intersections) != 0; hit != test.hit {
+			t.Errorf("%s: intersection %v (expected) %v (actual)", test.name, test.hit, hit)
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
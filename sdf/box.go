@@ -110,6 +110,13 @@ func (a Box2) Center() v2.Vec {
 	return a.Min.Add(a.Size().MulScalar(0.5))
 }
 
+// box2Radius returns the maximum distance from the origin to a 2d box.
+func box2Radius(a Box2) float64 {
+	x := math.Max(math.Abs(a.Min.X), math.Abs(a.Max.X))
+	y := math.Max(math.Abs(a.Min.Y), math.Abs(a.Max.Y))
+	return math.Sqrt(x*x + y*y)
+}
+
 //-----------------------------------------------------------------------------
 
 // ScaleAboutCenter returns a new 2d box scaled about the center of a box.
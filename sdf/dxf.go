@@ -0,0 +1,508 @@
+//-----------------------------------------------------------------------------
+/*
+
+DXF Import
+
+Convert the outlines in a DXF file into an SDF2.
+
+The LINE, ARC, CIRCLE, LWPOLYLINE (with bulges) and SPLINE entities in the
+ENTITIES section are converted to polylines, and the polylines are chained
+end to end into closed contours. Outlines that can't be closed (construction
+lines, stray segments, gaps in a drawing) are dropped. The contours are
+filled with the even-odd rule, so nested contours alternate between solid
+and hole regardless of the direction they were drawn in.
+
+Splines without control points are flattened through their fit points.
+Other entities (text, dimensions, block inserts, etc.) are ignored.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	v2 "github.com/gmlewis/sdfx/vec/v2"
+)
+
+//-----------------------------------------------------------------------------
+
+// dxfArcStep is the maximum angle subtended by a line segment of a flattened arc.
+var dxfArcStep = DtoR(3)
+
+// dxfSplineSteps is the number of line segments per control point of a flattened spline.
+const dxfSplineSteps = 16
+
+// dxfTolerance is the distance (relative to the drawing size) within which end points are joined.
+const dxfTolerance = 1e-6
+
+// dxfPair is a group code and value.
+type dxfPair struct {
+	code  int
+	value string
+}
+
+// dxfEntity is the group code/value pairs for an entity.
+type dxfEntity struct {
+	name  string
+	pairs []dxfPair
+}
+
+// float returns the value of the first group code as a float.
+func (e *dxfEntity) float(code int, def float64) (float64, error) {
+	for _, p := range e.pairs {
+		if p.code == code {
+			return strconv.ParseFloat(p.value, 64)
+		}
+	}
+	return def, nil
+}
+
+// floats returns the values of all the group codes as floats.
+func (e *dxfEntity) floats(code int) ([]float64, error) {
+	var x []float64
+	for _, p := range e.pairs {
+		if p.code == code {
+			f, err := strconv.ParseFloat(p.value, 64)
+			if err != nil {
+				return nil, err
+			}
+			x = append(x, f)
+		}
+	}
+	return x, nil
+}
+
+// ocs returns a function that converts points from the object coordinate system.
+// Only planar entities with an extrusion direction of +z or -z are supported.
+func (e *dxfEntity) ocs() (func(v2.Vec) v2.Vec, error) {
+	z, err := e.float(230, 1)
+	if err != nil {
+		return nil, err
+	}
+	if z < 0 {
+		// the arbitrary axis algorithm maps the OCS x-axis to -x
+		return func(v v2.Vec) v2.Vec { return v2.Vec{-v.X, v.Y} }, nil
+	}
+	return func(v v2.Vec) v2.Vec { return v }, nil
+}
+
+//-----------------------------------------------------------------------------
+
+// dxfEntities returns the entities in the ENTITIES section of DXF data.
+func dxfEntities(r io.Reader) ([]dxfEntity, error) {
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, 1<<20)
+	line := 0
+	var pairs []dxfPair
+	for s.Scan() {
+		code := strings.TrimSpace(s.Text())
+		line++
+		if !s.Scan() {
+			break
+		}
+		line++
+		c, err := strconv.Atoi(code)
+		if err != nil {
+			return nil, fmt.Errorf("bad group code \"%s\" at line %d", code, line-1)
+		}
+		pairs = append(pairs, dxfPair{c, strings.TrimSpace(s.Text())})
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	// find the entities section
+	var entities []dxfEntity
+	inSection := false
+	for i := 0; i < len(pairs); i++ {
+		p := pairs[i]
+		if p.code != 0 {
+			if inSection && len(entities) != 0 {
+				e := &entities[len(entities)-1]
+				e.pairs = append(e.pairs, p)
+			}
+			continue
+		}
+		switch {
+		case p.value == "SECTION":
+			inSection = i+1 < len(pairs) && pairs[i+1].code == 2 && pairs[i+1].value == "ENTITIES"
+			i++
+		case p.value == "ENDSEC":
+			inSection = false
+		case inSection:
+			entities = append(entities, dxfEntity{name: p.value})
+		}
+	}
+	return entities, nil
+}
+
+//-----------------------------------------------------------------------------
+// Entity Conversion
+
+// dxfArc returns the points on an arc.
+func dxfArc(
+	center v2.Vec, // arc center
+	radius float64, // arc radius
+	start float64, // start angle (radians)
+	sweep float64, // sweep angle (radians), < 0 for clockwise
+) []v2.Vec {
+	n := int(math.Ceil(math.Abs(sweep) / dxfArcStep))
+	if n < 1 {
+		n = 1
+	}
+	p := make([]v2.Vec, n+1)
+	for i := range p {
+		s, c := math.Sincos(start + sweep*float64(i)/float64(n))
+		p[i] = center.Add(v2.Vec{c, s}.MulScalar(radius))
+	}
+	return p
+}
+
+// dxfBulge returns the points on an arc between two polyline vertices.
+// The bulge is the tangent of 1/4 of the included angle, > 0 for counter-clockwise.
+func dxfBulge(p0, p1 v2.Vec, bulge float64) []v2.Vec {
+	theta := 4 * math.Atan(bulge)
+	c := p1.Sub(p0)
+	d := c.Length()
+	if d == 0 {
+		return []v2.Vec{p0}
+	}
+	// the center is on the perpendicular bisector of the chord
+	h := 0.5 * d / math.Tan(0.5*theta)
+	center := p0.Add(p1).MulScalar(0.5).Add(v2.Vec{-c.Y, c.X}.MulScalar(h / d))
+	a := p0.Sub(center)
+	p := dxfArc(center, a.Length(), math.Atan2(a.Y, a.X), theta)
+	// use the exact end points
+	p[0] = p0
+	return p[:len(p)-1]
+}
+
+// dxfLwPolyline returns the points on a lightweight polyline.
+func dxfLwPolyline(e *dxfEntity) ([]v2.Vec, bool, error) {
+	flags, err := e.float(70, 0)
+	if err != nil {
+		return nil, false, err
+	}
+	closed := int(flags)&1 != 0
+	// the bulge (42) follows the vertex (10, 20) it belongs to
+	var vertex []v2.Vec
+	var bulge []float64
+	for _, p := range e.pairs {
+		if p.code != 10 && p.code != 20 && p.code != 42 {
+			continue
+		}
+		x, err := strconv.ParseFloat(p.value, 64)
+		if err != nil {
+			return nil, false, err
+		}
+		switch p.code {
+		case 10:
+			vertex = append(vertex, v2.Vec{x, 0})
+			bulge = append(bulge, 0)
+		case 20:
+			if len(vertex) != 0 {
+				vertex[len(vertex)-1].Y = x
+			}
+		case 42:
+			if len(bulge) != 0 {
+				bulge[len(bulge)-1] = x
+			}
+		}
+	}
+	if len(vertex) < 2 {
+		return nil, false, ErrMsg("polyline has less than 2 vertices")
+	}
+	n := len(vertex)
+	if !closed {
+		n--
+	}
+	var p []v2.Vec
+	for i := 0; i < n; i++ {
+		p0 := vertex[i]
+		p1 := vertex[(i+1)%len(vertex)]
+		if bulge[i] == 0 {
+			p = append(p, p0)
+		} else {
+			p = append(p, dxfBulge(p0, p1, bulge[i])...)
+		}
+	}
+	if !closed {
+		p = append(p, vertex[len(vertex)-1])
+	}
+	return p, closed, nil
+}
+
+// dxfSpline returns the points on a spline.
+func dxfSpline(e *dxfEntity) ([]v2.Vec, bool, error) {
+	flags, err := e.float(70, 0)
+	if err != nil {
+		return nil, false, err
+	}
+	closed := int(flags)&1 != 0
+	degree, err := e.float(71, 3)
+	if err != nil {
+		return nil, false, err
+	}
+	k := int(degree)
+	knots, err := e.floats(40)
+	if err != nil {
+		return nil, false, err
+	}
+	x, err := e.floats(10)
+	if err != nil {
+		return nil, false, err
+	}
+	y, err := e.floats(20)
+	if err != nil {
+		return nil, false, err
+	}
+	w, err := e.floats(41)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(x) == 0 {
+		// no control points, use the fit points
+		x, err = e.floats(11)
+		if err != nil {
+			return nil, false, err
+		}
+		y, err = e.floats(21)
+		if err != nil {
+			return nil, false, err
+		}
+		if len(x) != len(y) || len(x) < 2 {
+			return nil, false, ErrMsg("bad spline fit points")
+		}
+		p := make([]v2.Vec, len(x))
+		for i := range p {
+			p[i] = v2.Vec{x[i], y[i]}
+		}
+		return p, closed, nil
+	}
+	n := len(x)
+	if len(y) != n || k < 1 || n <= k || len(knots) != n+k+1 {
+		return nil, false, ErrMsg("bad spline control points or knots")
+	}
+	if len(w) != n {
+		w = make([]float64, n)
+		for i := range w {
+			w[i] = 1
+		}
+	}
+	// evaluate the NURBS curve with de Boor's algorithm
+	eval := func(t float64) v2.Vec {
+		// find the knot span
+		s := k
+		for s < n-1 && t >= knots[s+1] {
+			s++
+		}
+		// homogeneous control points
+		d := make([][3]float64, k+1)
+		for j := 0; j <= k; j++ {
+			i := j + s - k
+			d[j] = [3]float64{x[i] * w[i], y[i] * w[i], w[i]}
+		}
+		for r := 1; r <= k; r++ {
+			for j := k; j >= r; j-- {
+				i := j + s - k
+				den := knots[i+k-r+1] - knots[i]
+				a := 0.0
+				if den != 0 {
+					a = (t - knots[i]) / den
+				}
+				for c := 0; c < 3; c++ {
+					d[j][c] = (1-a)*d[j-1][c] + a*d[j][c]
+				}
+			}
+		}
+		return v2.Vec{d[k][0] / d[k][2], d[k][1] / d[k][2]}
+	}
+	t0, t1 := knots[k], knots[n]
+	m := dxfSplineSteps * n
+	p := make([]v2.Vec, m+1)
+	for i := range p {
+		p[i] = eval(t0 + (t1-t0)*float64(i)/float64(m))
+	}
+	return p, closed, nil
+}
+
+// dxfPolyline returns the points on an entity, nil for unsupported entities.
+func dxfPolyline(e *dxfEntity) ([]v2.Vec, bool, error) {
+	var p []v2.Vec
+	closed := false
+	var err error
+	switch e.name {
+	case "LINE":
+		var x [4]float64
+		for i, code := range []int{10, 20, 11, 21} {
+			if x[i], err = e.float(code, 0); err != nil {
+				return nil, false, err
+			}
+		}
+		// lines are not in an OCS
+		return []v2.Vec{{x[0], x[1]}, {x[2], x[3]}}, false, nil
+	case "ARC", "CIRCLE":
+		var x [5]float64
+		for i, code := range []int{10, 20, 40, 50, 51} {
+			if x[i], err = e.float(code, 0); err != nil {
+				return nil, false, err
+			}
+		}
+		start, end := DtoR(x[3]), DtoR(x[4])
+		sweep := end - start
+		if e.name == "CIRCLE" {
+			start, sweep = 0, Tau
+			closed = true
+		} else if sweep <= 0 {
+			sweep += Tau
+		}
+		if x[2] <= 0 {
+			return nil, false, ErrMsg("radius <= 0")
+		}
+		p = dxfArc(v2.Vec{x[0], x[1]}, x[2], start, sweep)
+		if closed {
+			p = p[:len(p)-1]
+		}
+	case "LWPOLYLINE":
+		p, closed, err = dxfLwPolyline(e)
+	case "SPLINE":
+		// splines are not in an OCS
+		return dxfSpline(e)
+	default:
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	ocs, err := e.ocs()
+	if err != nil {
+		return nil, false, err
+	}
+	for i := range p {
+		p[i] = ocs(p[i])
+	}
+	return p, closed, nil
+}
+
+//-----------------------------------------------------------------------------
+// Contour Assembly
+
+// dxfContours chains open polylines end to end into closed contours.
+// Chains that can't be closed are dropped, the number of them is returned.
+func dxfContours(open, closed [][]v2.Vec, tolerance float64) ([][]v2.Vec, int) {
+	dropped := 0
+	used := make([]bool, len(open))
+	for i := range open {
+		if used[i] {
+			continue
+		}
+		used[i] = true
+		c := append([]v2.Vec(nil), open[i]...)
+		isClosed := true
+		for !c[0].Equals(c[len(c)-1], tolerance) {
+			// find the next polyline
+			end := c[len(c)-1]
+			found := false
+			for j := range open {
+				if used[j] {
+					continue
+				}
+				p := open[j]
+				if end.Equals(p[len(p)-1], tolerance) {
+					// reverse it
+					q := make([]v2.Vec, len(p))
+					for k := range p {
+						q[k] = p[len(p)-1-k]
+					}
+					p = q
+				} else if !end.Equals(p[0], tolerance) {
+					continue
+				}
+				c = append(c, p[1:]...)
+				used[j] = true
+				found = true
+				break
+			}
+			if !found {
+				isClosed = false
+				break
+			}
+		}
+		if !isClosed {
+			dropped++
+			continue
+		}
+		// drop the repeated start point
+		closed = append(closed, c[:len(c)-1])
+	}
+	return closed, dropped
+}
+
+//-----------------------------------------------------------------------------
+
+// ParseDXF returns an SDF2 for the closed outlines in DXF data.
+// Outlines that don't chain into a closed contour are ignored.
+func ParseDXF(r io.Reader) (SDF2, error) {
+	entities, err := dxfEntities(r)
+	if err != nil {
+		return nil, err
+	}
+	var open, closed [][]v2.Vec
+	var bb Box2
+	first := true
+	for i := range entities {
+		p, isClosed, err := dxfPolyline(&entities[i])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", entities[i].name, err)
+		}
+		if len(p) == 0 {
+			continue
+		}
+		for _, v := range p {
+			if first {
+				bb = Box2{v, v}
+				first = false
+			}
+			bb = bb.Include(v)
+		}
+		if isClosed {
+			closed = append(closed, p)
+		} else {
+			open = append(open, p)
+		}
+	}
+	if len(open) == 0 && len(closed) == 0 {
+		return nil, ErrMsg("no outlines in DXF")
+	}
+	contours, dropped := dxfContours(open, closed, dxfTolerance*bb.Size().MaxComponent())
+	// drop contours with too few vertices to enclose an area
+	var c [][]v2.Vec
+	for _, v := range contours {
+		if len(v) >= 3 {
+			c = append(c, v)
+		}
+	}
+	if len(c) == 0 {
+		return nil, fmt.Errorf("no closed outlines in DXF (%d open outlines)", dropped)
+	}
+	return MultiPolygon2D(c, FillEvenOdd)
+}
+
+// LoadDXF returns an SDF2 for the closed outlines in a DXF file.
+func LoadDXF(fname string) (SDF2, error) {
+	f, err := os.Open(fname)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseDXF(f)
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,204 @@
+//-----------------------------------------------------------------------------
+/*
+
+Fonts
+
+Load OpenType fonts (TrueType or CFF outlines) and chain them together so
+glyphs missing from one font are taken from the next.
+
+Glyph outlines, advances and kerning are returned in the units of the
+primary font. Fallback fonts are scaled to the em size of the primary font.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+
+	v2 "github.com/gmlewis/sdfx/vec/v2"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+//-----------------------------------------------------------------------------
+
+// fontPPEM is the em size (pixels) used to read glyph data.
+// It keeps the 26.6 fixed point glyph coordinates from overflowing.
+const fontPPEM = 1024
+
+// fontFace is a single font in a font chain.
+type fontFace struct {
+	f   *sfnt.Font
+	src []byte // font file data
+}
+
+// Font is an OpenType font with optional fallback fonts.
+type Font struct {
+	faces []*fontFace // the primary font followed by the fallback fonts
+}
+
+// fontGlyph is a glyph from a font chain.
+type fontGlyph struct {
+	face *fontFace       // font containing the glyph
+	idx  sfnt.GlyphIndex // glyph index within the font
+}
+
+//-----------------------------------------------------------------------------
+
+// ParseFont parses OpenType (*.otf) or TrueType (*.ttf) font data.
+func ParseFont(b []byte) (*Font, error) {
+	f, err := sfnt.Parse(b)
+	if err != nil {
+		return nil, err
+	}
+	return &Font{[]*fontFace{{f, b}}}, nil
+}
+
+// LoadFont loads an OpenType (*.otf) or TrueType (*.ttf) font file.
+func LoadFont(fname string) (*Font, error) {
+	// read the font file
+	b, err := ioutil.ReadFile(fname)
+	if err != nil {
+		return nil, err
+	}
+	return ParseFont(b)
+}
+
+// FallbackFont returns a font that takes each glyph from the first of the fonts that has it.
+// The metrics (line height, ascent, descent) are those of the first font.
+func FallbackFont(fonts ...*Font) (*Font, error) {
+	var faces []*fontFace
+	for _, f := range fonts {
+		if f == nil {
+			return nil, ErrMsg("font is nil")
+		}
+		faces = append(faces, f.faces...)
+	}
+	if len(faces) == 0 {
+		return nil, ErrMsg("no fonts")
+	}
+	return &Font{faces}, nil
+}
+
+//-----------------------------------------------------------------------------
+
+// unitsPerEm returns the em size of the primary font (font units).
+func (f *Font) unitsPerEm() float64 {
+	return float64(f.faces[0].f.UnitsPerEm())
+}
+
+// scale returns the conversion from 26.6 glyph data to primary font units.
+func (f *Font) scale() float64 {
+	return f.unitsPerEm() / (64 * fontPPEM)
+}
+
+// glyph returns the glyph for a rune.
+// Runes missing from all fonts use the missing glyph of the primary font.
+func (f *Font) glyph(b *sfnt.Buffer, r rune) (fontGlyph, error) {
+	for _, face := range f.faces {
+		i, err := face.f.GlyphIndex(b, r)
+		if err != nil {
+			return fontGlyph{}, err
+		}
+		if i != 0 {
+			return fontGlyph{face, i}, nil
+		}
+	}
+	return fontGlyph{f.faces[0], 0}, nil
+}
+
+// advance returns the advance width of a glyph (font units).
+func (f *Font) advance(b *sfnt.Buffer, g fontGlyph) (float64, error) {
+	x, err := g.face.f.GlyphAdvance(b, g.idx, fixed.I(fontPPEM), font.HintingNone)
+	if err != nil {
+		return 0, err
+	}
+	return float64(x) * f.scale(), nil
+}
+
+// kern returns the kerning adjustment between two glyphs (font units).
+// Glyphs from different fonts are not kerned.
+func (f *Font) kern(b *sfnt.Buffer, g0, g1 fontGlyph) (float64, error) {
+	if g0.face != g1.face {
+		return 0, nil
+	}
+	x, err := g0.face.f.Kern(b, g0.idx, g1.idx, fixed.I(fontPPEM), font.HintingNone)
+	if err == sfnt.ErrNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return float64(x) * f.scale(), nil
+}
+
+// outline returns the SDF2 for a glyph outline (font units), nil for an empty glyph.
+func (f *Font) outline(b *sfnt.Buffer, g fontGlyph) (SDF2, error) {
+	segs, err := g.face.f.LoadGlyph(b, g.idx, fixed.I(fontPPEM), nil)
+	if err != nil {
+		return nil, err
+	}
+	k := f.scale()
+	// sfnt has y increasing down
+	toV2 := func(p fixed.Point26_6) v2.Vec {
+		return v2.Vec{float64(p.X) * k, -float64(p.Y) * k}
+	}
+	var o glyphOutline
+	for _, s := range segs {
+		switch s.Op {
+		case sfnt.SegmentOpMoveTo:
+			o.moveTo(toV2(s.Args[0]))
+		case sfnt.SegmentOpLineTo:
+			o.add(toV2(s.Args[0]))
+		case sfnt.SegmentOpQuadTo:
+			o.add(toV2(s.Args[0]), toV2(s.Args[1]))
+		case sfnt.SegmentOpCubeTo:
+			o.add(toV2(s.Args[0]), toV2(s.Args[1]), toV2(s.Args[2]))
+		}
+	}
+	return o.sdf(), nil
+}
+
+// lineHeight returns the font line height (font units).
+// This is the typographic ascent - descent from the OS/2 table, or the em size if there is none.
+func lineHeight(f *Font) float64 {
+	src := f.faces[0].src
+	if len(src) < 12 {
+		return f.unitsPerEm()
+	}
+	n := int(binary.BigEndian.Uint16(src[4:]))
+	for i := 0; i < n; i++ {
+		r := 12 + 16*i
+		if r+16 > len(src) {
+			break
+		}
+		if string(src[r:r+4]) != "OS/2" {
+			continue
+		}
+		ofs := int(binary.BigEndian.Uint32(src[r+8:]))
+		size := int(binary.BigEndian.Uint32(src[r+12:]))
+		if size < 72 || ofs+72 > len(src) {
+			break
+		}
+		ascender := int16(binary.BigEndian.Uint16(src[ofs+68:]))
+		descender := int16(binary.BigEndian.Uint16(src[ofs+70:]))
+		return float64(ascender) - float64(descender)
+	}
+	return f.unitsPerEm()
+}
+
+// fontMetrics returns the font ascent and descent (font units, descent is positive).
+func fontMetrics(f *Font) (float64, float64, error) {
+	m, err := f.faces[0].f.Metrics(nil, fixed.I(fontPPEM), font.HintingNone)
+	if err != nil {
+		return 0, 0, err
+	}
+	k := f.scale()
+	return float64(m.Ascent) * k, float64(m.Descent) * k, nil
+}
+
+//-----------------------------------------------------------------------------
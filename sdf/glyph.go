@@ -0,0 +1,416 @@
+//-----------------------------------------------------------------------------
+/*
+
+Glyph Outlines
+
+A glyph outline is a set of closed contours made from line, quadratic and
+cubic Bezier segments. The distance to the outline is the exact distance to
+the segments, the sign comes from the non-zero winding rule.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"math"
+	"sort"
+
+	v2 "github.com/gmlewis/sdfx/vec/v2"
+)
+
+//-----------------------------------------------------------------------------
+
+// glyphCubic returns the real solutions of ax^3 + bx^2 + cx + d = 0
+func glyphCubic(a, b, c, d float64) []float64 {
+	if math.Abs(a) <= 1e-12*(math.Abs(b)+math.Abs(c)+math.Abs(d)) {
+		x, _ := quadratic(b, c, d)
+		return x
+	}
+	b /= a
+	c /= a
+	d /= a
+	q := (b*b - 3*c) / 9
+	r := (2*b*b*b - 9*b*c + 27*d) / 54
+	var x []float64
+	if r*r < q*q*q {
+		theta := math.Acos(Clamp(r/math.Sqrt(q*q*q), -1, 1))
+		k := -2 * math.Sqrt(q)
+		x = []float64{
+			k*math.Cos(theta/3) - b/3,
+			k*math.Cos((theta+Tau)/3) - b/3,
+			k*math.Cos((theta-Tau)/3) - b/3,
+		}
+	} else {
+		u := -math.Copysign(math.Cbrt(math.Abs(r)+math.Sqrt(r*r-q*q*q)), r)
+		v := 0.0
+		if u != 0 {
+			v = q / u
+		}
+		x = []float64{u + v - b/3}
+	}
+	// polish the roots
+	for i := range x {
+		for j := 0; j < 2; j++ {
+			f := ((x[i]+b)*x[i]+c)*x[i] + d
+			df := (3*x[i]+2*b)*x[i] + c
+			if df == 0 {
+				break
+			}
+			x[i] -= f / df
+		}
+	}
+	return x
+}
+
+//-----------------------------------------------------------------------------
+
+// glyphSegment is a line, quadratic or cubic Bezier segment of a glyph outline.
+// Segments are split so they are monotonic in x and y.
+type glyphSegment struct {
+	p  [4]v2.Vec // control points
+	n  int       // degree (1, 2 or 3)
+	bb Box2      // bounding box
+}
+
+// newGlyphSegment returns a segment with its bounding box.
+func newGlyphSegment(p []v2.Vec) glyphSegment {
+	s := glyphSegment{n: len(p) - 1}
+	copy(s.p[:], p)
+	// the segment is monotonic so the end points bound it
+	s.bb = Box2{p[0].Min(p[s.n]), p[0].Max(p[s.n])}
+	return s
+}
+
+// f0 returns the position at t.
+func (s *glyphSegment) f0(t float64) v2.Vec {
+	// de Casteljau
+	var q [4]v2.Vec
+	copy(q[:], s.p[:s.n+1])
+	for k := s.n; k > 0; k-- {
+		for i := 0; i < k; i++ {
+			q[i] = q[i].Add(q[i+1].Sub(q[i]).MulScalar(t))
+		}
+	}
+	return q[0]
+}
+
+// f1 returns the first derivative at t.
+func (s *glyphSegment) f1(t float64) v2.Vec {
+	var q [3]v2.Vec
+	for i := 0; i < s.n; i++ {
+		q[i] = s.p[i+1].Sub(s.p[i]).MulScalar(float64(s.n))
+	}
+	for k := s.n - 1; k > 0; k-- {
+		for i := 0; i < k; i++ {
+			q[i] = q[i].Add(q[i+1].Sub(q[i]).MulScalar(t))
+		}
+	}
+	return q[0]
+}
+
+// glyphSplit returns the control points of the segment split at t.
+func glyphSplit(p []v2.Vec, t float64) ([]v2.Vec, []v2.Vec) {
+	n := len(p) - 1
+	p0 := make([]v2.Vec, n+1)
+	p1 := make([]v2.Vec, n+1)
+	q := append([]v2.Vec(nil), p...)
+	for k := 0; k <= n; k++ {
+		p0[k] = q[0]
+		p1[n-k] = q[n-k]
+		for i := 0; i < n-k; i++ {
+			q[i] = q[i].Add(q[i+1].Sub(q[i]).MulScalar(t))
+		}
+	}
+	return p0, p1
+}
+
+// glyphExtrema returns the parameters of the turning points of one component of a curve.
+func glyphExtrema(p []float64) []float64 {
+	switch len(p) {
+	case 3:
+		// derivative is linear
+		d := p[0] - 2*p[1] + p[2]
+		if d == 0 {
+			return nil
+		}
+		return []float64{(p[0] - p[1]) / d}
+	case 4:
+		// derivative is quadratic
+		a := -p[0] + 3*p[1] - 3*p[2] + p[3]
+		b := 2 * (p[0] - 2*p[1] + p[2])
+		c := p[1] - p[0]
+		x, _ := quadratic(a, b, c)
+		return x
+	}
+	return nil
+}
+
+// glyphMonotonic splits a segment into pieces that are monotonic in x and y.
+func glyphMonotonic(p []v2.Vec) []glyphSegment {
+	var x, y []float64
+	for i := range p {
+		x = append(x, p[i].X)
+		y = append(y, p[i].Y)
+	}
+	var ts []float64
+	for _, t := range append(glyphExtrema(x), glyphExtrema(y)...) {
+		if t > 1e-9 && t < 1-1e-9 {
+			ts = append(ts, t)
+		}
+	}
+	sort.Float64s(ts)
+	var segs []glyphSegment
+	t0 := 0.0
+	for _, t := range ts {
+		if t-t0 < 1e-9 {
+			continue
+		}
+		// split point relative to the remaining curve
+		var p0 []v2.Vec
+		p0, p = glyphSplit(p, (t-t0)/(1-t0))
+		segs = append(segs, newGlyphSegment(p0))
+		t0 = t
+	}
+	return append(segs, newGlyphSegment(p))
+}
+
+// quintic returns the Bernstein coefficients of (B(t) - p).B'(t) for a cubic segment.
+func (s *glyphSegment) quintic(p v2.Vec) []float64 {
+	// B(t) - p has cubic coefficients c, B'(t) has quadratic coefficients d.
+	var c [4]v2.Vec
+	var d [3]v2.Vec
+	for i := range c {
+		c[i] = s.p[i].Sub(p)
+	}
+	for i := range d {
+		d[i] = s.p[i+1].Sub(s.p[i]).MulScalar(3)
+	}
+	// the product of Bernstein polynomials
+	b3 := [4]float64{1, 3, 3, 1}
+	b2 := [3]float64{1, 2, 1}
+	b5 := [6]float64{1, 5, 10, 10, 5, 1}
+	e := make([]float64, 6)
+	for i := range c {
+		for j := range d {
+			e[i+j] += b3[i] * b2[j] * c[i].Dot(d[j])
+		}
+	}
+	for k := range e {
+		e[k] /= b5[k]
+	}
+	return e
+}
+
+// glyphRoots appends the roots of a polynomial in Bernstein form on [t0, t1].
+// The number of sign changes in the coefficients bounds the number of roots,
+// so intervals are subdivided until each has at most one root.
+func glyphRoots(b []float64, t0, t1 float64, roots []float64) []float64 {
+	n := len(b) - 1
+	changes := 0
+	for i := 1; i <= n; i++ {
+		if (b[i-1] < 0) != (b[i] < 0) {
+			changes++
+		}
+	}
+	if changes == 0 {
+		return roots
+	}
+	if changes == 1 || t1-t0 < 1e-9 {
+		if changes == 1 {
+			// a single root, bisect
+			a, c := 0.0, 1.0
+			for j := 0; j < 40 && (c-a)*(t1-t0) > 1e-12; j++ {
+				t := 0.5 * (a + c)
+				if (glyphBernstein(b, t) < 0) == (b[0] < 0) {
+					a = t
+				} else {
+					c = t
+				}
+			}
+			return append(roots, t0+0.5*(a+c)*(t1-t0))
+		}
+		return append(roots, 0.5*(t0+t1))
+	}
+	// split the polynomial at the middle of the interval
+	b0 := make([]float64, n+1)
+	b1 := make([]float64, n+1)
+	q := append([]float64(nil), b...)
+	for k := 0; k <= n; k++ {
+		b0[k] = q[0]
+		b1[n-k] = q[n-k]
+		for i := 0; i < n-k; i++ {
+			q[i] = 0.5 * (q[i] + q[i+1])
+		}
+	}
+	tm := 0.5 * (t0 + t1)
+	roots = glyphRoots(b0, t0, tm, roots)
+	return glyphRoots(b1, tm, t1, roots)
+}
+
+// glyphBernstein evaluates a polynomial in Bernstein form at t.
+func glyphBernstein(b []float64, t float64) float64 {
+	var q [6]float64
+	copy(q[:], b)
+	for k := len(b) - 1; k > 0; k-- {
+		for i := 0; i < k; i++ {
+			q[i] += (q[i+1] - q[i]) * t
+		}
+	}
+	return q[0]
+}
+
+// distance2 returns the squared distance from p to the segment.
+func (s *glyphSegment) distance2(p v2.Vec) float64 {
+	d2 := math.Min(p.Sub(s.p[0]).Length2(), p.Sub(s.p[s.n]).Length2())
+	check := func(t float64) {
+		if t > 0 && t < 1 {
+			d2 = math.Min(d2, p.Sub(s.f0(t)).Length2())
+		}
+	}
+	switch s.n {
+	case 1:
+		v := s.p[1].Sub(s.p[0])
+		if l2 := v.Length2(); l2 > 0 {
+			check(p.Sub(s.p[0]).Dot(v) / l2)
+		}
+	case 2:
+		// B(t) - p = a.t^2 + 2b.t + m, the closest point is at a root of (B(t) - p).B'(t)
+		a := s.p[0].Sub(s.p[1].MulScalar(2)).Add(s.p[2])
+		b := s.p[1].Sub(s.p[0])
+		m := s.p[0].Sub(p)
+		for _, t := range glyphCubic(a.Dot(a), 3*a.Dot(b), 2*b.Dot(b)+m.Dot(a), m.Dot(b)) {
+			check(t)
+		}
+	case 3:
+		// (B(t) - p).B'(t) is a quintic, check all of its roots
+		for _, t := range glyphRoots(s.quintic(p), 0, 1, nil) {
+			check(t)
+		}
+	}
+	return d2
+}
+
+// winding returns the contribution of the segment to the winding number of p.
+// A ray is cast from p along +x, upward crossings are +1 and downward crossings are -1.
+func (s *glyphSegment) winding(p v2.Vec) int {
+	y0 := s.p[0].Y
+	y1 := s.p[s.n].Y
+	if y0 == y1 {
+		return 0
+	}
+	dir := 1
+	if y1 < y0 {
+		dir = -1
+	}
+	// half open so crossings at shared end points are counted once
+	if p.Y < s.bb.Min.Y || p.Y >= s.bb.Max.Y {
+		return 0
+	}
+	if p.X >= s.bb.Max.X {
+		return 0
+	}
+	if p.X < s.bb.Min.X {
+		return dir
+	}
+	// the segment is monotonic, find the crossing
+	a, b := 0.0, 1.0
+	for j := 0; j < 50; j++ {
+		t := 0.5 * (a + b)
+		if (s.f0(t).Y < p.Y) == (dir > 0) {
+			a = t
+		} else {
+			b = t
+		}
+	}
+	if s.f0(0.5*(a+b)).X > p.X {
+		return dir
+	}
+	return 0
+}
+
+//-----------------------------------------------------------------------------
+
+// glyphSDF2 is the SDF2 for a glyph outline.
+type glyphSDF2 struct {
+	segs []glyphSegment
+	bb   Box2
+}
+
+// glyphOutline builds a glyph outline from a set of segments.
+type glyphOutline struct {
+	segs  []glyphSegment
+	start v2.Vec // start of the current contour
+	last  v2.Vec // end of the previous segment
+	open  bool   // a contour is in progress
+}
+
+// moveTo starts a new contour.
+func (g *glyphOutline) moveTo(p v2.Vec) {
+	g.close()
+	g.start = p
+	g.last = p
+	g.open = true
+}
+
+// add adds a segment from the last point through the given control points.
+func (g *glyphOutline) add(p ...v2.Vec) {
+	pts := append([]v2.Vec{g.last}, p...)
+	g.last = p[len(p)-1]
+	// skip degenerate segments
+	for i := 1; i < len(pts); i++ {
+		if !pts[i].Equals(pts[0], 0) {
+			g.segs = append(g.segs, glyphMonotonic(pts)...)
+			return
+		}
+	}
+}
+
+// close closes the current contour.
+func (g *glyphOutline) close() {
+	if g.open && !g.last.Equals(g.start, 0) {
+		g.add(g.start)
+	}
+	g.open = false
+}
+
+// sdf returns the SDF2 for the outline, nil if the outline is empty.
+func (g *glyphOutline) sdf() SDF2 {
+	g.close()
+	if len(g.segs) == 0 {
+		return nil
+	}
+	s := glyphSDF2{segs: g.segs, bb: g.segs[0].bb}
+	for i := range g.segs {
+		s.bb = s.bb.Extend(g.segs[i].bb)
+	}
+	return &s
+}
+
+// Evaluate returns the minimum distance to the glyph outline.
+func (s *glyphSDF2) Evaluate(p v2.Vec) float64 {
+	d2 := math.MaxFloat64
+	w := 0
+	for i := range s.segs {
+		seg := &s.segs[i]
+		w += seg.winding(p)
+		// the segment lies within its bounding box
+		dx := math.Max(math.Max(seg.bb.Min.X-p.X, p.X-seg.bb.Max.X), 0)
+		dy := math.Max(math.Max(seg.bb.Min.Y-p.Y, p.Y-seg.bb.Max.Y), 0)
+		if dx*dx+dy*dy < d2 {
+			d2 = math.Min(d2, seg.distance2(p))
+		}
+	}
+	d := math.Sqrt(d2)
+	if w != 0 {
+		return -d
+	}
+	return d
+}
+
+// BoundingBox returns the bounding box for the glyph outline.
+func (s *glyphSDF2) BoundingBox() Box2 {
+	return s.bb
+}
+
+//-----------------------------------------------------------------------------
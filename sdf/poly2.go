@@ -9,9 +9,11 @@ SDF for 2D polygons.
 package sdf
 
 import (
+	"fmt"
 	"math"
 
 	v2 "github.com/gmlewis/sdfx/vec/v2"
+	"github.com/gmlewis/sdfx/vec/v2i"
 )
 
 //-----------------------------------------------------------------------------
@@ -119,3 +121,313 @@ func (s *PolySDF2) BoundingBox() Box2 {
 }
 
 //-----------------------------------------------------------------------------
+// Polygons with Multiple Contours
+
+// FillRule determines which regions enclosed by a set of contours are inside the shape.
+type FillRule int
+
+// Fill rules.
+const (
+	FillNonZero FillRule = iota // inside when the winding number is non-zero
+	FillEvenOdd                 // inside when the winding number is odd
+)
+
+// inside returns true if a winding number is inside the shape.
+func (r FillRule) inside(wn int) bool {
+	if r == FillEvenOdd {
+		return wn%2 != 0
+	}
+	return wn != 0
+}
+
+// polySegment is a line segment of a polygon contour.
+type polySegment struct {
+	a, b v2.Vec  // end points
+	v    v2.Vec  // unit line vector
+	l    float64 // line length
+}
+
+// distance2 returns the squared distance from p to the line segment.
+func (s *polySegment) distance2(p v2.Vec) float64 {
+	pa := p.Sub(s.a)
+	t := pa.Dot(s.v)
+	if t < 0 {
+		return pa.Length2()
+	}
+	if t > s.l {
+		return p.Sub(s.b).Length2()
+	}
+	dn := s.v.Cross(pa)
+	return dn * dn
+}
+
+// crossing returns the change in winding number when moving from p0 to p1.
+// This is +1 when the line segment is crossed from right to left, -1 when it is
+// crossed from left to right, and 0 if it is not crossed.
+func (s *polySegment) crossing(p0, p1 v2.Vec) int {
+	// the end points of the line segment must be on opposite sides of the path
+	d := p1.Sub(p0)
+	if (d.Cross(s.a.Sub(p0)) < 0) == (d.Cross(s.b.Sub(p0)) < 0) {
+		return 0
+	}
+	// the ends of the path must be on opposite sides of the line segment
+	left0 := s.v.Cross(p0.Sub(s.a)) >= 0
+	left1 := s.v.Cross(p1.Sub(s.a)) >= 0
+	if left0 == left1 {
+		return 0
+	}
+	if left1 {
+		return 1
+	}
+	return -1
+}
+
+// MultiPolySDF2 is an SDF2 made from a set of closed contours and a fill rule.
+// The line segments are binned in a uniform grid so that evaluation only needs
+// to consider the line segments near the point.
+type MultiPolySDF2 struct {
+	seg  []polySegment // line segments
+	rule FillRule      // fill rule
+	bb   Box2          // bounding box
+	n    v2i.Vec       // grid dimensions (cells)
+	size v2.Vec        // grid cell size
+	cell [][]int32     // line segment indices for each grid cell
+	ref  []v2.Vec      // reference point for each grid cell
+	wn   []int         // winding number of the reference point for each grid cell
+}
+
+// MultiPolygon2D returns an SDF2 made from a set of closed contours and a fill rule.
+// Inner contours are holes or islands according to the fill rule.
+func MultiPolygon2D(contours [][]v2.Vec, rule FillRule) (SDF2, error) {
+	if len(contours) == 0 {
+		return nil, ErrMsg("no contours")
+	}
+	s := MultiPolySDF2{rule: rule}
+
+	for i, vertex := range contours {
+		if len(vertex) < 3 {
+			return nil, fmt.Errorf("contour %d: number of vertices < 3", i)
+		}
+	}
+
+	// build the line segments
+	s.bb = Box2{contours[0][0], contours[0][0]}
+	for _, vertex := range contours {
+		n := len(vertex)
+		// close the loop (if necessary)
+		if !vertex[0].Equals(vertex[n-1], tolerance) {
+			vertex = append(vertex[:n:n], vertex[0])
+		}
+		for j := 0; j < len(vertex)-1; j++ {
+			a, b := vertex[j], vertex[j+1]
+			s.bb = s.bb.Include(a)
+			l := b.Sub(a)
+			if l.Length() == 0 {
+				continue
+			}
+			s.seg = append(s.seg, polySegment{a, b, l.Normalize(), l.Length()})
+		}
+	}
+	if len(s.seg) == 0 {
+		return nil, ErrMsg("contours have zero length")
+	}
+
+	// size the grid for about one line segment per cell
+	bbSize := s.bb.Size()
+	k := math.Sqrt(bbSize.X * bbSize.Y / float64(len(s.seg)))
+	if k == 0 {
+		// the contours are a line
+		k = math.Max(bbSize.X, bbSize.Y) / float64(len(s.seg))
+	}
+	cells := func(x float64) int {
+		return int(Clamp(math.Ceil(x/k), 1, float64(len(s.seg))))
+	}
+	s.n = v2i.Vec{cells(bbSize.X), cells(bbSize.Y)}
+	s.size = v2.Vec{bbSize.X / float64(s.n.X), bbSize.Y / float64(s.n.Y)}
+	s.cell = make([][]int32, s.n.X*s.n.Y)
+
+	// bin the line segments
+	eps := 1e-9 * s.size.Length()
+	for i := range s.seg {
+		seg := &s.seg[i]
+		i0, j0 := s.index(seg.a.Min(seg.b))
+		i1, j1 := s.index(seg.a.Max(seg.b))
+		for j := j0; j <= j1; j++ {
+			for k := i0; k <= i1; k++ {
+				if i0 != i1 && j0 != j1 {
+					// the line segment must pass through the cell
+					box := s.cellBox(k, j)
+					dmin := math.MaxFloat64
+					dmax := -math.MaxFloat64
+					for _, v := range box.Vertices() {
+						d := seg.v.Cross(v.Sub(seg.a))
+						dmin = math.Min(dmin, d)
+						dmax = math.Max(dmax, d)
+					}
+					if dmin > eps || dmax < -eps {
+						continue
+					}
+				}
+				c := j*s.n.X + k
+				s.cell[c] = append(s.cell[c], int32(i))
+			}
+		}
+	}
+
+	// find the winding number of a reference point in each cell
+	s.ref = make([]v2.Vec, len(s.cell))
+	s.wn = make([]int, len(s.cell))
+	mark := make([]int, len(s.seg))
+	for j := 0; j < s.n.Y; j++ {
+		// a ray along the row only crosses the line segments of the row
+		var row []int32
+		for i := 0; i < s.n.X; i++ {
+			for _, k := range s.cell[j*s.n.X+i] {
+				if mark[k] != j+1 {
+					mark[k] = j + 1
+					row = append(row, k)
+				}
+			}
+		}
+		for i := 0; i < s.n.X; i++ {
+			c := j*s.n.X + i
+			p := s.reference(i, j)
+			s.ref[c] = p
+			// See: http://geomalgorithms.com/a03-_inclusion.html
+			for _, k := range row {
+				seg := &s.seg[k]
+				dn := seg.v.Cross(p.Sub(seg.a)) // > 0 when p is to the left of the line segment
+				if seg.a.Y <= p.Y {
+					if seg.b.Y > p.Y && dn > 0 { // upward crossing
+						s.wn[c]++
+					}
+				} else {
+					if seg.b.Y <= p.Y && dn < 0 { // downward crossing
+						s.wn[c]--
+					}
+				}
+			}
+		}
+	}
+
+	return &s, nil
+}
+
+// index returns the grid cell containing a point (clamped to the grid).
+func (s *MultiPolySDF2) index(p v2.Vec) (int, int) {
+	f := func(x, min, size float64, n int) int {
+		if size == 0 {
+			return 0
+		}
+		return int(Clamp(math.Floor((x-min)/size), 0, float64(n-1)))
+	}
+	return f(p.X, s.bb.Min.X, s.size.X, s.n.X), f(p.Y, s.bb.Min.Y, s.size.Y, s.n.Y)
+}
+
+// cellBox returns the bounding box of a grid cell.
+func (s *MultiPolySDF2) cellBox(i, j int) Box2 {
+	p := s.bb.Min.Add(v2.Vec{float64(i) * s.size.X, float64(j) * s.size.Y})
+	return Box2{p, p.Add(s.size)}
+}
+
+// reference returns a point within a grid cell that is not too close to the cell line segments.
+func (s *MultiPolySDF2) reference(i, j int) v2.Vec {
+	box := s.cellBox(i, j)
+	clearance := func(p v2.Vec) float64 {
+		d2 := math.MaxFloat64
+		for _, k := range s.cell[j*s.n.X+i] {
+			d2 = math.Min(d2, s.seg[k].distance2(p))
+		}
+		return d2
+	}
+	// try the center first, then some other points
+	min2 := 1e-12 * s.size.Length2()
+	var best v2.Vec
+	bestD2 := -1.0
+	for _, k := range []v2.Vec{{0.5, 0.5}, {0.31, 0.62}, {0.73, 0.37}, {0.43, 0.19}, {0.17, 0.83}, {0.87, 0.71}} {
+		p := box.Min.Add(s.size.Mul(k))
+		d2 := clearance(p)
+		if d2 > min2 {
+			return p
+		}
+		if d2 > bestD2 {
+			best, bestD2 = p, d2
+		}
+	}
+	return best
+}
+
+// Evaluate returns the minimum distance for a set of contours.
+func (s *MultiPolySDF2) Evaluate(p v2.Vec) float64 {
+	i0, j0 := s.index(p)
+
+	// search rings of cells around the cell containing p
+	dd := math.MaxFloat64
+	for k := 0; ; k++ {
+		// lower bound on the distance to the cells in this ring
+		lb := math.MaxFloat64
+		if i0-k >= 0 {
+			lb = math.Min(lb, p.X-(s.bb.Min.X+float64(i0-k+1)*s.size.X))
+		}
+		if i0+k < s.n.X {
+			lb = math.Min(lb, s.bb.Min.X+float64(i0+k)*s.size.X-p.X)
+		}
+		if j0-k >= 0 {
+			lb = math.Min(lb, p.Y-(s.bb.Min.Y+float64(j0-k+1)*s.size.Y))
+		}
+		if j0+k < s.n.Y {
+			lb = math.Min(lb, s.bb.Min.Y+float64(j0+k)*s.size.Y-p.Y)
+		}
+		if lb == math.MaxFloat64 {
+			// the ring is outside the grid
+			break
+		}
+		if k > 0 && lb > 0 && lb*lb >= dd {
+			break
+		}
+		for j := max(j0-k, 0); j <= min(j0+k, s.n.Y-1); j++ {
+			step := 1
+			if j != j0-k && j != j0+k {
+				// only the ends of the row are in the ring
+				step = max(2*k, 1)
+			}
+			for i := i0 - k; i <= i0+k; i += step {
+				if i < 0 || i >= s.n.X {
+					continue
+				}
+				box := s.cellBox(i, j)
+				dx := math.Max(math.Max(box.Min.X-p.X, p.X-box.Max.X), 0)
+				dy := math.Max(math.Max(box.Min.Y-p.Y, p.Y-box.Max.Y), 0)
+				if dx*dx+dy*dy >= dd {
+					continue
+				}
+				for _, n := range s.cell[j*s.n.X+i] {
+					dd = math.Min(dd, s.seg[n].distance2(p))
+				}
+			}
+		}
+	}
+	d := math.Sqrt(dd)
+
+	// the winding number is 0 outside the bounding box
+	if !s.bb.Contains(p) {
+		return d
+	}
+	// winding number of the cell reference point + changes on the path to p
+	c := j0*s.n.X + i0
+	wn := s.wn[c]
+	for _, n := range s.cell[c] {
+		wn += s.seg[n].crossing(s.ref[c], p)
+	}
+	if s.rule.inside(wn) {
+		return -d
+	}
+	return d
+}
+
+// BoundingBox returns the bounding box for a set of contours.
+func (s *MultiPolySDF2) BoundingBox() Box2 {
+	return s.bb
+}
+
+//-----------------------------------------------------------------------------
@@ -11,9 +11,10 @@ the x-axis as the screw axis. Most thread profiles are symmetric about the y-axi
 but a few aren't (E.g. buttress threads) so in general we build the profile of
 an entire pitch period.
 
-This code doesn't deal with thread tolerancing. If you want threads to fit properly
-the radius of the thread will need to be tweaked (+/-) to give internal/external thread
-clearance.
+Thread tolerancing is done by moving the thread profile radially so the pitch
+diameter is in the middle of the tolerance zone for an ISO 965 (E.g. 6g/6H) or
+ASME B1.1 (E.g. 2A/2B) tolerance class. External and internal threads made with
+their default classes will mate.
 
 */
 //-----------------------------------------------------------------------------
@@ -24,6 +25,7 @@ import (
 	"fmt"
 	"log"
 	"math"
+	"sort"
 
 	v2 "github.com/gmlewis/sdfx/vec/v2"
 	v3 "github.com/gmlewis/sdfx/vec/v3"
@@ -40,6 +42,7 @@ type ThreadParameters struct {
 	Taper        float64 // thread taper (radians)
 	HexFlat2Flat float64 // hex head flat to flat distance
 	Units        string  // "inch" or "mm"
+	Form         string  // thread form "iso" (default), "whitworth" or "trapezoidal"
 }
 
 type threadDatabase map[string]*ThreadParameters
@@ -62,6 +65,7 @@ func (m threadDatabase) UTSAdd(
 	t.Pitch = 1.0 / tpi
 	t.HexFlat2Flat = ftof
 	t.Units = "inch"
+	t.Form = "iso"
 	m[name] = &t
 }
 
@@ -81,6 +85,7 @@ func (m threadDatabase) ISOAdd(
 	t.Pitch = pitch
 	t.HexFlat2Flat = ftof
 	t.Units = "mm"
+	t.Form = "iso"
 	m[name] = &t
 }
 
@@ -101,6 +106,75 @@ func (m threadDatabase) NPTAdd(
 	t.Taper = math.Atan(1.0 / 32.0)
 	t.HexFlat2Flat = ftof
 	t.Units = "inch"
+	t.Form = "iso"
+	m[name] = &t
+}
+
+// BSWAdd adds a British Standard Whitworth thread to the thread database.
+func (m threadDatabase) BSWAdd(
+	name string, // thread name
+	diameter float64, // screw major diameter
+	tpi float64, // threads per inch
+	ftof float64, // hex head flat to flat distance
+) {
+	if ftof <= 0 {
+		log.Panicf("bad flat to flat distance for thread \"%s\"", name)
+	}
+	t := ThreadParameters{}
+	t.Name = name
+	t.Radius = diameter / 2.0
+	t.Pitch = 1.0 / tpi
+	t.HexFlat2Flat = ftof
+	t.Units = "inch"
+	t.Form = "whitworth"
+	m[name] = &t
+}
+
+// BSPAdd adds British Standard Pipe threads to the thread database.
+// Each size is added as a parallel (bspp) and a tapered (bspt) thread.
+func (m threadDatabase) BSPAdd(
+	size string, // nominal pipe size
+	diameter float64, // major diameter (mm)
+	tpi float64, // threads per inch
+	ftof float64, // hex head flat to flat distance (mm)
+) {
+	if ftof <= 0 {
+		log.Panicf("bad flat to flat distance for thread \"%s\"", size)
+	}
+	for _, taper := range []bool{false, true} {
+		t := ThreadParameters{}
+		t.Name = "bspp_" + size
+		t.Radius = diameter / 2.0
+		t.Pitch = MillimetresPerInch / tpi
+		if taper {
+			// 1 in 16 on the diameter
+			t.Name = "bspt_" + size
+			t.Taper = math.Atan(1.0 / 32.0)
+		}
+		t.HexFlat2Flat = ftof
+		t.Units = "mm"
+		t.Form = "whitworth"
+		m[t.Name] = &t
+	}
+}
+
+// TrAdd adds an ISO trapezoidal thread to the thread database.
+func (m threadDatabase) TrAdd(
+	name string, // thread name
+	diameter float64, // screw major diamater
+	pitch float64, // thread pitch
+	ftof float64, // hex head flat to flat distance
+) {
+	if ftof <= 0 {
+		log.Panicf("bad flat to flat distance for thread \"%s\"", name)
+	}
+	t := ThreadParameters{}
+	t.Name = name
+	t.Radius = diameter / 2.0
+	t.Pitch = pitch
+	t.HexFlat2Flat = ftof
+	t.Units = "mm"
+	t.Form = "trapezoidal"
 	m[name] = &t
 }
 
@@ -166,6 +240,16 @@ func initThreadLookup() threadDatabase {
 	m.ISOAdd("M48x5", 48, 5, 75)
 	m.ISOAdd("M56x5.5", 56, 5.5, 85)
 	m.ISOAdd("M64x6", 64, 6, 95)
+	// ISO Coarse (second choice sizes)
+	m.ISOAdd("M14x2", 14, 2, 22)
+	m.ISOAdd("M18x2.5", 18, 2.5, 27)
+	m.ISOAdd("M22x2.5", 22, 2.5, 32)
+	m.ISOAdd("M27x3", 27, 3, 41)
+	m.ISOAdd("M33x3.5", 33, 3.5, 50)
+	m.ISOAdd("M39x4", 39, 4, 60)
+	m.ISOAdd("M45x4.5", 45, 4.5, 70)
+	m.ISOAdd("M52x5", 52, 5, 80)
+	m.ISOAdd("M60x5.5", 60, 5.5, 90)
 	// ISO Fine
 	m.ISOAdd("M1x0.2", 1, 0.2, 1.75)    // ftof?
 	m.ISOAdd("M1.2x0.2", 1.2, 0.2, 2.0) // ftof?
@@ -188,6 +272,66 @@ func initThreadLookup() threadDatabase {
 	m.ISOAdd("M48x3", 48, 3, 75)
 	m.ISOAdd("M56x4", 56, 4, 85)
 	m.ISOAdd("M64x4", 64, 4, 95)
+	// ISO Fine (other ISO 261 pitches)
+	m.ISOAdd("M8x0.75", 8, 0.75, 13)
+	m.ISOAdd("M10x1", 10, 1, 17)
+	m.ISOAdd("M10x0.75", 10, 0.75, 17)
+	m.ISOAdd("M12x1.25", 12, 1.25, 19)
+	m.ISOAdd("M12x1", 12, 1, 19)
+	m.ISOAdd("M14x1.5", 14, 1.5, 22)
+	m.ISOAdd("M14x1.25", 14, 1.25, 22)
+	m.ISOAdd("M16x1", 16, 1, 24)
+	m.ISOAdd("M18x2", 18, 2, 27)
+	m.ISOAdd("M18x1.5", 18, 1.5, 27)
+	m.ISOAdd("M20x1.5", 20, 1.5, 30)
+	m.ISOAdd("M22x2", 22, 2, 32)
+	m.ISOAdd("M22x1.5", 22, 1.5, 32)
+	m.ISOAdd("M24x1.5", 24, 1.5, 36)
+	m.ISOAdd("M27x2", 27, 2, 41)
+	m.ISOAdd("M30x1.5", 30, 1.5, 46)
+	m.ISOAdd("M33x2", 33, 2, 50)
+	m.ISOAdd("M36x2", 36, 2, 55)
+	m.ISOAdd("M42x2", 42, 2, 65)
+	m.ISOAdd("M48x2", 48, 2, 75)
+
+	// British Standard Whitworth. Flat to flat distance from BS 1083.
+	m.BSWAdd("bsw_1/4", 1.0/4.0, 20, 0.445)
+	m.BSWAdd("bsw_5/16", 5.0/16.0, 18, 0.525)
+	m.BSWAdd("bsw_3/8", 3.0/8.0, 16, 0.600)
+	m.BSWAdd("bsw_7/16", 7.0/16.0, 14, 0.710)
+	m.BSWAdd("bsw_1/2", 1.0/2.0, 12, 0.820)
+	m.BSWAdd("bsw_5/8", 5.0/8.0, 11, 1.010)
+	m.BSWAdd("bsw_3/4", 3.0/4.0, 10, 1.200)
+	m.BSWAdd("bsw_7/8", 7.0/8.0, 9, 1.300)
+	m.BSWAdd("bsw_1", 1.0, 8, 1.480)
+
+	// British Standard Pipe (ISO 228 parallel, ISO 7 tapered). Flat to flat distance from DIN 910 plugs.
+	m.BSPAdd("1/8", 9.728, 28, 14)
+	m.BSPAdd("1/4", 13.157, 19, 17)
+	m.BSPAdd("3/8", 16.662, 19, 19)
+	m.BSPAdd("1/2", 20.955, 14, 22)
+	m.BSPAdd("3/4", 26.441, 14, 27)
+	m.BSPAdd("1", 33.249, 11, 36)
+	m.BSPAdd("1_1/4", 41.910, 11, 46)
+	m.BSPAdd("1_1/2", 47.803, 11, 50)
+	m.BSPAdd("2", 59.614, 11, 65)
+
+	// ISO Trapezoidal (ISO 2904). Flat to flat distance of the metric thread of the same diameter.
+	m.TrAdd("Tr8x1.5", 8, 1.5, 13)
+	m.TrAdd("Tr10x2", 10, 2, 17)
+	m.TrAdd("Tr12x3", 12, 3, 19)
+	m.TrAdd("Tr14x3", 14, 3, 22)
+	m.TrAdd("Tr16x4", 16, 4, 24)
+	m.TrAdd("Tr18x4", 18, 4, 27)
+	m.TrAdd("Tr20x4", 20, 4, 30)
+	m.TrAdd("Tr22x5", 22, 5, 32)
+	m.TrAdd("Tr24x5", 24, 5, 36)
+	m.TrAdd("Tr26x5", 26, 5, 41)
+	m.TrAdd("Tr28x5", 28, 5, 41)
+	m.TrAdd("Tr30x6", 30, 6, 46)
+	m.TrAdd("Tr32x6", 32, 6, 50)
+	m.TrAdd("Tr36x6", 36, 6, 55)
+	m.TrAdd("Tr40x7", 40, 7, 60)
 	return m
 }
 
@@ -199,6 +343,50 @@ func ThreadLookup(name string) (*ThreadParameters, error) {
 	return nil, fmt.Errorf("thread \"%s\" not found", name)
 }
 
+// ThreadRegister adds a custom thread to the thread database.
+// It is not safe to call this concurrently with other thread database functions.
+func ThreadRegister(t *ThreadParameters) error {
+	if t.Name == "" {
+		return ErrMsg("thread name is empty")
+	}
+	if _, ok := threadDB[t.Name]; ok {
+		return fmt.Errorf("thread \"%s\" already exists", t.Name)
+	}
+	if t.Radius <= 0 {
+		return ErrMsg("Radius <= 0")
+	}
+	if t.Pitch <= 0 {
+		return ErrMsg("Pitch <= 0")
+	}
+	if t.Taper < 0 || t.Taper >= Pi*0.5 {
+		return ErrMsg("bad Taper")
+	}
+	if t.HexFlat2Flat <= 0 {
+		return ErrMsg("HexFlat2Flat <= 0")
+	}
+	if t.Units != "inch" && t.Units != "mm" {
+		return fmt.Errorf("bad units \"%s\"", t.Units)
+	}
+	switch t.Form {
+	case "", "iso", "whitworth", "trapezoidal":
+	default:
+		return fmt.Errorf("unknown thread form \"%s\"", t.Form)
+	}
+	tCopy := *t
+	threadDB[t.Name] = &tCopy
+	return nil
+}
+
+// ThreadNames returns the sorted names of all the threads in the thread database.
+func ThreadNames() []string {
+	names := make([]string, 0, len(threadDB))
+	for name := range threadDB {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // HexRadius returns the hex head radius.
 func (t *ThreadParameters) HexRadius() float64 {
 	return t.HexFlat2Flat / (2.0 * math.Cos(DtoR(30)))
@@ -209,6 +397,188 @@ func (t *ThreadParameters) HexHeight() float64 {
 	return 2.0 * t.HexRadius() * (5.0 / 12.0)
 }
 
+//-----------------------------------------------------------------------------
+// Thread Tolerances
+
+// isoDeviation returns the ISO 965 fundamental deviation (um) of a tolerance position.
+var isoDeviation = map[byte]func(p float64) float64{
+	'e': func(p float64) float64 { return -(50 + 11*p) },
+	'f': func(p float64) float64 { return -(30 + 11*p) },
+	'g': func(p float64) float64 { return -(15 + 11*p) },
+	'h': func(p float64) float64 { return 0 },
+	'G': func(p float64) float64 { return 15 + 11*p },
+	'H': func(p float64) float64 { return 0 },
+}
+
+// isoGrade is the ISO 965 pitch diameter tolerance of a grade relative to grade 6.
+var isoGrade = map[byte]float64{
+	'3': 0.5,
+	'4': 0.63,
+	'5': 0.8,
+	'6': 1,
+	'7': 1.25,
+	'8': 1.6,
+	'9': 2,
+}
+
+// utsClass is an ASME B1.1 tolerance class.
+type utsClass struct {
+	factor    float64 // pitch diameter tolerance relative to class 2A
+	allowance bool    // the class has an allowance (0.3 x class 2A tolerance)
+}
+
+var utsClasses = map[string]utsClass{
+	"1A": {1.5, true},
+	"2A": {1, true},
+	"3A": {0.75, false},
+	"1B": {1.95, false},
+	"2B": {1.3, false},
+	"3B": {0.975, false},
+}
+
+// ThreadClassExternal returns true if a tolerance class is for an external thread.
+// ISO classes have lower case letters for external threads (E.g. "6g") and upper case
+// letters for internal threads (E.g. "6H"). UTS classes end in "A" for external threads
+// and "B" for internal threads.
+func ThreadClassExternal(class string) (bool, error) {
+	if _, ok := utsClasses[class]; ok {
+		return class[1] == 'A', nil
+	}
+	if len(class) >= 2 {
+		if _, ok := isoDeviation[class[1]]; ok {
+			return class[1] >= 'a', nil
+		}
+	}
+	return false, fmt.Errorf("unknown thread class \"%s\"", class)
+}
+
+// DefaultClass returns the default tolerance class for an external or internal thread.
+// These are the medium fit classes, 6g/6H for metric threads and 2A/2B for unified threads.
+// Tapered threads and threads that don't have the ISO form are not toleranced and have an empty class.
+func (t *ThreadParameters) DefaultClass(external bool) string {
+	switch {
+	case t.Taper != 0 || !t.isoForm():
+		return ""
+	case t.Units == "mm" && external:
+		return "6g"
+	case t.Units == "mm":
+		return "6H"
+	case external:
+		return "2A"
+	}
+	return "2B"
+}
+
+// ToleranceOffset returns the radial offset of a thread profile for a tolerance class.
+// It moves the pitch diameter to the middle of the tolerance zone of the class.
+// The offset is negative for external threads and positive for internal threads.
+// An empty class returns a zero offset.
+//
+// ISO 965 classes (E.g. "6g", "6H", "4h6h") are used with metric threads.
+// The pitch diameter grade and tolerance position are used, the crest diameter grade is ignored.
+// ASME B1.1 classes ("1A", "2A", "3A", "1B", "2B", "3B") are used with unified threads.
+func (t *ThreadParameters) ToleranceOffset(class string) (float64, error) {
+	if class == "" {
+		return 0, nil
+	}
+	if t.Taper != 0 {
+		return 0, fmt.Errorf("thread \"%s\" is tapered, tolerance classes are not supported", t.Name)
+	}
+	if !t.isoForm() {
+		return 0, fmt.Errorf("thread \"%s\" has a %s form, tolerance classes are not supported", t.Name, t.Form)
+	}
+	external, err := ThreadClassExternal(class)
+	if err != nil {
+		return 0, err
+	}
+	sign := 1.0
+	if external {
+		sign = -1.0
+	}
+
+	if c, ok := utsClasses[class]; ok {
+		if t.Units != "inch" {
+			return 0, fmt.Errorf("thread \"%s\" is not a unified thread, can't use class \"%s\"", t.Name, class)
+		}
+		// pitch diameter tolerance for class 2A (inches)
+		d := 2 * t.Radius
+		p := t.Pitch
+		le := 9 * p // length of engagement
+		t2a := 0.0015*math.Cbrt(d) + 0.0015*math.Sqrt(le) + 0.015*math.Pow(p, 2.0/3.0)
+		ofs := 0.5 * c.factor * t2a
+		if c.allowance {
+			ofs += 0.3 * t2a
+		}
+		// diameter to radius
+		return sign * 0.5 * ofs, nil
+	}
+
+	if t.Units != "mm" {
+		return 0, fmt.Errorf("thread \"%s\" is not a metric thread, can't use class \"%s\"", t.Name, class)
+	}
+	grade, ok := isoGrade[class[0]]
+	if !ok {
+		return 0, fmt.Errorf("bad tolerance grade in thread class \"%s\"", class)
+	}
+	// pitch diameter tolerance for grade 6 external threads (um)
+	td2 := 90 * math.Pow(t.Pitch, 0.4) * math.Pow(2*t.Radius, 0.1)
+	if !external {
+		td2 *= 1.32
+	}
+	deviation := isoDeviation[class[1]](t.Pitch)
+	// distance from the nominal pitch diameter to the middle of the tolerance zone
+	ofs := math.Abs(deviation) + 0.5*grade*td2
+	// um to mm, diameter to radius
+	return sign * 0.5 * ofs / 1000, nil
+}
+
+// ThreadRadius returns the major radius of an external or internal thread for a tolerance class.
+// An empty class gives the nominal radius.
+func (t *ThreadParameters) ThreadRadius(external bool, class string) (float64, error) {
+	if class != "" {
+		ext, err := ThreadClassExternal(class)
+		if err != nil {
+			return 0, err
+		}
+		if ext != external {
+			return 0, fmt.Errorf("thread class \"%s\" is for the wrong (internal/external) thread", class)
+		}
+	}
+	ofs, err := t.ToleranceOffset(class)
+	if err != nil {
+		return 0, err
+	}
+	return t.Radius + ofs, nil
+}
+
+// isoForm returns true if the thread has the ISO/UTS form.
+func (t *ThreadParameters) isoForm() bool {
+	return t.Form == "" || t.Form == "iso"
+}
+
+// ThreadProfile returns the 2d profile of an external or internal thread with the given major radius.
+func (t *ThreadParameters) ThreadProfile(radius float64, external bool) (SDF2, error) {
+	switch t.Form {
+	case "", "iso":
+		return ISOThread(radius, t.Pitch, external)
+	case "whitworth":
+		return WhitworthThread(radius, t.Pitch)
+	case "trapezoidal":
+		return TrapezoidalThread(radius, t.Pitch, external)
+	}
+	return nil, fmt.Errorf("unknown thread form \"%s\"", t.Form)
+}
+
+// Profile returns the 2d profile of an external or internal thread for a tolerance class.
+// An empty class gives an untoleranced thread.
+func (t *ThreadParameters) Profile(external bool, class string) (SDF2, error) {
+	r, err := t.ThreadRadius(external, class)
+	if err != nil {
+		return nil, err
+	}
+	return t.ThreadProfile(r, external)
+}
+
 //-----------------------------------------------------------------------------
 // Thread Profiles
 
@@ -278,6 +648,82 @@ func ISOThread(
 	return Polygon2D(iso.Vertices())
 }
 
+// WhitworthThread returns the 2d profile for a Whitworth (BSW, BSP) thread.
+// The form is the same for external and internal threads.
+// https://en.wikipedia.org/wiki/British_Standard_Whitworth
+func WhitworthThread(
+	radius float64, // radius of thread
+	pitch float64, // thread to thread distance
+) (SDF2, error) {
+	theta := DtoR(55.0 / 2.0)
+	H := pitch / (2.0 * math.Tan(theta)) // height of the sharp V
+	h := 0.640327 * pitch                // depth of the thread
+	r := 0.137329 * pitch                // crest and root radius
+	rCrest := radius + H/6.0             // radius of the sharp crest
+	rRoot := radius - h - H/6.0          // radius of the sharp root
+
+	bsw := NewPolygon()
+	bsw.Add(pitch, 0)
+	bsw.Add(pitch, rCrest)
+	bsw.Add(pitch/2.0, rRoot).Smooth(r, 5)
+	bsw.Add(0, rCrest).Smooth(r, 5)
+	bsw.Add(-pitch/2.0, rRoot).Smooth(r, 5)
+	bsw.Add(-pitch, rCrest)
+	bsw.Add(-pitch, 0)
+	return Polygon2D(bsw.Vertices())
+}
+
+// TrapezoidalThread returns the 2d profile for an ISO trapezoidal (Tr) thread.
+// The crest clearance between external and internal threads is built in (ISO 2904).
+// https://en.wikipedia.org/wiki/Trapezoidal_thread_form
+func TrapezoidalThread(
+	radius float64, // radius of thread
+	pitch float64, // thread to thread distance
+	external bool, // external (or internal) thread
+) (SDF2, error) {
+	// crest clearance
+	var ac float64
+	switch {
+	case pitch <= 1.5:
+		ac = 0.15
+	case pitch <= 5:
+		ac = 0.25
+	case pitch <= 12:
+		ac = 0.5
+	default:
+		ac = 1
+	}
+	t := math.Tan(DtoR(15.0))
+	// the thread is half the pitch wide at the pitch radius
+	r2 := radius - 0.25*pitch
+	var rTop, rBottom, h0, h1 float64
+	if external {
+		rTop = radius
+		rBottom = r2 - 0.25*pitch - ac
+	} else {
+		rTop = radius + ac
+		rBottom = r2 - 0.25*pitch
+	}
+	// half widths of the thread at the top and of the gap at the bottom
+	h0 = 0.25*pitch - (rTop-r2)*t
+	h1 = 0.25*pitch - (r2-rBottom)*t
+
+	tr := NewPolygon()
+	tr.Add(pitch, 0)
+	tr.Add(pitch, rTop)
+	tr.Add(pitch-h0, rTop)
+	tr.Add(pitch/2+h1, rBottom)
+	tr.Add(pitch/2-h1, rBottom)
+	tr.Add(h0, rTop)
+	tr.Add(-h0, rTop)
+	tr.Add(-pitch/2+h1, rBottom)
+	tr.Add(-pitch/2-h1, rBottom)
+	tr.Add(-pitch+h0, rTop)
+	tr.Add(-pitch, rTop)
+	tr.Add(-pitch, 0)
+	return Polygon2D(tr.Vertices())
+}
+
 // ANSIButtressThread returns the 2d profile for an ANSI 45/7 buttress thread.
 // https://en.wikipedia.org/wiki/Buttress_thread
 // AMSE B1.9-1973
@@ -341,7 +787,14 @@ type ScrewSDF3 struct {
 	length float64 // total length of screw
 	taper  float64 // thread taper angle
 	starts int     // number of thread starts
+	minor  float64 // minor radius of thread
+	major  float64 // major radius of thread
+	k      float64 // lipschitz factor of the screw mapping
+	core   float64 // radius below which the distance to the minor radius is used
 	bb     Box3    // bounding box
+	// shaped ends
+	external bool        // external (or internal) thread
+	end      [2]ScrewEnd // bottom and top ends
 }
 
 // Screw3D returns a screw SDF3.
@@ -380,15 +833,136 @@ func Screw3D(
 	// add the taper increment
 	r += s.length * math.Tan(taper)
 	s.bb = Box3{v3.Vec{-r, -r, -s.length}, v3.Vec{r, r, s.length}}
+	// Work out the distance scaling.
+	// Below the minor radius the distance to the solid core is used, so the lipschitz
+	// factor only needs to hold down to (about) the minor radius.
+	s.minor = screwMinorRadius(thread, pitch)
+	rk := s.minor - 0.5*pitch
+	if rk <= 0 {
+		// no solid core, use a fraction of the major radius
+		rk = 0.5 * bb.Max.Y
+	}
+	s.k = screwLipschitz(rk, s.lead, taper)
+	s.core = math.Min(rk, s.minor)
+	s.major = screwMajorRadius(thread, pitch)
 	return &s, nil
 }
 
+// ScrewEnd defines the shape of an end of a screw thread.
+// Going inwards from the end there is a thread-free shank, then the thread run-out, then the full thread.
+type ScrewEnd struct {
+	Chamfer float64 // axial length of a 45 degree lead-in chamfer (external) or countersink (internal)
+	Shank   float64 // axial length of the thread-free shank
+	Runout  float64 // axial length over which the thread depth tapers to zero
+}
+
+// ScrewEnds3D returns a screw with shaped ends.
+// For external threads the shank is at the major radius, for internal threads it is at the minor radius.
+func ScrewEnds3D(
+	screw SDF3, // screw made by Screw3D
+	external bool, // external (or internal) thread
+	bottom ScrewEnd, // bottom (-z) end
+	top ScrewEnd, // top (+z) end
+) (SDF3, error) {
+	s0, ok := screw.(*ScrewSDF3)
+	if !ok {
+		return nil, ErrMsg("screw is not a *ScrewSDF3")
+	}
+	for _, e := range []ScrewEnd{bottom, top} {
+		if e.Chamfer < 0 {
+			return nil, ErrMsg("Chamfer < 0")
+		}
+		if e.Shank < 0 {
+			return nil, ErrMsg("Shank < 0")
+		}
+		if e.Runout < 0 {
+			return nil, ErrMsg("Runout < 0")
+		}
+	}
+	s := *s0
+	s.external = external
+	s.end = [2]ScrewEnd{bottom, top}
+	if !external {
+		// the countersink is outside the thread
+		r := s.bb.Max.X + math.Max(bottom.Chamfer, top.Chamfer)
+		s.bb = Box3{v3.Vec{-r, -r, -s.length}, v3.Vec{r, r, s.length}}
+	}
+	return &s, nil
+}
+
+// screwMinorRadius returns an estimate of the minor radius of a thread profile.
+// This is the radius below which the profile is solid for the whole pitch period.
+func screwMinorRadius(thread SDF2, pitch float64) float64 {
+	const nx = 32  // samples across the pitch
+	const ny = 256 // samples across the profile height
+	bb := thread.BoundingBox()
+	dy := (bb.Max.Y - bb.Min.Y) / ny
+	r := bb.Max.Y
+	for i := 0; i < nx; i++ {
+		x := pitch * (float64(i)/nx - 0.5)
+		// scan down from the outside of the profile to the first inside point
+		y := bb.Max.Y
+		for y > bb.Min.Y && thread.Evaluate(v2.Vec{x, y}) >= 0 {
+			y -= dy
+		}
+		r = math.Min(r, y)
+	}
+	// allow for the profile dipping between x samples
+	return r - pitch/nx
+}
+
+// screwMajorRadius returns an estimate of the major radius of a thread profile.
+// This is the largest radius of the profile within the pitch period.
+func screwMajorRadius(thread SDF2, pitch float64) float64 {
+	const nx = 32  // samples across the pitch
+	const ny = 256 // samples across the profile height
+	bb := thread.BoundingBox()
+	dy := (bb.Max.Y - bb.Min.Y) / ny
+	r := bb.Min.Y
+	for i := 0; i < nx; i++ {
+		x := pitch * (float64(i)/nx - 0.5)
+		// scan down from the outside of the profile to the first inside point
+		y := bb.Max.Y
+		for y > bb.Min.Y && thread.Evaluate(v2.Vec{x, y}) >= 0 {
+			y -= dy
+		}
+		// and refine the crossing
+		y0, y1 := y, y+dy
+		for j := 0; j < 32; j++ {
+			y := 0.5 * (y0 + y1)
+			if thread.Evaluate(v2.Vec{x, y}) < 0 {
+				y0 = y
+			} else {
+				y1 = y
+			}
+		}
+		r = math.Max(r, y0)
+	}
+	return r
+}
+
+// screwLipschitz returns the lipschitz factor of the screw mapping from 3d to the thread profile.
+// The thread profile x-axis varies with z and theta, the y-axis varies with the radius and z (taper).
+// The theta contribution grows as the radius decreases, so we evaluate it at radius r.
+func screwLipschitz(r, lead, taper float64) float64 {
+	// gradients in (radial, tangential, z) coordinates:
+	// x: (0, q, 1), y: (1, 0, c)
+	q := lead / (Tau * r)
+	c := math.Atan(taper)
+	// largest eigenvalue of the 2x2 gram matrix
+	a := q*q + 1
+	b := 1 + c*c
+	l := 0.5 * (a + b + math.Sqrt((a-b)*(a-b)+4*c*c))
+	return math.Sqrt(l)
+}
+
 // Evaluate returns the minimum distance to a 3d screw form.
 func (s *ScrewSDF3) Evaluate(p v3.Vec) float64 {
 	// map the 3d point back to the xy space of the profile
 	p0 := v2.Vec{}
 	// the distance from the 3d z-axis maps to the 2d y-axis
-	p0.Y = math.Sqrt(p.X*p.X + p.Y*p.Y)
+	r := math.Sqrt(p.X*p.X + p.Y*p.Y)
+	p0.Y = r
 	if s.taper != 0 {
 		p0.Y += p.Z * math.Atan(s.taper)
 	}
@@ -397,14 +971,60 @@ func (s *ScrewSDF3) Evaluate(p v3.Vec) float64 {
 	theta := math.Atan2(p.Y, p.X)
 	z := p.Z + s.lead*theta/Tau
 	p0.X = SawTooth(z, s.pitch)
-	// get the thread profile distance
-	d0 := s.thread.Evaluate(p0)
+	// Get the thread profile distance.
+	// The screw mapping stretches distances by k, so scale the profile distance.
+	d0 := s.thread.Evaluate(p0) / s.k
+	// The distance to the bounding cylinder is a lower bound outside of it.
+	d0 = math.Max(d0, r-s.bb.Max.X)
+	// The distance to the minor radius is an upper bound within the solid core.
+	// The lipschitz factor only holds above the core radius, so below it the
+	// profile distance is lifted above the distance to the minor radius.
+	// The two bounds meet at the core radius, and the lift is negative above it.
+	d0 = math.Min(r-s.minor, math.Max(d0, 2*s.core-r-s.minor))
+	// shape the ends
+	d0 = s.shapeEnd(d0, p0.Y, p.Z+s.length, s.end[0])
+	d0 = s.shapeEnd(d0, p0.Y, s.length-p.Z, s.end[1])
 	// create a region for the screw length
 	d1 := math.Abs(p.Z) - s.length
 	// return the intersection
 	return math.Max(d0, d1)
 }
 
+// shapeEnd modifies the thread distance for the shape of an end.
+// r is the (taper corrected) radius, e is the axial distance from the end.
+func (s *ScrewSDF3) shapeEnd(d, r, e float64, end ScrewEnd) float64 {
+	h := s.major - s.minor
+	if end.Shank > 0 || end.Runout > 0 {
+		// The thread radius (the crest of internal threads, the root of external threads)
+		// tapers between the shank and full thread.
+		k := 1.0
+		t := 0.0
+		if end.Runout > 0 {
+			k = 1 / math.Sqrt(1+(h/end.Runout)*(h/end.Runout))
+			t = Clamp((e-end.Shank)/end.Runout, 0, 1)
+		}
+		l := end.Shank + end.Runout
+		if s.external {
+			// union with the shank and the run-out cone
+			rr := s.major - h*t
+			d = math.Min(d, math.Max((r-rr)*k, e-l))
+		} else {
+			// intersect with the shank and the run-out cone
+			rr := s.minor + h*t
+			d = math.Max(d, math.Min((r-rr)*k, l-e))
+		}
+	}
+	if end.Chamfer > 0 {
+		// 45 degree cone from the end
+		if s.external {
+			d = math.Max(d, (r-(s.major-end.Chamfer+e))*sqrtHalf)
+		} else {
+			d = math.Min(d, (r-(s.major+end.Chamfer-e))*sqrtHalf)
+		}
+	}
+	return d
+}
+
 // BoundingBox returns the bounding box for a 3d screw form.
 func (s *ScrewSDF3) BoundingBox() Box3 {
 	return s.bb
@@ -148,6 +148,8 @@ type ExtrudeSDF3 struct {
 	sdf     SDF2
 	height  float64
 	extrude ExtrudeFunc
+	k       float64 // lipschitz factor of the extrude function
+	r       float64 // bounding cylinder radius (twisted extrusions)
 	bb      Box3
 }
 
@@ -157,6 +159,7 @@ func Extrude3D(sdf SDF2, height float64) SDF3 {
 	s.sdf = sdf
 	s.height = height / 2
 	s.extrude = NormalExtrude
+	s.k = 1
 	// work out the bounding box
 	bb := sdf.BoundingBox()
 	s.bb = Box3{v3.Vec{bb.Min.X, bb.Min.Y, -s.height}, v3.Vec{bb.Max.X, bb.Max.Y, s.height}}
@@ -170,9 +173,11 @@ func TwistExtrude3D(sdf SDF2, height, twist float64) SDF3 {
 	s.height = height / 2
 	s.extrude = TwistExtrude(height, twist)
 	// work out the bounding box
-	bb := sdf.BoundingBox()
-	l := bb.Max.Length()
-	s.bb = Box3{v3.Vec{-l, -l, -s.height}, v3.Vec{l, l, s.height}}
+	s.r = box2Radius(sdf.BoundingBox())
+	s.bb = Box3{v3.Vec{-s.r, -s.r, -s.height}, v3.Vec{s.r, s.r, s.height}}
+	// A twist of k radians per unit z moves a point at radius r by k*r per unit z.
+	k := twist / height
+	s.k = math.Sqrt(1 + k*k*s.r*s.r)
 	return &s
 }
 
@@ -186,6 +191,11 @@ func ScaleExtrude3D(sdf SDF2, height float64, scale v2.Vec) SDF3 {
 	bb := sdf.BoundingBox()
 	bb = bb.Extend(Box2{bb.Min.Mul(scale), bb.Max.Mul(scale)})
 	s.bb = Box3{v3.Vec{bb.Min.X, bb.Min.Y, -s.height}, v3.Vec{bb.Max.X, bb.Max.Y, s.height}}
+	// The xy scaling ranges from 1 to 1/scale, and changes with z at a rate of m.
+	smax, m := scaleExtrudeFactors(height, scale)
+	x := math.Max(math.Abs(bb.Min.X), math.Abs(bb.Max.X))
+	y := math.Max(math.Abs(bb.Min.Y), math.Abs(bb.Max.Y))
+	s.k = math.Sqrt(smax*smax + x*x*m.X*m.X + y*y*m.Y*m.Y)
 	return &s
 }
 
@@ -198,15 +208,50 @@ func ScaleTwistExtrude3D(sdf SDF2, height, twist float64, scale v2.Vec) SDF3 {
 	// work out the bounding box
 	bb := sdf.BoundingBox()
 	bb = bb.Extend(Box2{bb.Min.Mul(scale), bb.Max.Mul(scale)})
-	l := bb.Max.Length()
-	s.bb = Box3{v3.Vec{-l, -l, -s.height}, v3.Vec{l, l, s.height}}
+	s.r = box2Radius(bb)
+	s.bb = Box3{v3.Vec{-s.r, -s.r, -s.height}, v3.Vec{s.r, s.r, s.height}}
+	// combine the scaling and twisting rates of change
+	smax, m := scaleExtrudeFactors(height, scale)
+	k := math.Abs(twist/height)*smax*s.r + m.Abs().MaxComponent()*s.r
+	s.k = math.Sqrt(smax*smax + k*k)
 	return &s
 }
 
+// scaleExtrudeFactors returns the maximum xy scaling and the z slope of the xy scaling for a scaled extrusion.
+func scaleExtrudeFactors(height float64, scale v2.Vec) (float64, v2.Vec) {
+	inv := v2.Vec{1 / scale.X, 1 / scale.Y}
+	smax := math.Max(1, inv.Abs().MaxComponent())
+	return smax, inv.Sub(v2.Vec{1, 1}).DivScalar(height)
+}
+
 // Evaluate returns the minimum distance to an extrusion.
 func (s *ExtrudeSDF3) Evaluate(p v3.Vec) float64 {
-	// sdf for the projected 2d surface
-	a := s.sdf.Evaluate(s.extrude(p))
+	var a float64
+	if s.k == 1 {
+		// sdf for the projected 2d surface
+		a = s.sdf.Evaluate(s.extrude(p))
+	} else {
+		// The extrude function stretches distances by up to k within the bounding volume.
+		// Clamp the point to the bounding volume, scale the distance by 1/k and use the
+		// distance to the bounding volume as a lower bound outside of it.
+		var q v3.Vec
+		var d float64
+		if s.r > 0 {
+			// bounding cylinder
+			l := math.Sqrt(p.X*p.X + p.Y*p.Y)
+			q = p
+			if l > s.r {
+				q.X *= s.r / l
+				q.Y *= s.r / l
+			}
+			d = l - s.r
+		} else {
+			// bounding box
+			q = p.Clamp(s.bb.Min, s.bb.Max)
+			d = sdfBox3d(p.Sub(s.bb.Center()), s.bb.Size().MulScalar(0.5))
+		}
+		a = math.Max(s.sdf.Evaluate(s.extrude(q))/s.k, d)
+	}
 	// sdf for the extrusion region: z = [-height, height]
 	b := math.Abs(p.Z) - s.height
 	// return the intersection
@@ -214,6 +259,8 @@ func (s *ExtrudeSDF3) Evaluate(p v3.Vec) float64 {
 }
 
 // SetExtrude sets the extrusion control function.
+// The distance scaling of the original extrusion is retained, so the new function should
+// not stretch distances more than the original.
 func (s *ExtrudeSDF3) SetExtrude(extrude ExtrudeFunc) {
 	s.extrude = extrude
 }
@@ -307,6 +354,7 @@ type LoftSDF3 struct {
 	sdf0, sdf1 SDF2
 	height     float64
 	round      float64
+	k          float64 // lipschitz factor of the z-blend
 	bb         Box3
 }
 
@@ -338,9 +386,32 @@ func Loft3D(sdf0, sdf1 SDF2, height, round float64) (SDF3, error) {
 	bb1 := sdf1.BoundingBox()
 	bb := bb0.Extend(bb1)
 	s.bb = Box3{v3.Vec{bb.Min.X, bb.Min.Y, -s.height}.SubScalar(round), v3.Vec{bb.Max.X, bb.Max.Y, s.height}.AddScalar(round)}
+	// The blended distance changes with z at (sdf1 - sdf0)/(2 * height).
+	// Scale it down so the gradient is <= 1.
+	s.k = 1
+	if s.height > 0 {
+		dz := loftDifference(sdf0, sdf1, bb) / (2 * s.height)
+		s.k = math.Sqrt(1 + dz*dz)
+	}
 	return &s, nil
 }
 
+// loftDifference returns an upper bound for |sdf1 - sdf0| around a bounding box.
+func loftDifference(sdf0, sdf1 SDF2, bb Box2) float64 {
+	const n = 64 // samples on each axis
+	bb = bb.ScaleAboutCenter(1.5)
+	step := bb.Size().DivScalar(n - 1)
+	d := 0.0
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			p := bb.Min.Add(v2.Vec{float64(i) * step.X, float64(j) * step.Y})
+			d = math.Max(d, math.Abs(sdf1.Evaluate(p)-sdf0.Evaluate(p)))
+		}
+	}
+	// The difference changes by at most 2 times the distance to the nearest sample.
+	return d + step.Length()
+}
+
 // Evaluate returns the minimum distance to a loft extrusion.
 func (s *LoftSDF3) Evaluate(p v3.Vec) float64 {
 	// work out the mix value as a function of height
@@ -348,7 +419,7 @@ func (s *LoftSDF3) Evaluate(p v3.Vec) float64 {
 	// mix the 2D SDFs
 	a0 := s.sdf0.Evaluate(v2.Vec{p.X, p.Y})
 	a1 := s.sdf1.Evaluate(v2.Vec{p.X, p.Y})
-	a := Mix(a0, a1, k)
+	a := Mix(a0, a1, k) / s.k
 
 	b := math.Abs(p.Z) - s.height
 	var d float64
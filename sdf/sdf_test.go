@@ -7,13 +7,18 @@ package sdf
 import (
 	"fmt"
 	"math"
+	"math/rand"
 	"reflect"
+	"sort"
+	"strings"
 	"testing"
 
 	v2 "github.com/gmlewis/sdfx/vec/v2"
 	"github.com/gmlewis/sdfx/vec/v2i"
 	v3 "github.com/gmlewis/sdfx/vec/v3"
+	"github.com/gmlewis/sdfx/vec/v3i"
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/image/font/sfnt"
 )
 
 //-----------------------------------------------------------------------------
@@ -872,3 +877,889 @@ func Test_Normal(t *testing.T) {
 }
 
 //-----------------------------------------------------------------------------
+
+func Test_Sweep3D(t *testing.T) {
+	circle, err := Circle2D(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cylinder, err := Cylinder3D(10, 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a straight sweep along z is the same as a cylinder
+	for _, frame := range []SweepFrame{FrenetFrame, RotationMinimizingFrame} {
+		s, err := Sweep3D(circle, v3.VecSet{{0, 0, -5}, {0, 0, 0}, {0, 0, 5}}, frame)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !s.BoundingBox().Contains(v3.Vec{1, 1, 5}) {
+			t.Errorf("bad bounding box %v", s.BoundingBox())
+		}
+		b := NewBox3(v3.Vec{0, 0, 0}, v3.Vec{6, 6, 9.8})
+		for _, p := range b.RandomSet(1000) {
+			d0 := s.Evaluate(p)
+			d1 := cylinder.Evaluate(p)
+			if math.Abs(d0-d1) > tolerance {
+				t.Errorf("%v %f (expected) %f (actual)", p, d1, d0)
+			}
+		}
+	}
+	// a quarter circle path
+	path, err := BezierPath([]v3.Vec{{10, 0, 0}, {10, 5.5228, 0}, {5.5228, 10, 0}, {0, 10, 0}}, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := Sweep3D(circle, path, RotationMinimizingFrame)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := v3.Vec{10 * sqrtHalf, 10 * sqrtHalf, 0}
+	// interior distances are exact within a segment length of the surface
+	if d := s.Evaluate(p.Add(v3.Vec{0, 0, 0.75})); math.Abs(d+0.25) > 0.01 {
+		t.Errorf("%v -0.25 (expected) %f (actual)", p, d)
+	}
+	// and conservative further in
+	if d := s.Evaluate(p); d >= 0 || d < -1-tolerance {
+		t.Errorf("%v [-1, 0) (expected) %f (actual)", p, d)
+	}
+	if d := s.Evaluate(p.Add(v3.Vec{0, 0, 1})); math.Abs(d) > 0.01 {
+		t.Errorf("%v 0 (expected) %f (actual)", p, d)
+	}
+	for _, v := range path {
+		if !s.BoundingBox().Contains(v) {
+			t.Errorf("bad bounding box %v", s.BoundingBox())
+		}
+	}
+	// a sharp turn has a round join that stays within the profile radius of the path
+	path = v3.VecSet{{0, 0, 0}, {10, 0, 0}, {0, 1, 0}}
+	pathDistance := func(p v3.Vec) float64 {
+		d := math.MaxFloat64
+		for i := 0; i < len(path)-1; i++ {
+			a, b := path[i], path[i+1]
+			t := Clamp(p.Sub(a).Dot(b.Sub(a))/b.Sub(a).Length2(), 0, 1)
+			d = math.Min(d, p.Sub(a.Add(b.Sub(a).MulScalar(t))).Length())
+		}
+		return d
+	}
+	for _, frame := range []SweepFrame{FrenetFrame, RotationMinimizingFrame} {
+		s, err := Sweep3D(circle, path, frame)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bb := s.BoundingBox()
+		if bb.Max.X > 12 {
+			t.Errorf("bad bounding box %v", bb)
+		}
+		// the outside of the turn is filled
+		if d := s.Evaluate(v3.Vec{10.5, 0, 0}); d >= 0 || d < -0.5-tolerance {
+			t.Errorf("[-0.5, 0) (expected) %f (actual)", d)
+		}
+		if d := s.Evaluate(v3.Vec{11, 0, 0}); math.Abs(d) > tolerance {
+			t.Errorf("0 (expected) %f (actual)", d)
+		}
+		for x := 10.0; x < 20; x += 0.01 {
+			p := v3.Vec{x, 0.1, 0}
+			if d := s.Evaluate(p); d < 0 && pathDistance(p) > 1+tolerance {
+				t.Errorf("%v is inside (%f)", p, d)
+			}
+		}
+		inside := NewBox3(v3.Vec{5, 0, 0}, v3.Vec{20, 10, 10})
+		for _, p := range inside.RandomSet(20000) {
+			if s.Evaluate(p) >= 0 {
+				continue
+			}
+			if !bb.Contains(p) {
+				t.Errorf("%v is outside the bounding box %v", p, bb)
+			}
+			if d := pathDistance(p); d > 1+tolerance {
+				t.Errorf("%v is %f from the path", p, d)
+			}
+		}
+		if k := lipschitz3(s, 20000, rand.New(rand.NewSource(1))); k > 1+1e-6 {
+			t.Errorf("lipschitz factor %f > 1", k)
+		}
+	}
+	// a non-circular profile on a curved path is still a distance bound,
+	// including a profile that is wider than the radius of curvature
+	path, err = CubicSplinePath([]v3.Vec{{0, 0, 0}, {5, 5, 0}, {10, 0, 5}, {0, -3, 2}}, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, profile := range []SDF2{Box2D(v2.Vec{4, 2}, 0), Box2D(v2.Vec{8, 3}, 0)} {
+		for _, frame := range []SweepFrame{FrenetFrame, RotationMinimizingFrame} {
+			s, err := Sweep3D(profile, path, frame)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if k := lipschitz3(s, 20000, rand.New(rand.NewSource(1))); k > 1+1e-6 {
+				t.Errorf("lipschitz factor %f > 1", k)
+			}
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+// lipschitz3 returns the largest |f(a)-f(b)|/|a-b| for random points around an SDF3.
+func lipschitz3(s SDF3, n int, rnd *rand.Rand) float64 {
+	bb := s.BoundingBox()
+	bb = bb.ScaleAboutCenter(1.5)
+	size := bb.Size().MaxComponent()
+	k := 0.0
+	for i := 0; i < n; i++ {
+		a := bb.Min.Add(bb.Size().Mul(v3.Vec{rnd.Float64(), rnd.Float64(), rnd.Float64()}))
+		// mix short and long steps
+		l := size * math.Pow(10, -4*rnd.Float64())
+		dir := v3.Vec{rnd.NormFloat64(), rnd.NormFloat64(), rnd.NormFloat64()}.Normalize()
+		b := a.Add(dir.MulScalar(l))
+		k = math.Max(k, math.Abs(s.Evaluate(a)-s.Evaluate(b))/l)
+	}
+	return k
+}
+
+func Test_Lipschitz3(t *testing.T) {
+	circle, _ := Circle2D(3)
+	small, _ := Circle2D(0.6)
+	box2 := Box2D(v2.Vec{8, 4}, 0.5)
+	offsetBox := Transform2D(box2, Translate2d(v2.Vec{3, 1}))
+	box, _ := Box3D(v3.Vec{4, 5, 6}, 0.5)
+	sphere, _ := Sphere3D(2)
+	cylinder, _ := Cylinder3D(5, 2, 0.5)
+	cone, _ := Cone3D(4, 2, 1, 0.2)
+	iso, _ := ISOThread(5, 1, true)
+	acme, _ := AcmeThread(5, 2)
+	path, _ := CubicSplinePath([]v3.Vec{{0, 0, 0}, {10, 5, 0}, {20, 0, 5}}, 16)
+
+	// constructors that do not preserve distance, and their lipschitz factor
+	notExact := map[string]float64{
+		"Gyroid3D":     math.Sqrt2 * Tau * math.Sqrt(3) / 10, // the gyroid is an implicit surface, not a distance
+		"NewVoxelSDF3": math.Sqrt(3),                         // trilinear interpolation
+	}
+
+	tests := map[string]func() (SDF3, error){
+		"Revolve3D": func() (SDF3, error) {
+			return Revolve3D(Transform2D(circle, Translate2d(v2.Vec{5, 0})))
+		},
+		"RevolveTheta3D": func() (SDF3, error) {
+			return RevolveTheta3D(Transform2D(circle, Translate2d(v2.Vec{5, 0})), DtoR(225))
+		},
+		"Extrude3D":      func() (SDF3, error) { return Extrude3D(box2, 5), nil },
+		"TwistExtrude3D": func() (SDF3, error) { return TwistExtrude3D(offsetBox, 10, Tau), nil },
+		"ScaleExtrude3D": func() (SDF3, error) { return ScaleExtrude3D(offsetBox, 10, v2.Vec{0.2, 1.5}), nil },
+		"ScaleTwistExtrude3D": func() (SDF3, error) {
+			return ScaleTwistExtrude3D(offsetBox, 10, 1.5*Pi, v2.Vec{0.5, 2}), nil
+		},
+		"ExtrudeRounded3D": func() (SDF3, error) { return ExtrudeRounded3D(box2, 5, 1) },
+		"Loft3D":           func() (SDF3, error) { return Loft3D(circle, box2, 5, 0.5) },
+		"Loft3D(steep)":    func() (SDF3, error) { return Loft3D(small, Box2D(v2.Vec{20, 10}, 0), 2, 0) },
+		"Box3D":            func() (SDF3, error) { return box, nil },
+		"Sphere3D":         func() (SDF3, error) { return sphere, nil },
+		"Cylinder3D":       func() (SDF3, error) { return cylinder, nil },
+		"Capsule3D":        func() (SDF3, error) { return Capsule3D(6, 1.5) },
+		"Cone3D":           func() (SDF3, error) { return cone, nil },
+		"Transform3D": func() (SDF3, error) {
+			return Transform3D(box, RotateX(1).Mul(Translate3d(v3.Vec{1, 2, 3}))), nil
+		},
+		"ScaleUniform3D": func() (SDF3, error) { return ScaleUniform3D(box, 2.5), nil },
+		"Union3D":        func() (SDF3, error) { return Union3D(box, Transform3D(sphere, Translate3d(v3.Vec{3, 0, 0}))), nil },
+		"Difference3D":   func() (SDF3, error) { return Difference3D(box, sphere), nil },
+		"Elongate3D":     func() (SDF3, error) { return Elongate3D(sphere, v3.Vec{2, 3, 4}), nil },
+		"Intersect3D":    func() (SDF3, error) { return Intersect3D(box, sphere), nil },
+		"Cut3D":          func() (SDF3, error) { return Cut3D(box, v3.Vec{0, 0, 1}, v3.Vec{1, 1, 1}), nil },
+		"Array3D": func() (SDF3, error) {
+			return Array3D(sphere, v3i.Vec{3, 2, 1}, v3.Vec{5, 5, 5}), nil
+		},
+		"RotateUnion3D": func() (SDF3, error) {
+			return RotateUnion3D(Transform3D(sphere, Translate3d(v3.Vec{5, 0, 0})), 5, RotateZ(Tau/5)), nil
+		},
+		"RotateCopy3D": func() (SDF3, error) {
+			return RotateCopy3D(Transform3D(sphere, Translate3d(v3.Vec{8, 0, 0})), 6), nil
+		},
+		"Offset3D": func() (SDF3, error) { return Offset3D(box, 1), nil },
+		"Shell3D":  func() (SDF3, error) { return Shell3D(sphere, 0.5) },
+		"LineOf3D": func() (SDF3, error) {
+			return LineOf3D(sphere, v3.Vec{0, 0, 0}, v3.Vec{20, 0, 0}, "x.x.x"), nil
+		},
+		"Multi3D": func() (SDF3, error) {
+			return Multi3D(sphere, v3.VecSet{{0, 0, 0}, {5, 5, 0}}), nil
+		},
+		"Orient3D": func() (SDF3, error) {
+			return Orient3D(cylinder, v3.Vec{0, 0, 1}, v3.VecSet{{1, 0, 0}, {0, 1, 0}}), nil
+		},
+		"Screw3D(iso)":  func() (SDF3, error) { return Screw3D(iso, 10, 0, 1, 1) },
+		"Screw3D(acme)": func() (SDF3, error) { return Screw3D(acme, 10, 0, 2, 3) },
+		"Screw3D(taper)": func() (SDF3, error) {
+			return Screw3D(iso, 10, math.Atan(1.0/32.0), 1, -1)
+		},
+		"ScrewEnds3D(external)": func() (SDF3, error) {
+			s, _ := Screw3D(iso, 10, 0, 1, 2)
+			return ScrewEnds3D(s, true, ScrewEnd{Shank: 1, Runout: 2}, ScrewEnd{Chamfer: 0.5})
+		},
+		"ScrewEnds3D(internal)": func() (SDF3, error) {
+			s, _ := Screw3D(iso, 10, 0, 1, 1)
+			return ScrewEnds3D(s, false, ScrewEnd{Chamfer: 0.5}, ScrewEnd{Runout: 1})
+		},
+		"Sweep3D": func() (SDF3, error) { return Sweep3D(circle, path, RotationMinimizingFrame) },
+		"Gyroid3D": func() (SDF3, error) {
+			g, err := Gyroid3D(v3.Vec{10, 10, 10})
+			return Intersect3D(box, g), err
+		},
+		"NewVoxelSDF3": func() (SDF3, error) { return NewVoxelSDF3(sphere, 20, nil), nil },
+	}
+	// test in a fixed order with a fixed sequence of points for each constructor
+	names := make([]string, 0, len(tests))
+	for name := range tests {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		s, err := tests[name]()
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		limit := 1.0
+		if k, ok := notExact[name]; ok {
+			limit = k
+		}
+		k := lipschitz3(s, 20000, rand.New(rand.NewSource(1)))
+		if k > limit+1e-6 {
+			t.Errorf("%s: lipschitz factor %f > %f", name, k, limit)
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_ThreadTolerance(t *testing.T) {
+	tests := []struct {
+		thread string
+		class  string
+		ofs    float64 // radial offset
+		tol    float64
+	}{
+		// ISO 965-2: M10x1.5 6g d2 = 8.862..8.994, 6H D2 = 9.026..9.206, basic d2 = 9.026
+		{"M10x1.5", "6g", 0.5 * (8.928 - 9.026), 0.002},
+		{"M10x1.5", "6H", 0.5 * (9.116 - 9.026), 0.002},
+		{"M10x1.5", "", 0, 0},
+		// ASME B1.1: 1/4-20 2A pitch diameter = 0.2127..0.2164, basic 0.2175
+		{"unc_1/4", "2A", 0.5 * (0.21455 - 0.2175), 0.0003},
+		{"unc_1/4", "3A", 0.5 * (0.2175 - 0.00140 - 0.2175), 0.0003},
+	}
+	for _, test := range tests {
+		tp, err := ThreadLookup(test.thread)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ofs, err := tp.ToleranceOffset(test.class)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(ofs-test.ofs) > test.tol {
+			t.Errorf("%s %s: offset %f, expected %f", test.thread, test.class, ofs, test.ofs)
+		}
+	}
+	// bad classes
+	tp, _ := ThreadLookup("M6x1")
+	for _, class := range []string{"2A", "6x", "1g", "x"} {
+		if _, err := tp.ToleranceOffset(class); err == nil {
+			t.Errorf("M6x1 %s: expected error", class)
+		}
+	}
+	if _, err := tp.ThreadRadius(true, "6H"); err == nil {
+		t.Error("M6x1 external 6H: expected error")
+	}
+	tp, _ = ThreadLookup("npt_1/2")
+	if _, err := tp.ToleranceOffset("2A"); err == nil {
+		t.Error("npt_1/2 2A: expected error")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_ThreadDatabase(t *testing.T) {
+	// pitch radius of each thread form (relative to the pitch)
+	pitchDepth := map[string]float64{
+		"iso":         0.75 * math.Sqrt(3) / 4,
+		"whitworth":   0.640327 / 2,
+		"trapezoidal": 0.25,
+	}
+	names := ThreadNames()
+	if !sort.StringsAreSorted(names) {
+		t.Error("thread names are not sorted")
+	}
+	for _, name := range names {
+		tp, err := ThreadLookup(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, external := range []bool{true, false} {
+			profile, err := tp.Profile(external, "")
+			if err != nil {
+				t.Fatalf("%s: %s", name, err)
+			}
+			if _, err := Screw3D(profile, 10*tp.Pitch, tp.Taper, tp.Pitch, 1); err != nil {
+				t.Fatalf("%s: %s", name, err)
+			}
+			if !external || tp.Taper != 0 {
+				continue
+			}
+			// the thread is half a pitch wide at the pitch radius
+			r2 := tp.Radius - pitchDepth[tp.Form]*tp.Pitch
+			d := profile.Evaluate(v2.Vec{0.25 * tp.Pitch, r2})
+			if math.Abs(d) > 0.01*tp.Pitch {
+				t.Errorf("%s: distance %f at the pitch radius", name, d)
+			}
+		}
+	}
+	// custom threads
+	custom := ThreadParameters{Name: "custom", Radius: 3, Pitch: 0.7, HexFlat2Flat: 10, Units: "mm", Form: "trapezoidal"}
+	if err := ThreadRegister(&custom); err != nil {
+		t.Fatal(err)
+	}
+	if err := ThreadRegister(&custom); err == nil {
+		t.Error("expected error for duplicate thread")
+	}
+	if _, err := ThreadLookup("custom"); err != nil {
+		t.Error(err)
+	}
+	custom.Name = "bad"
+	custom.Form = "square"
+	if err := ThreadRegister(&custom); err == nil {
+		t.Error("expected error for bad thread form")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_TextLayout(t *testing.T) {
+	f, err := LoadFont("../files/cmr10.ttf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	const h = 10.0
+	// horizontal alignment
+	for _, test := range []struct {
+		a    HAlign
+		x    func(bb Box2) float64
+		want float64
+	}{
+		{HAlignLeft, func(bb Box2) float64 { return bb.Min.X }, 0},
+		{HAlignRight, func(bb Box2) float64 { return bb.Max.X }, 0},
+		{HAlignCenter, func(bb Box2) float64 { return bb.Center().X }, 0},
+	} {
+		txt := NewText("Hello\nWorld")
+		txt.SetHAlign(test.a)
+		s, err := TextSDF2(f, txt, h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the glyph side bearings are small relative to the text height
+		if x := test.x(s.BoundingBox()); math.Abs(x-test.want) > 0.05*h {
+			t.Errorf("halign %d: x %f, want %f", test.a, x, test.want)
+		}
+	}
+	// vertical alignment: the top line is just below y = 0, the bottom line is just above it
+	txt := NewText("Hello\nWorld")
+	txt.SetVAlign(VAlignTop)
+	s, _ := TextSDF2(f, txt, h)
+	if bb := s.BoundingBox(); bb.Max.Y > 0 || bb.Max.Y < -0.5*h {
+		t.Errorf("valign top: %v", bb)
+	}
+	txt.SetVAlign(VAlignBottom)
+	s, _ = TextSDF2(f, txt, h)
+	if bb := s.BoundingBox(); bb.Min.Y < 0 || bb.Min.Y > 0.5*h {
+		t.Errorf("valign bottom: %v", bb)
+	}
+	// line spacing
+	txt.SetLineSpacing(2)
+	s2, _ := TextSDF2(f, txt, h)
+	if dy := s2.BoundingBox().Size().Y - s.BoundingBox().Size().Y; math.Abs(dy-h) > tolerance {
+		t.Errorf("line spacing: height change %f, want %f", dy, h)
+	}
+	// letter spacing
+	txt = NewText("ABCD")
+	s0, _ := TextSDF2(f, txt, h)
+	txt.SetLetterSpacing(0.25)
+	s1, _ := TextSDF2(f, txt, h)
+	if dx := s1.BoundingBox().Size().X - s0.BoundingBox().Size().X; math.Abs(dx-3*0.25*h) > tolerance {
+		t.Errorf("letter spacing: width change %f, want %f", dx, 3*0.25*h)
+	}
+	// text on an arc stays within a line height of the arc
+	const r = 30.0
+	arc, err := ArcPath(r, Pi, -Pi)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err = TextPathSDF2(f, NewText("ROUND LABEL"), h, arc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bb := s.BoundingBox()
+	for i := 0; i < 10000; i++ {
+		p := bb.Random()
+		if s.Evaluate(p) < 0 {
+			if d := math.Abs(p.Length() - r); d > 0.6*h {
+				t.Fatalf("text is %f from the arc", d)
+			}
+		}
+	}
+	// the path length of a spline
+	sp, err := SplinePath([]v2.Vec{{0, 0}, {10, 0}, {20, 0}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(sp.Length()-20) > tolerance {
+		t.Errorf("spline path length %f, want 20", sp.Length())
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Glyph(t *testing.T) {
+	// cubic roots
+	x := glyphCubic(2, 0, -14, 12) // 2(x-1)(x-2)(x+3)
+	sort.Float64s(x)
+	if len(x) != 3 || math.Abs(x[0]+3) > tolerance || math.Abs(x[1]-1) > tolerance || math.Abs(x[2]-2) > tolerance {
+		t.Errorf("cubic roots %v, want [-3 1 2]", x)
+	}
+	// outline distances match a finely sampled and refined outline
+	var o glyphOutline
+	o.moveTo(v2.Vec{0, 0})
+	o.add(v2.Vec{1, 3}, v2.Vec{4, -1}, v2.Vec{5, 2})
+	o.add(v2.Vec{3, 5}, v2.Vec{0, 2})
+	// a cubic with most of its length near one end
+	o.add(v2.Vec{-1, 1.99}, v2.Vec{-1, 1.98}, v2.Vec{-8, 1})
+	o.add(v2.Vec{-1, 1})
+	s := o.sdf()
+	distance := func(seg *glyphSegment, p v2.Vec) float64 {
+		const n = 1000
+		dt := 1.0 / n
+		tmin := 0.0
+		for j := 0; j <= n; j++ {
+			t := float64(j) * dt
+			if p.Sub(seg.f0(t)).Length() < p.Sub(seg.f0(tmin)).Length() {
+				tmin = t
+			}
+		}
+		// golden section search around the closest sample
+		a, b := math.Max(tmin-dt, 0), math.Min(tmin+dt, 1)
+		for j := 0; j < 60; j++ {
+			t0, t1 := b-0.618*(b-a), a+0.618*(b-a)
+			if p.Sub(seg.f0(t0)).Length() < p.Sub(seg.f0(t1)).Length() {
+				b = t1
+			} else {
+				a = t0
+			}
+		}
+		return p.Sub(seg.f0(0.5 * (a + b))).Length()
+	}
+	bb := s.BoundingBox().ScaleAboutCenter(1.5)
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		p := v2.Vec{bb.Min.X + rnd.Float64()*(bb.Max.X-bb.Min.X), bb.Min.Y + rnd.Float64()*(bb.Max.Y-bb.Min.Y)}
+		d := math.Abs(s.Evaluate(p))
+		dmin := math.MaxFloat64
+		for j := range o.segs {
+			dmin = math.Min(dmin, distance(&o.segs[j], p))
+		}
+		if math.Abs(d-dmin) > 1e-9 {
+			t.Fatalf("distance at %v is %.12f, want %.12f", p, d, dmin)
+		}
+	}
+	// a sharply curved cubic with distance extrema close together in t
+	seg := newGlyphSegment([]v2.Vec{{1.18, 1.18}, {1.18, 2}, {3.08, 4.8}, {4.58, 4.8}})
+	for x := 1.8; x <= 2.3; x += 0.01 {
+		for y := 1.0; y <= 1.3; y += 0.01 {
+			p := v2.Vec{x, y}
+			if d, dmin := math.Sqrt(seg.distance2(p)), distance(&seg, p); math.Abs(d-dmin) > 1e-9 {
+				t.Fatalf("distance at %v is %.12f, want %.12f", p, d, dmin)
+			}
+		}
+	}
+	// contours are filled with the non-zero winding rule
+	o = glyphOutline{}
+	o.moveTo(v2.Vec{0, 0})
+	o.add(v2.Vec{4, 0})
+	o.add(v2.Vec{4, 4})
+	o.add(v2.Vec{0, 4})
+	o.moveTo(v2.Vec{1, 1})
+	o.add(v2.Vec{1, 3})
+	o.add(v2.Vec{3, 3})
+	o.add(v2.Vec{3, 1})
+	s = o.sdf()
+	for _, test := range []struct {
+		p    v2.Vec
+		want float64
+	}{
+		{v2.Vec{0.5, 2}, -0.5},
+		{v2.Vec{2, 2}, 1},
+		{v2.Vec{2, 1}, 0},
+		{v2.Vec{5, 2}, 1},
+	} {
+		if d := s.Evaluate(test.p); math.Abs(d-test.want) > tolerance {
+			t.Errorf("evaluate %v: %f, want %f", test.p, d, test.want)
+		}
+	}
+	// CFF fonts have cubic outlines
+	cff, err := LoadFont("../files/CFFTest.otf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b sfnt.Buffer
+	g, err := cff.glyph(&b, '0')
+	if err != nil {
+		t.Fatal(err)
+	}
+	zero, err := cff.outline(&b, g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cubics := 0
+	for _, seg := range zero.(*glyphSDF2).segs {
+		if seg.n == 3 {
+			cubics++
+		}
+	}
+	if cubics == 0 {
+		t.Error("expected cubic segments in a CFF glyph")
+	}
+	// the zero is a ring
+	if d := zero.Evaluate(zero.BoundingBox().Center()); d <= 0 {
+		t.Errorf("zero: center is inside (%f)", d)
+	}
+	// font fallback
+	ttf, err := LoadFont("../files/cmr10.ttf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := FallbackFont(); err == nil {
+		t.Error("expected an error for an empty font chain")
+	}
+	fb, err := FallbackFont(cff, ttf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Q is in the primary font, A is only in the fallback font
+	if g, _ := fb.glyph(&b, 'Q'); g.face != cff.faces[0] || g.idx == 0 {
+		t.Error("Q should come from the primary font")
+	}
+	g, err = fb.glyph(&b, 'A')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.face != ttf.faces[0] || g.idx == 0 {
+		t.Fatal("A should come from the fallback font")
+	}
+	a0, err := fb.outline(&b, g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, _ = ttf.glyph(&b, 'A')
+	a1, err := ttf.outline(&b, g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the fallback glyph is scaled to the em size of the primary font
+	k := cff.unitsPerEm() / ttf.unitsPerEm()
+	bb0, bb1 := a0.BoundingBox(), a1.BoundingBox()
+	if !bb0.Min.Equals(bb1.Min.MulScalar(k), 1e-6) || !bb0.Max.Equals(bb1.Max.MulScalar(k), 1e-6) {
+		t.Errorf("fallback glyph bounding box %v, want %v scaled by %f", bb0, bb1, k)
+	}
+	// runes missing from all fonts use the missing glyph of the primary font
+	if g, _ := fb.glyph(&b, '\u2603'); g.face != cff.faces[0] || g.idx != 0 {
+		t.Error("expected the missing glyph of the primary font")
+	}
+	// mixed text
+	s0, err := TextSDF2(cff, NewText("Q1"), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s1, err := TextSDF2(fb, NewText("Q1A"), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w0, w1 := s0.BoundingBox().Size().X, s1.BoundingBox().Size().X; w1 <= w0 {
+		t.Errorf("fallback text width %f, want more than %f", w1, w0)
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_SVG(t *testing.T) {
+	svg := `<svg xmlns="http://www.w3.org/2000/svg">
+	<g transform="translate(100,0)">
+		<path fill-rule="evenodd" d="M0 0h40v40H0z M10 10h20v20H10z"/>
+		<path style="fill-rule:nonzero" transform="translate(0,100)" d="m0 0h40v40h-40z m10 10h20v20h-20z"/>
+	</g>
+	<circle cx="20" cy="20" r="10"/>
+	<path d="M0 100a10 10 0 0 0 20 0z"/>
+	<rect width="1000" height="1000" fill="none"/>
+	</svg>`
+	s, err := ParseSVG(strings.NewReader(svg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the y-axis is flipped
+	for _, test := range []struct {
+		p    v2.Vec
+		want float64
+	}{
+		{v2.Vec{105, -20}, -5}, // evenodd outer
+		{v2.Vec{120, -20}, 10}, // evenodd hole
+		{v2.Vec{20, -20}, -10}, // circle center
+		{v2.Vec{35, -20}, 5},   // outside the circle
+		{v2.Vec{10, -105}, -5}, // half circle arc
+	} {
+		if d := s.Evaluate(test.p); math.Abs(d-test.want) > 0.05 {
+			t.Errorf("evaluate %v: %f, want %f", test.p, d, test.want)
+		}
+	}
+	// nonzero fills the inner square
+	if d := s.Evaluate(v2.Vec{120, -120}); d >= 0 {
+		t.Errorf("nonzero: evaluate %v: %f, want < 0", v2.Vec{120, -120}, d)
+	}
+	// the unfilled rectangle is skipped
+	if bb := s.BoundingBox(); !bb.Equals(Box2{v2.Vec{0, -140}, v2.Vec{140, 0}}, 0.05) {
+		t.Errorf("bounding box %v", bb)
+	}
+	// curves, shapes and transforms, the points are in SDF2 coordinates (y flipped)
+	square := `<rect width="10" height="10" transform="%s"/>`
+	for _, test := range []struct {
+		svg     string
+		inside  []v2.Vec
+		outside []v2.Vec
+	}{
+		// the S control point is (40,20) reflected about (40,0)
+		{`<path d="M0 0C0 20 40 20 40 0S80 -20 80 0z"/>`,
+			[]v2.Vec{{20, -12}, {60, 12}}, []v2.Vec{{20, -16}, {60, 16}}},
+		// without a previous curve the S control point is the current point
+		{`<path d="M0 0L40 0S80 -20 80 0z"/>`,
+			[]v2.Vec{{70, 7}}, []v2.Vec{{60, 12}}},
+		// the T control point is (20,20) reflected about (40,0)
+		{`<path d="M0 0Q20 20 40 0T80 0z"/>`,
+			[]v2.Vec{{20, -8}, {60, 8}}, []v2.Vec{{20, -12}, {60, 12}}},
+		{`<path d="m0 0q20 20 40 0t40 0z"/>`,
+			[]v2.Vec{{20, -8}, {60, 8}}, []v2.Vec{{20, -12}, {60, 12}}},
+		// rounded corners, a missing rx is the same as ry
+		{`<rect width="40" height="20" ry="5"/>`,
+			[]v2.Vec{{2, -5}, {5, -2}, {35, -18}}, []v2.Vec{{1, -1}, {39, -19}}},
+		{`<ellipse cx="100" cy="0" rx="20" ry="10"/>`,
+			[]v2.Vec{{119, 0}, {100, -9}, {100, 9}}, []v2.Vec{{121, 0}, {100, -11}, {115, 8}}},
+		{`<polygon points="0,0 40,0 0,40"/>`,
+			[]v2.Vec{{5, -5}, {35, -2}}, []v2.Vec{{25, -25}, {-1, -5}}},
+		// polylines are closed for filling
+		{`<polyline points="0 0 40 0 0 40"/>`,
+			[]v2.Vec{{5, -5}, {35, -2}}, []v2.Vec{{25, -25}, {-1, -5}}},
+		{fmt.Sprintf(square, "matrix(1 0 0 1 50 60)"),
+			[]v2.Vec{{55, -65}}, []v2.Vec{{5, -5}}},
+		{fmt.Sprintf(square, "matrix(0 1 -1 0 0 0)"),
+			[]v2.Vec{{-5, -5}}, []v2.Vec{{5, -5}}},
+		{fmt.Sprintf(square, "rotate(90)"),
+			[]v2.Vec{{-5, -5}}, []v2.Vec{{5, -5}}},
+		{fmt.Sprintf(square, "rotate(90 10 10)"),
+			[]v2.Vec{{15, -5}}, []v2.Vec{{5, -5}}},
+		{fmt.Sprintf(square, "scale(2 3)"),
+			[]v2.Vec{{18, -28}}, []v2.Vec{{22, -5}, {5, -32}}},
+		{fmt.Sprintf(square, "scale(-1)"),
+			[]v2.Vec{{-5, 5}}, []v2.Vec{{5, -5}}},
+		{fmt.Sprintf(square, "skewX(45)"),
+			[]v2.Vec{{14, -9}}, []v2.Vec{{1, -9}}},
+		{fmt.Sprintf(square, "skewY(45)"),
+			[]v2.Vec{{9, -14}}, []v2.Vec{{9, -1}}},
+		// transforms apply right to left
+		{fmt.Sprintf(square, "translate(100) scale(2)"),
+			[]v2.Vec{{118, -18}}, []v2.Vec{{18, -18}}},
+	} {
+		s, err := ParseSVG(strings.NewReader("<svg>" + test.svg + "</svg>"))
+		if err != nil {
+			t.Errorf("%s: %s", test.svg, err)
+			continue
+		}
+		for _, p := range test.inside {
+			if d := s.Evaluate(p); d >= 0 {
+				t.Errorf("%s: evaluate %v: %f, want < 0", test.svg, p, d)
+			}
+		}
+		for _, p := range test.outside {
+			if d := s.Evaluate(p); d <= 0 {
+				t.Errorf("%s: evaluate %v: %f, want > 0", test.svg, p, d)
+			}
+		}
+	}
+	// percentages are relative to the viewBox, or the width and height
+	for _, test := range []struct {
+		svg string
+		bb  Box2
+	}{
+		{`<svg viewBox="0 0 200 100" width="10mm"><rect x="10%" y="10%" width="50%" height="50%"/></svg>`,
+			Box2{v2.Vec{20, -60}, v2.Vec{120, -10}}},
+		{`<svg width="200" height="100"><circle cx="50%" cy="50%" r="10%"/></svg>`,
+			Box2{v2.Vec{100 - 15.811, -65.811}, v2.Vec{100 + 15.811, -34.189}}},
+		{`<svg viewBox="0 0 200 100"><svg width="50%" height="50%"><rect width="100%" height="100%"/></svg></svg>`,
+			Box2{v2.Vec{0, -50}, v2.Vec{100, 0}}},
+	} {
+		s, err := ParseSVG(strings.NewReader(test.svg))
+		if err != nil {
+			t.Errorf("%s: %s", test.svg, err)
+			continue
+		}
+		if bb := s.BoundingBox(); !bb.Equals(test.bb, 0.05) {
+			t.Errorf("%s: bounding box %v, want %v", test.svg, bb, test.bb)
+		}
+	}
+	// errors
+	for _, svg := range []string{
+		`<svg><path d="M0 0 X 10 10"/></svg>`,
+		`<svg><path d="M0 0 L 10"/></svg>`,
+		`<svg><path d="M0 0 L10 10 10 0" transform="spin(1)"/></svg>`,
+		`<svg><rect width="50%" height="10"/></svg>`,
+		`<svg viewBox="0 0 10"><rect width="50%" height="10"/></svg>`,
+		`<svg viewBox="0 0 100 100"><rect width="x%" height="10"/></svg>`,
+		`<svg></svg>`,
+	} {
+		if _, err := ParseSVG(strings.NewReader(svg)); err == nil {
+			t.Errorf("expected an error for %s", svg)
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_DXF(t *testing.T) {
+	// entity returns the group code/value lines for an entity
+	entity := func(name string, pairs ...interface{}) string {
+		s := "0\n" + name + "\n"
+		for i := 0; i < len(pairs); i += 2 {
+			s += fmt.Sprintf("%d\n%v\n", pairs[i], pairs[i+1])
+		}
+		return s
+	}
+	dxf := "0\nSECTION\n2\nHEADER\n0\nENDSEC\n0\nSECTION\n2\nENTITIES\n" +
+		// outer polyline with a semicircular bulge on the right hand side
+		entity("LWPOLYLINE", 90, 4, 70, 1, 10, 0, 20, 0, 10, 100, 20, 0, 42, 1, 10, 100, 20, 50, 10, 0, 20, 50) +
+		// circular hole
+		entity("CIRCLE", 10, 25, 20, 25, 40, 10) +
+		// hole from lines and an arc, drawn in mixed directions
+		entity("LINE", 10, 50, 20, 10, 11, 80, 21, 10) +
+		entity("LINE", 10, 80, 20, 40, 11, 50, 21, 40) +
+		entity("LINE", 10, 50, 20, 10, 11, 50, 21, 40) +
+		entity("ARC", 10, 80, 20, 25, 40, 15, 50, -90, 51, 90) +
+		// island in the hole
+		entity("CIRCLE", 10, 65, 20, 25, 40, 5) +
+		// cubic spline closed with a line
+		entity("SPLINE", 70, 8, 71, 3, 72, 8, 73, 4,
+			40, 0, 40, 0, 40, 0, 40, 0, 40, 1, 40, 1, 40, 1, 40, 1,
+			10, 0, 20, 60, 10, 10, 20, 80, 10, 30, 20, 80, 10, 40, 20, 60) +
+		entity("LINE", 10, 0, 20, 60, 11, 40, 21, 60) +
+		// ignored
+		entity("TEXT", 10, 0, 20, 0, 1, "label") +
+		// open outlines are dropped: a stray line and a chain with a gap
+		entity("LINE", 10, 200, 20, 0, 11, 200, 21, 50) +
+		entity("LINE", 10, 150, 20, 0, 11, 180, 21, 0) +
+		entity("LINE", 10, 180, 20, 0, 11, 180, 21, 30) +
+		"0\nENDSEC\n0\nEOF\n"
+	s, err := ParseDXF(strings.NewReader(dxf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		p    v2.Vec
+		want float64
+	}{
+		{v2.Vec{5, 25}, -5},     // solid
+		{v2.Vec{25, 25}, 10},    // circular hole
+		{v2.Vec{90, 25}, 5},     // hole with an arc
+		{v2.Vec{65, 25}, -5},    // island
+		{v2.Vec{120, 25}, -5},   // bulge
+		{v2.Vec{130, 25}, 5},    // outside the bulge
+		{v2.Vec{20, 75}, 0},     // on the spline
+		{v2.Vec{175, 5}, 52.62}, // the open chain is dropped (distance to the bulge)
+	} {
+		if d := s.Evaluate(test.p); math.Abs(d-test.want) > 0.02 {
+			t.Errorf("evaluate %v: %f, want %f", test.p, d, test.want)
+		}
+	}
+	// a drawing with only open outlines is an error
+	open := "0\nSECTION\n2\nENTITIES\n" + entity("LINE", 10, 0, 20, 0, 11, 1, 21, 0) + "0\nENDSEC\n0\nEOF\n"
+	if _, err := ParseDXF(strings.NewReader(open)); err == nil {
+		t.Error("expected an error for no closed outlines")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_MultiPolygon(t *testing.T) {
+	// a single contour matches Polygon2D
+	var star []v2.Vec
+	n := 2000
+	for i := 0; i < n; i++ {
+		a := Tau * float64(i) / float64(n)
+		star = append(star, v2.Vec{math.Cos(a), math.Sin(a)}.MulScalar(randomRange(50, 100)))
+	}
+	var comb []v2.Vec
+	for i := 0; i < 20; i++ {
+		x := float64(i)
+		comb = append(comb, v2.Vec{x + 1, 0}, v2.Vec{x + 1, 10}, v2.Vec{x + 0.5, 10}, v2.Vec{x + 0.5, 0})
+	}
+	comb = append(comb, v2.Vec{0, -5}, v2.Vec{20, -5})
+	for _, v := range [][]v2.Vec{star, comb} {
+		s0, err := Polygon2D(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s1, err := MultiPolygon2D([][]v2.Vec{v}, FillNonZero)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bb := s0.BoundingBox().ScaleAboutCenter(1.5)
+		for i := 0; i < 2000; i++ {
+			p := bb.Random()
+			if i%2 == 0 {
+				// points on the grid of the vertices
+				p = v2.Vec{math.Round(2*p.X) / 2, math.Round(2*p.Y) / 2}
+			}
+			if d0, d1 := s0.Evaluate(p), s1.Evaluate(p); math.Abs(d0-d1) > tolerance {
+				t.Fatalf("evaluate %v: %f, want %f", p, d1, d0)
+			}
+		}
+	}
+	// nested contours with fill rules
+	square := func(x0, y0, x1, y1 float64) []v2.Vec {
+		return []v2.Vec{{x0, y0}, {x1, y0}, {x1, y1}, {x0, y1}}
+	}
+	reverse := func(v []v2.Vec) []v2.Vec {
+		r := make([]v2.Vec, len(v))
+		for i := range v {
+			r[len(v)-1-i] = v[i]
+		}
+		return r
+	}
+	for _, test := range []struct {
+		contours [][]v2.Vec
+		rule     FillRule
+		p        v2.Vec
+		want     float64
+	}{
+		{[][]v2.Vec{square(0, 0, 10, 10), square(2, 2, 8, 8)}, FillNonZero, v2.Vec{5, 5}, -3},
+		{[][]v2.Vec{square(0, 0, 10, 10), square(2, 2, 8, 8)}, FillEvenOdd, v2.Vec{5, 5}, 3},
+		{[][]v2.Vec{square(0, 0, 10, 10), reverse(square(2, 2, 8, 8))}, FillNonZero, v2.Vec{5, 5}, 3},
+		{[][]v2.Vec{square(0, 0, 10, 10), reverse(square(2, 2, 8, 8))}, FillNonZero, v2.Vec{1, 5}, -1},
+		{[][]v2.Vec{square(0, 0, 10, 10), square(2, 2, 8, 8), square(4, 4, 6, 6)}, FillEvenOdd, v2.Vec{5, 5}, -1},
+		{[][]v2.Vec{square(0, 0, 10, 10), square(20, 0, 30, 10)}, FillEvenOdd, v2.Vec{15, 5}, 5},
+	} {
+		s, err := MultiPolygon2D(test.contours, test.rule)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d := s.Evaluate(test.p); math.Abs(d-test.want) > tolerance {
+			t.Errorf("evaluate %v: %f, want %f", test.p, d, test.want)
+		}
+	}
+	// contours need at least 3 vertices
+	if _, err := MultiPolygon2D([][]v2.Vec{square(0, 0, 1, 1), {{0, 0}, {1, 1}}}, FillNonZero); err == nil {
+		t.Error("expected an error for a contour with 2 vertices")
+	}
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,753 @@
+//-----------------------------------------------------------------------------
+/*
+
+SVG Import
+
+Convert the filled shapes in an SVG file into an SDF2.
+
+Supported elements are path, rect, circle, ellipse, polygon and polyline,
+grouped with g elements and positioned with transform attributes. Paths
+support all the path commands (M, L, H, V, C, S, Q, T, A, Z). Curves are
+converted to polygons with the Bezier code. The fill-rule (nonzero, evenodd)
+is honoured for each shape and shapes with fill="none" are skipped.
+
+Coordinates are SVG user units with the y-axis flipped so the image is the
+right way up. The viewBox (or width/height) of an svg element is only used to
+resolve percentage lengths, it doesn't scale the drawing.
+
+See: https://www.w3.org/TR/SVG11/paths.html
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	v2 "github.com/gmlewis/sdfx/vec/v2"
+)
+
+//-----------------------------------------------------------------------------
+// Path Data Scanner
+
+// svgScanner reads commands and numbers from SVG path data and attribute lists.
+type svgScanner struct {
+	s string // data
+	i int    // current position
+}
+
+// skip skips whitespace and commas.
+func (s *svgScanner) skip() {
+	for s.i < len(s.s) && strings.IndexByte(" \t\r\n,", s.s[s.i]) >= 0 {
+		s.i++
+	}
+}
+
+// done returns true if there is no more data.
+func (s *svgScanner) done() bool {
+	s.skip()
+	return s.i == len(s.s)
+}
+
+// isNumber returns true if the next token is a number.
+func (s *svgScanner) isNumber() bool {
+	if s.done() {
+		return false
+	}
+	return strings.IndexByte("+-.0123456789", s.s[s.i]) >= 0
+}
+
+// command returns the next path command.
+func (s *svgScanner) command() (byte, error) {
+	if s.done() {
+		return 0, io.EOF
+	}
+	c := s.s[s.i]
+	if strings.IndexByte("MmLlHhVvCcSsQqTtAaZz", c) < 0 {
+		return 0, fmt.Errorf("bad path command '%c'", c)
+	}
+	s.i++
+	return c, nil
+}
+
+// number returns the next number.
+func (s *svgScanner) number() (float64, error) {
+	s.skip()
+	j := s.i
+	if j < len(s.s) && (s.s[j] == '+' || s.s[j] == '-') {
+		j++
+	}
+	digits := func() {
+		for j < len(s.s) && s.s[j] >= '0' && s.s[j] <= '9' {
+			j++
+		}
+	}
+	digits()
+	if j < len(s.s) && s.s[j] == '.' {
+		j++
+		digits()
+	}
+	if j < len(s.s) && (s.s[j] == 'e' || s.s[j] == 'E') {
+		k := j + 1
+		if k < len(s.s) && (s.s[k] == '+' || s.s[k] == '-') {
+			k++
+		}
+		if k < len(s.s) && s.s[k] >= '0' && s.s[k] <= '9' {
+			j = k
+			digits()
+		}
+	}
+	x, err := strconv.ParseFloat(s.s[s.i:j], 64)
+	if err != nil {
+		return 0, fmt.Errorf("bad number at \"%s\"", s.s[s.i:])
+	}
+	s.i = j
+	return x, nil
+}
+
+// numbers returns the next n numbers.
+func (s *svgScanner) numbers(n int) ([]float64, error) {
+	x := make([]float64, n)
+	for i := range x {
+		var err error
+		x[i], err = s.number()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return x, nil
+}
+
+// flag returns the next arc flag, flags need not be separated from the next number.
+func (s *svgScanner) flag() (bool, error) {
+	s.skip()
+	if s.i < len(s.s) {
+		switch s.s[s.i] {
+		case '0':
+			s.i++
+			return false, nil
+		case '1':
+			s.i++
+			return true, nil
+		}
+	}
+	return false, ErrMsg("bad arc flag")
+}
+
+//-----------------------------------------------------------------------------
+// Path Building
+
+// svgPath builds the contours of a shape.
+type svgPath struct {
+	m        M33        // user units to SDF2 coordinates
+	contours [][]v2.Vec // closed contours
+	b        *Bezier    // current subpath
+	start    v2.Vec     // start of the current subpath (user units)
+	cur      v2.Vec     // current point (user units)
+	ctrl     v2.Vec     // last control point, for smooth curves (user units)
+}
+
+// moveTo starts a new subpath.
+func (p *svgPath) moveTo(v v2.Vec) error {
+	if err := p.end(); err != nil {
+		return err
+	}
+	p.cur, p.ctrl = v, v
+	p.begin()
+	return nil
+}
+
+// begin starts a subpath at the current point if there isn't one.
+func (p *svgPath) begin() {
+	if p.b == nil {
+		p.b = NewBezier()
+		p.b.AddV2(p.m.MulPosition(p.cur))
+		p.start = p.cur
+	}
+}
+
+// lineTo adds a line to the subpath.
+func (p *svgPath) lineTo(v v2.Vec) {
+	p.begin()
+	p.b.AddV2(p.m.MulPosition(v))
+	p.cur, p.ctrl = v, v
+}
+
+// quadTo adds a quadratic bezier curve to the subpath.
+func (p *svgPath) quadTo(c, v v2.Vec) {
+	p.begin()
+	p.b.AddV2(p.m.MulPosition(c)).Mid()
+	p.b.AddV2(p.m.MulPosition(v))
+	p.cur, p.ctrl = v, c
+}
+
+// cubeTo adds a cubic bezier curve to the subpath.
+func (p *svgPath) cubeTo(c0, c1, v v2.Vec) {
+	p.begin()
+	p.b.AddV2(p.m.MulPosition(c0)).Mid()
+	p.b.AddV2(p.m.MulPosition(c1)).Mid()
+	p.b.AddV2(p.m.MulPosition(v))
+	p.cur, p.ctrl = v, c1
+}
+
+// arcTo adds an elliptical arc to the subpath.
+// See: https://www.w3.org/TR/SVG11/implnote.html#ArcImplementationNotes
+func (p *svgPath) arcTo(
+	r v2.Vec, // x/y radii
+	phi float64, // x-axis rotation (degrees)
+	large bool, // large arc flag
+	sweep bool, // sweep flag
+	v v2.Vec, // end point
+) {
+	p0 := p.cur
+	if p0.Equals(v, 0) {
+		return
+	}
+	r = r.Abs()
+	if r.X == 0 || r.Y == 0 {
+		p.lineTo(v)
+		return
+	}
+	sinPhi, cosPhi := math.Sincos(DtoR(phi))
+	rot := func(a v2.Vec, s float64) v2.Vec {
+		return v2.Vec{cosPhi*a.X - s*sinPhi*a.Y, s*sinPhi*a.X + cosPhi*a.Y}
+	}
+	// end points in the frame of the ellipse
+	d := rot(p0.Sub(v).MulScalar(0.5), -1)
+	// scale up radii that are too small
+	if k := (d.X*d.X)/(r.X*r.X) + (d.Y*d.Y)/(r.Y*r.Y); k > 1 {
+		r = r.MulScalar(math.Sqrt(k))
+	}
+	// center
+	num := r.X*r.X*r.Y*r.Y - r.X*r.X*d.Y*d.Y - r.Y*r.Y*d.X*d.X
+	den := r.X*r.X*d.Y*d.Y + r.Y*r.Y*d.X*d.X
+	k := math.Sqrt(math.Max(num/den, 0))
+	if large == sweep {
+		k = -k
+	}
+	c := v2.Vec{k * r.X * d.Y / r.Y, -k * r.Y * d.X / r.X}
+	center := rot(c, 1).Add(p0.Add(v).MulScalar(0.5))
+	// start and sweep angles
+	angle := func(a v2.Vec) float64 { return math.Atan2(a.Y, a.X) }
+	theta0 := angle(v2.Vec{(d.X - c.X) / r.X, (d.Y - c.Y) / r.Y})
+	theta1 := angle(v2.Vec{(-d.X - c.X) / r.X, (-d.Y - c.Y) / r.Y})
+	dtheta := theta1 - theta0
+	if sweep && dtheta < 0 {
+		dtheta += Tau
+	} else if !sweep && dtheta > 0 {
+		dtheta -= Tau
+	}
+	// approximate the arc with cubic bezier curves of <= 90 degrees
+	n := int(math.Ceil(math.Abs(dtheta) / (0.5 * Pi)))
+	da := dtheta / float64(n)
+	h := 4.0 / 3.0 * math.Tan(da/4)
+	point := func(a float64) (v2.Vec, v2.Vec) {
+		s, c := math.Sincos(a)
+		pos := rot(v2.Vec{r.X * c, r.Y * s}, 1).Add(center)
+		tangent := rot(v2.Vec{-r.X * s, r.Y * c}, 1)
+		return pos, tangent
+	}
+	a := theta0
+	q0, t0 := point(a)
+	for i := 0; i < n; i++ {
+		a += da
+		q1, t1 := point(a)
+		if i == n-1 {
+			// use the exact end point
+			q1 = v
+		}
+		p.cubeTo(q0.Add(t0.MulScalar(h)), q1.Sub(t1.MulScalar(h)), q1)
+		q0, t0 = q1, t1
+	}
+}
+
+// close closes the current subpath.
+func (p *svgPath) close() error {
+	if err := p.end(); err != nil {
+		return err
+	}
+	p.cur, p.ctrl = p.start, p.start
+	return nil
+}
+
+// end finishes the current subpath, subpaths are closed for filling.
+func (p *svgPath) end() error {
+	b := p.b
+	p.b = nil
+	if b == nil || len(b.vlist) < 3 {
+		// no area
+		return nil
+	}
+	b.Close()
+	poly, err := b.Polygon()
+	if err != nil {
+		return err
+	}
+	if v := poly.Vertices(); len(v) >= 3 {
+		p.contours = append(p.contours, v)
+	}
+	return nil
+}
+
+// svgArgs is the number of arguments for each path command.
+var svgArgs = map[byte]int{'M': 2, 'L': 2, 'H': 1, 'V': 1, 'C': 6, 'S': 4, 'Q': 4, 'T': 2, 'A': 7, 'Z': 0}
+
+// svgPathData adds the contours for SVG path data.
+func (p *svgPath) svgPathData(d string) error {
+	s := &svgScanner{s: d}
+	var cmd, prev byte
+	for !s.done() {
+		if !s.isNumber() {
+			var err error
+			cmd, err = s.command()
+			if err != nil {
+				return err
+			}
+		} else if cmd == 0 || cmd == 'Z' || cmd == 'z' {
+			return ErrMsg("path data without a command")
+		}
+		// relative commands are offset by the current point
+		ofs := v2.Vec{}
+		if cmd >= 'a' && cmd <= 'z' {
+			ofs = p.cur
+		}
+		x := make([]float64, svgArgs[cmd&^0x20])
+		for i := range x {
+			var err error
+			if (cmd == 'A' || cmd == 'a') && (i == 3 || i == 4) {
+				var f bool
+				f, err = s.flag()
+				if f {
+					x[i] = 1
+				}
+			} else {
+				x[i], err = s.number()
+			}
+			if err != nil {
+				return err
+			}
+		}
+		// smooth curves reflect the control point of the previous curve of the same kind
+		ctrl := p.cur
+		if (strings.IndexByte("CcSs", prev) >= 0 && (cmd == 'S' || cmd == 's')) ||
+			(strings.IndexByte("QqTt", prev) >= 0 && (cmd == 'T' || cmd == 't')) {
+			ctrl = p.cur.MulScalar(2).Sub(p.ctrl)
+		}
+		prev = cmd
+		switch cmd {
+		case 'M', 'm':
+			if err := p.moveTo(v2.Vec{x[0], x[1]}.Add(ofs)); err != nil {
+				return err
+			}
+			// subsequent pairs are lines
+			if cmd == 'M' {
+				cmd = 'L'
+			} else {
+				cmd = 'l'
+			}
+		case 'L', 'l':
+			p.lineTo(v2.Vec{x[0], x[1]}.Add(ofs))
+		case 'H', 'h':
+			p.lineTo(v2.Vec{x[0] + ofs.X, p.cur.Y})
+		case 'V', 'v':
+			p.lineTo(v2.Vec{p.cur.X, x[0] + ofs.Y})
+		case 'C', 'c':
+			p.cubeTo(v2.Vec{x[0], x[1]}.Add(ofs), v2.Vec{x[2], x[3]}.Add(ofs), v2.Vec{x[4], x[5]}.Add(ofs))
+		case 'S', 's':
+			p.cubeTo(ctrl, v2.Vec{x[0], x[1]}.Add(ofs), v2.Vec{x[2], x[3]}.Add(ofs))
+		case 'Q', 'q':
+			p.quadTo(v2.Vec{x[0], x[1]}.Add(ofs), v2.Vec{x[2], x[3]}.Add(ofs))
+		case 'T', 't':
+			p.quadTo(ctrl, v2.Vec{x[0], x[1]}.Add(ofs))
+		case 'A', 'a':
+			p.arcTo(v2.Vec{x[0], x[1]}, x[2], x[3] != 0, x[4] != 0, v2.Vec{x[5], x[6]}.Add(ofs))
+		case 'Z', 'z':
+			if err := p.close(); err != nil {
+				return err
+			}
+		}
+	}
+	return p.end()
+}
+
+//-----------------------------------------------------------------------------
+// Attributes
+
+// svgLength converts a length to user units.
+// Percentages are relative to the reference length, 0 if there is no viewport.
+func svgLength(s string, ref float64) (float64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	if strings.HasSuffix(s, "%") {
+		x, err := strconv.ParseFloat(strings.TrimSpace(s[:len(s)-1]), 64)
+		if err != nil {
+			return 0, fmt.Errorf("bad length \"%s\"", s)
+		}
+		if ref <= 0 {
+			return 0, fmt.Errorf("percentage length \"%s\" without a viewBox", s)
+		}
+		return 0.01 * x * ref, nil
+	}
+	units := map[string]float64{
+		"px": 1,
+		"pt": 96.0 / 72.0,
+		"pc": 16,
+		"mm": 96.0 / 25.4,
+		"cm": 96.0 / 2.54,
+		"in": 96,
+	}
+	k := 1.0
+	if len(s) > 2 {
+		if u, ok := units[s[len(s)-2:]]; ok {
+			k = u
+			s = s[:len(s)-2]
+		}
+	}
+	x, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return 0, fmt.Errorf("bad length \"%s\"", s)
+	}
+	return k * x, nil
+}
+
+// svgTransform returns the matrix for a transform attribute.
+func svgTransform(s string) (M33, error) {
+	m := Identity2d()
+	for {
+		s = strings.TrimLeft(s, " \t\r\n,")
+		if s == "" {
+			return m, nil
+		}
+		i := strings.IndexByte(s, '(')
+		j := strings.IndexByte(s, ')')
+		if i < 0 || j < i {
+			return m, fmt.Errorf("bad transform \"%s\"", s)
+		}
+		name := strings.TrimSpace(s[:i])
+		sc := &svgScanner{s: s[i+1 : j]}
+		var x []float64
+		for !sc.done() {
+			v, err := sc.number()
+			if err != nil {
+				return m, err
+			}
+			x = append(x, v)
+		}
+		s = s[j+1:]
+		var t M33
+		switch {
+		case name == "matrix" && len(x) == 6:
+			t = M33{x[0], x[2], x[4], x[1], x[3], x[5], 0, 0, 1}
+		case name == "translate" && len(x) == 1:
+			t = Translate2d(v2.Vec{x[0], 0})
+		case name == "translate" && len(x) == 2:
+			t = Translate2d(v2.Vec{x[0], x[1]})
+		case name == "scale" && len(x) == 1:
+			t = Scale2d(v2.Vec{x[0], x[0]})
+		case name == "scale" && len(x) == 2:
+			t = Scale2d(v2.Vec{x[0], x[1]})
+		case name == "rotate" && len(x) == 1:
+			t = Rotate2d(DtoR(x[0]))
+		case name == "rotate" && len(x) == 3:
+			c := v2.Vec{x[1], x[2]}
+			t = Translate2d(c).Mul(Rotate2d(DtoR(x[0]))).Mul(Translate2d(c.Neg()))
+		case name == "skewX" && len(x) == 1:
+			t = M33{1, math.Tan(DtoR(x[0])), 0, 0, 1, 0, 0, 0, 1}
+		case name == "skewY" && len(x) == 1:
+			t = M33{1, 0, 0, math.Tan(DtoR(x[0])), 1, 0, 0, 0, 1}
+		default:
+			return m, fmt.Errorf("bad transform \"%s\"", name)
+		}
+		m = m.Mul(t)
+	}
+}
+
+// svgStyle is the inherited state for an SVG element.
+type svgStyle struct {
+	m    M33      // user units to SDF2 coordinates
+	view v2.Vec   // viewport size for percentage lengths (user units)
+	rule FillRule // fill rule
+	fill bool     // the element is filled
+}
+
+// svgViewport returns the viewport size set by an svg element.
+// The viewBox takes precedence over the width and height.
+func svgViewport(parent v2.Vec, attr []xml.Attr) (v2.Vec, error) {
+	if vb := svgAttrString(attr, "viewBox"); vb != "" {
+		x, err := (&svgScanner{s: vb}).numbers(4)
+		if err != nil || x[2] <= 0 || x[3] <= 0 {
+			return parent, fmt.Errorf("bad viewBox \"%s\"", vb)
+		}
+		return v2.Vec{x[2], x[3]}, nil
+	}
+	x, err := svgAttr(attr, parent, "width", "height")
+	if err != nil {
+		return parent, err
+	}
+	view := parent
+	if x[0] > 0 {
+		view.X = x[0]
+	}
+	if x[1] > 0 {
+		view.Y = x[1]
+	}
+	return view, nil
+}
+
+// svgElement returns the style for an element given the style of its parent.
+func svgElement(parent svgStyle, name string, attr []xml.Attr) (svgStyle, error) {
+	s := parent
+	if name == "svg" {
+		view, err := svgViewport(parent.view, attr)
+		if err != nil {
+			return s, err
+		}
+		s.view = view
+	}
+	props := map[string]string{}
+	for _, a := range attr {
+		props[a.Name.Local] = a.Value
+	}
+	// style properties override attributes
+	for _, p := range strings.Split(props["style"], ";") {
+		if kv := strings.SplitN(p, ":", 2); len(kv) == 2 {
+			props[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+		}
+	}
+	if t, ok := props["transform"]; ok {
+		m, err := svgTransform(t)
+		if err != nil {
+			return s, err
+		}
+		s.m = s.m.Mul(m)
+	}
+	switch props["fill-rule"] {
+	case "evenodd":
+		s.rule = FillEvenOdd
+	case "nonzero":
+		s.rule = FillNonZero
+	}
+	if f, ok := props["fill"]; ok {
+		s.fill = f != "none"
+	}
+	return s, nil
+}
+
+// svgReference returns the reference length for a percentage value of an attribute.
+func svgReference(name string, view v2.Vec) float64 {
+	switch name {
+	case "x", "cx", "width", "rx":
+		return view.X
+	case "y", "cy", "height", "ry":
+		return view.Y
+	}
+	// other lengths are relative to the normalized diagonal
+	return view.Length() / math.Sqrt2
+}
+
+// svgAttr returns the named attributes as lengths.
+func svgAttr(attr []xml.Attr, view v2.Vec, names ...string) ([]float64, error) {
+	x := make([]float64, len(names))
+	for i, name := range names {
+		for _, a := range attr {
+			if a.Name.Local == name {
+				var err error
+				x[i], err = svgLength(a.Value, svgReference(name, view))
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return x, nil
+}
+
+// svgAttrString returns the named attribute.
+func svgAttrString(attr []xml.Attr, name string) string {
+	for _, a := range attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+//-----------------------------------------------------------------------------
+// Shapes
+
+// svgShape returns the contours for a shape element.
+// Percentage lengths are relative to the viewport size.
+func svgShape(p *svgPath, view v2.Vec, name string, attr []xml.Attr) error {
+	switch name {
+	case "path":
+		return p.svgPathData(svgAttrString(attr, "d"))
+	case "rect":
+		x, err := svgAttr(attr, view, "x", "y", "width", "height", "rx", "ry")
+		if err != nil {
+			return err
+		}
+		w, h, rx, ry := x[2], x[3], x[4], x[5]
+		if w <= 0 || h <= 0 {
+			return nil
+		}
+		// a missing radius is the same as the other one
+		if rx == 0 {
+			rx = ry
+		}
+		if ry == 0 {
+			ry = rx
+		}
+		rx = math.Min(rx, 0.5*w)
+		ry = math.Min(ry, 0.5*h)
+		x0, y0, x1, y1 := x[0], x[1], x[0]+w, x[1]+h
+		if rx == 0 {
+			poly := NewPolygon()
+			for _, v := range []v2.Vec{{x0, y0}, {x1, y0}, {x1, y1}, {x0, y1}} {
+				poly.AddV2(p.m.MulPosition(v))
+			}
+			p.contours = append(p.contours, poly.Vertices())
+			return nil
+		}
+		r := v2.Vec{rx, ry}
+		if err := p.moveTo(v2.Vec{x0 + rx, y0}); err != nil {
+			return err
+		}
+		p.lineTo(v2.Vec{x1 - rx, y0})
+		p.arcTo(r, 0, false, true, v2.Vec{x1, y0 + ry})
+		p.lineTo(v2.Vec{x1, y1 - ry})
+		p.arcTo(r, 0, false, true, v2.Vec{x1 - rx, y1})
+		p.lineTo(v2.Vec{x0 + rx, y1})
+		p.arcTo(r, 0, false, true, v2.Vec{x0, y1 - ry})
+		p.lineTo(v2.Vec{x0, y0 + ry})
+		p.arcTo(r, 0, false, true, v2.Vec{x0 + rx, y0})
+		return p.close()
+	case "circle", "ellipse":
+		var x []float64
+		var err error
+		if name == "circle" {
+			x, err = svgAttr(attr, view, "cx", "cy", "r")
+			if err == nil {
+				x = append(x, x[2])
+			}
+		} else {
+			x, err = svgAttr(attr, view, "cx", "cy", "rx", "ry")
+		}
+		if err != nil {
+			return err
+		}
+		c, r := v2.Vec{x[0], x[1]}, v2.Vec{x[2], x[3]}
+		if r.X <= 0 || r.Y <= 0 {
+			return nil
+		}
+		if err := p.moveTo(v2.Vec{c.X + r.X, c.Y}); err != nil {
+			return err
+		}
+		p.arcTo(r, 0, false, true, v2.Vec{c.X - r.X, c.Y})
+		p.arcTo(r, 0, false, true, v2.Vec{c.X + r.X, c.Y})
+		return p.close()
+	case "polygon", "polyline":
+		s := &svgScanner{s: svgAttrString(attr, "points")}
+		poly := NewPolygon()
+		for !s.done() {
+			x, err := s.numbers(2)
+			if err != nil {
+				return err
+			}
+			poly.AddV2(p.m.MulPosition(v2.Vec{x[0], x[1]}))
+		}
+		if v := poly.Vertices(); len(v) >= 3 {
+			p.contours = append(p.contours, v)
+		}
+		return nil
+	}
+	return nil
+}
+
+//-----------------------------------------------------------------------------
+
+// svgSkip are elements whose content is not drawn directly.
+var svgSkip = map[string]bool{
+	"defs":     true,
+	"clipPath": true,
+	"mask":     true,
+	"marker":   true,
+	"pattern":  true,
+	"symbol":   true,
+	"metadata": true,
+}
+
+// ParseSVG returns an SDF2 for the filled shapes in SVG data.
+func ParseSVG(r io.Reader) (SDF2, error) {
+	d := xml.NewDecoder(r)
+	// flip the y-axis
+	stack := []svgStyle{{m: Scale2d(v2.Vec{1, -1}), rule: FillNonZero, fill: true}}
+	skip := 0
+	var ss []SDF2
+	for {
+		t, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch e := t.(type) {
+		case xml.StartElement:
+			if skip > 0 || svgSkip[e.Name.Local] {
+				skip++
+				continue
+			}
+			s, err := svgElement(stack[len(stack)-1], e.Name.Local, e.Attr)
+			if err != nil {
+				return nil, err
+			}
+			stack = append(stack, s)
+			if !s.fill {
+				continue
+			}
+			p := svgPath{m: s.m}
+			if err := svgShape(&p, s.view, e.Name.Local, e.Attr); err != nil {
+				return nil, fmt.Errorf("%s: %s", e.Name.Local, err)
+			}
+			if len(p.contours) != 0 {
+				c, err := MultiPolygon2D(p.contours, s.rule)
+				if err != nil {
+					return nil, err
+				}
+				ss = append(ss, c)
+			}
+		case xml.EndElement:
+			if skip > 0 {
+				skip--
+				continue
+			}
+			stack = stack[:len(stack)-1]
+		}
+	}
+	s := Union2D(ss...)
+	if s == nil {
+		return nil, ErrMsg("no filled shapes in SVG")
+	}
+	return s, nil
+}
+
+// LoadSVG returns an SDF2 for the filled shapes in an SVG file.
+func LoadSVG(fname string) (SDF2, error) {
+	f, err := os.Open(fname)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseSVG(f)
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,474 @@
+//-----------------------------------------------------------------------------
+/*
+
+Sweep a 2D profile along a 3D path.
+
+The path is a polyline. Smooth paths (cubic splines, bezier curves) are sampled
+into polylines before sweeping.
+
+Each path segment is a straight extrusion of the profile with its own coordinate
+frame (tangent, normal, binormal). The profile x-axis maps to the frame normal and
+the profile y-axis maps to the frame binormal. For a straight path along +z this
+is the same as Extrude3D.
+
+Adjacent extrusions are clipped by the mitre plane that bisects the angle between
+their segments. The sweep is the union of the clipped extrusions. Each piece is an
+intersection of distance bounds, so the sweep distance is a conservative bound
+for any profile and path curvature. The intersection of adjacent extrusions is
+added to the union so the interior distance is not cut short at the joints.
+Interior distances are exact within a segment length of the surface and
+conservative further in.
+
+The mitre at a sharp turn reaches far beyond the path, so turns with a mitre
+longer than sweepMitreLimit times the profile radius have a round join instead.
+The extrusions are clipped square at the vertex and the gap on the outside of the
+turn is filled with the profile revolved about the normal to the plane of the turn.
+The interior distance is conservative near a round join.
+
+With a rotation minimizing frame the frame of each segment is the reflection of
+the previous frame in the mitre plane, so the profile sections of adjacent pieces
+match exactly on the mitre plane.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"math"
+
+	v2 "github.com/gmlewis/sdfx/vec/v2"
+	v3 "github.com/gmlewis/sdfx/vec/v3"
+)
+
+//-----------------------------------------------------------------------------
+
+// SweepFrame selects how the profile is oriented as it moves along the path.
+type SweepFrame int
+
+const (
+	// FrenetFrame aligns the profile with the curvature of the path.
+	// The frame flips at inflection points and is undefined on straight sections.
+	FrenetFrame SweepFrame = iota
+	// RotationMinimizingFrame minimizes the twist of the profile about the path.
+	RotationMinimizingFrame
+)
+
+//-----------------------------------------------------------------------------
+// Sweep Paths
+
+// BezierPath returns a polyline sampled from a chain of cubic bezier curves.
+// The control points are: end, control, control, end, control, control, end...
+// Each bezier curve is sampled with n line segments.
+func BezierPath(ctrl []v3.Vec, n int) (v3.VecSet, error) {
+	if len(ctrl) < 4 || (len(ctrl)-1)%3 != 0 {
+		return nil, ErrMsg("bad number of bezier control points")
+	}
+	if n < 1 {
+		return nil, ErrMsg("n < 1")
+	}
+	path := v3.VecSet{ctrl[0]}
+	for i := 0; i < len(ctrl)-1; i += 3 {
+		var px, py, pz BezierPolynomial
+		px.Set([]float64{ctrl[i].X, ctrl[i+1].X, ctrl[i+2].X, ctrl[i+3].X})
+		py.Set([]float64{ctrl[i].Y, ctrl[i+1].Y, ctrl[i+2].Y, ctrl[i+3].Y})
+		pz.Set([]float64{ctrl[i].Z, ctrl[i+1].Z, ctrl[i+2].Z, ctrl[i+3].Z})
+		for j := 1; j <= n; j++ {
+			t := float64(j) / float64(n)
+			path = append(path, v3.Vec{px.f0(t), py.f0(t), pz.f0(t)})
+		}
+	}
+	return path, nil
+}
+
+// CubicSplinePath returns a polyline sampled from a natural cubic spline through the knots.
+// Each spline segment is sampled with n line segments.
+func CubicSplinePath(knot []v3.Vec, n int) (v3.VecSet, error) {
+	if len(knot) < 2 {
+		return nil, ErrMsg("cubic splines need at least 2 knots")
+	}
+	if n < 1 {
+		return nil, ErrMsg("n < 1")
+	}
+	// Build and solve the tridiagonal matrices (see CubicSpline2D)
+	k := len(knot)
+	m := make([]v3.Vec, k)
+	d := make([][]float64, 3)
+	for j := range d {
+		d[j] = make([]float64, k)
+	}
+	for i := 1; i < k-1; i++ {
+		m[i] = v3.Vec{1, 4, 1}
+		for j := range d {
+			d[j][i] = 3 * (knot[i+1].Get(j) - knot[i-1].Get(j))
+		}
+	}
+	m[0] = v3.Vec{0, 2, 1}
+	m[k-1] = v3.Vec{1, 2, 0}
+	for j := range d {
+		d[j][0] = 3 * (knot[1].Get(j) - knot[0].Get(j))
+		d[j][k-1] = 3 * (knot[k-1].Get(j) - knot[k-2].Get(j))
+	}
+	x := make([][]float64, 3)
+	for j := range d {
+		var err error
+		x[j], err = triDiagonal(m, d[j])
+		if err != nil {
+			return nil, err
+		}
+	}
+	// sample the cubic polynomials
+	path := v3.VecSet{knot[0]}
+	for i := 0; i < k-1; i++ {
+		var p [3]CubicPolynomial
+		for j := range p {
+			p[j].Set(knot[i].Get(j), knot[i+1].Get(j), x[j][i], x[j][i+1])
+		}
+		for s := 1; s <= n; s++ {
+			t := float64(s) / float64(n)
+			path = append(path, v3.Vec{p[0].f0(t), p[1].f0(t), p[2].f0(t)})
+		}
+	}
+	return path, nil
+}
+
+//-----------------------------------------------------------------------------
+
+// sweepMitreLimit is the longest mitre as a multiple of the profile radius.
+// Sharper turns have a round join.
+const sweepMitreLimit = 2.0
+
+// sweepPath is a polyline with a coordinate frame for each segment.
+type sweepPath struct {
+	vertex  []v3.Vec     // path vertices
+	vector  []v3.Vec     // unit segment vectors
+	length  []float64    // segment lengths
+	tangent []v3.Vec     // mitre plane normal at each vertex
+	normal  []v3.Vec     // frame normal for each segment
+	start   []v3.Vec     // clip plane normal at the start of each segment
+	end     []v3.Vec     // clip plane normal at the end of each segment
+	join    []*sweepJoin // round join at each vertex (nil for a mitre)
+	total   float64      // total path length
+}
+
+// sweepJoin is a round join between two path segments.
+// It is the profile revolved about the normal to the plane of the turn,
+// through the outside of the turn between the segment end planes.
+type sweepJoin struct {
+	u0, u1 v3.Vec // unit vectors of the incoming and outgoing segments
+	w      v3.Vec // unit normal to the plane of the turn
+	o, z   v2.Vec // the outward turn direction and w in profile coordinates
+}
+
+// newSweepPath returns a sweep path with frames for a polyline.
+func newSweepPath(path []v3.Vec, frame SweepFrame) (*sweepPath, error) {
+	// remove repeated vertices
+	vertex := []v3.Vec{path[0]}
+	for _, v := range path[1:] {
+		if !v.Equals(vertex[len(vertex)-1], tolerance) {
+			vertex = append(vertex, v)
+		}
+	}
+	n := len(vertex)
+	if n < 2 {
+		return nil, ErrMsg("path length == 0")
+	}
+	s := sweepPath{vertex: vertex}
+	// segments
+	s.vector = make([]v3.Vec, n-1)
+	s.length = make([]float64, n-1)
+	for i := 0; i < n-1; i++ {
+		l := vertex[i+1].Sub(vertex[i])
+		s.length[i] = l.Length()
+		s.vector[i] = l.Normalize()
+		s.total += s.length[i]
+	}
+	// vertex tangents (the mitre plane normals)
+	tangent := make([]v3.Vec, n)
+	tangent[0] = s.vector[0]
+	tangent[n-1] = s.vector[n-2]
+	for i := 1; i < n-1; i++ {
+		t := s.vector[i-1].Add(s.vector[i])
+		if t.Length() < tolerance {
+			return nil, ErrMsg("path reverses direction")
+		}
+		tangent[i] = t.Normalize()
+	}
+	s.tangent = tangent
+	// segment clip planes, square ends at sharp turns
+	s.start = make([]v3.Vec, n-1)
+	s.end = make([]v3.Vec, n-1)
+	s.join = make([]*sweepJoin, n)
+	for i := 0; i < n-1; i++ {
+		s.start[i] = tangent[i]
+		s.end[i] = tangent[i+1]
+	}
+	for i := 1; i < n-1; i++ {
+		// the mitre length is 1/cos(theta/2) for a turn of theta
+		if tangent[i].Dot(s.vector[i-1])*sweepMitreLimit < 1 {
+			s.end[i-1] = s.vector[i-1]
+			s.start[i] = s.vector[i]
+			s.join[i] = &sweepJoin{}
+		}
+	}
+	// segment normals
+	switch frame {
+	case FrenetFrame:
+		vn := frenetNormals(tangent)
+		s.normal = make([]v3.Vec, n-1)
+		for i := range s.normal {
+			s.normal[i] = projectNormal(vn[i].Add(vn[i+1]), s.vector[i])
+		}
+	case RotationMinimizingFrame:
+		s.normal = rmfNormals(s.vector, tangent)
+	default:
+		return nil, ErrMsg("bad sweep frame")
+	}
+	// round joins
+	for i, j := range s.join {
+		if j == nil {
+			continue
+		}
+		u0, u1 := s.vector[i-1], s.vector[i]
+		w := u0.Cross(u1)
+		if w.Length() < tolerance {
+			return nil, ErrMsg("path reverses direction")
+		}
+		w = w.Normalize()
+		// outward normal to the incoming segment in the plane of the turn
+		o := w.Cross(u0)
+		if o.Dot(u1) > 0 {
+			o = o.Neg()
+		}
+		n := s.normal[i-1]
+		b := u0.Cross(n)
+		*j = sweepJoin{
+			u0: u0,
+			u1: u1,
+			w:  w,
+			o:  v2.Vec{o.Dot(n), o.Dot(b)},
+			z:  v2.Vec{w.Dot(n), w.Dot(b)},
+		}
+	}
+	return &s, nil
+}
+
+// initialNormal returns a normal to t such that a +z tangent maps the profile x-axis to x.
+func initialNormal(t v3.Vec) v3.Vec {
+	ref := v3.Vec{1, 0, 0}
+	if math.Abs(t.Dot(ref)) > 0.9 {
+		ref = v3.Vec{0, 1, 0}
+	}
+	return ref.Sub(t.MulScalar(ref.Dot(t))).Normalize()
+}
+
+// frenetNormals returns the principal normals for the path tangents.
+// Straight sections carry the normal from the adjacent curved section.
+func frenetNormals(tangent []v3.Vec) []v3.Vec {
+	n := len(tangent)
+	normal := make([]v3.Vec, n)
+	valid := make([]bool, n)
+	for i := range tangent {
+		var dt v3.Vec
+		switch {
+		case n == 2:
+			// a single segment has no curvature
+		case i == 0:
+			dt = tangent[1].Sub(tangent[0])
+		case i == n-1:
+			dt = tangent[n-1].Sub(tangent[n-2])
+		default:
+			dt = tangent[i+1].Sub(tangent[i-1])
+		}
+		// remove the tangential component
+		dt = dt.Sub(tangent[i].MulScalar(dt.Dot(tangent[i])))
+		if dt.Length() > tolerance {
+			normal[i] = dt.Normalize()
+			valid[i] = true
+		}
+	}
+	// fill in the straight sections
+	first := -1
+	for i := range valid {
+		if valid[i] {
+			first = i
+			break
+		}
+	}
+	if first < 0 {
+		// the whole path is straight
+		for i := range normal {
+			normal[i] = initialNormal(tangent[i])
+		}
+		return normal
+	}
+	for i := first - 1; i >= 0; i-- {
+		normal[i] = projectNormal(normal[i+1], tangent[i])
+	}
+	for i := first + 1; i < n; i++ {
+		if !valid[i] {
+			normal[i] = projectNormal(normal[i-1], tangent[i])
+		}
+	}
+	return normal
+}
+
+// projectNormal returns the component of n normal to the unit vector t.
+func projectNormal(n, t v3.Vec) v3.Vec {
+	x := n.Sub(t.MulScalar(n.Dot(t)))
+	if x.Length() < tolerance {
+		return initialNormal(t)
+	}
+	return x.Normalize()
+}
+
+// rmfNormals returns rotation minimizing frame normals for the path segments.
+// The frame is reflected in the mitre plane at each vertex. The reflection maps the
+// segment vector onto the (reversed) next segment vector, so no twist is added.
+func rmfNormals(vector, tangent []v3.Vec) []v3.Vec {
+	normal := make([]v3.Vec, len(vector))
+	normal[0] = initialNormal(vector[0])
+	for i := 1; i < len(vector); i++ {
+		t := tangent[i]
+		rn := normal[i-1].Sub(t.MulScalar(2 * t.Dot(normal[i-1])))
+		normal[i] = projectNormal(rn, vector[i])
+	}
+	return normal
+}
+
+// sweepPiece is a point evaluated against the extrusion for a path segment.
+type sweepPiece struct {
+	q    v2.Vec  // point in the profile plane
+	line float64 // lower bound from the distance to the segment line
+	c0   float64 // distance beyond the start mitre plane
+	c1   float64 // distance beyond the end mitre plane
+	a    float64 // profile distance
+	done bool    // has the profile distance been evaluated?
+}
+
+// piece returns the cheap parts of the evaluation of p against segment i.
+// r is the radius of a circle containing the profile.
+func (s *sweepPath) piece(i int, p v3.Vec, r float64) sweepPiece {
+	pa := p.Sub(s.vertex[i])
+	u := s.vector[i]
+	n := s.normal[i]
+	q := v2.Vec{pa.Dot(n), pa.Dot(u.Cross(n))}
+	return sweepPiece{
+		q:    q,
+		line: q.Length() - r,
+		c0:   -pa.Dot(s.start[i]),
+		c1:   p.Sub(s.vertex[i+1]).Dot(s.end[i]),
+	}
+}
+
+// evaluate returns the distance from p to the round join at vertex i,
+// or math.MaxFloat64 if it can't be closer than d.
+func (s *sweepPath) evaluate(i int, p v3.Vec, sdf SDF2, r, d float64) float64 {
+	j := s.join[i]
+	pa := p.Sub(s.vertex[i])
+	// the wedge outside the segment end planes
+	c := math.Max(-pa.Dot(j.u0), pa.Dot(j.u1))
+	if math.Max(pa.Length()-r, c) >= d {
+		return math.MaxFloat64
+	}
+	z := pa.Dot(j.w)
+	rho := pa.Sub(j.w.MulScalar(z)).Length()
+	q := j.o.MulScalar(rho).Add(j.z.MulScalar(z))
+	return math.Max(sdf.Evaluate(q), c)
+}
+
+// profile returns the profile distance for the piece.
+func (sp *sweepPiece) profile(sdf SDF2) float64 {
+	if !sp.done {
+		sp.a = sdf.Evaluate(sp.q)
+		sp.done = true
+	}
+	return sp.a
+}
+
+//-----------------------------------------------------------------------------
+
+// SweepSDF3 is an SDF2 profile swept along a 3d path.
+type SweepSDF3 struct {
+	sdf  SDF2       // swept profile
+	path *sweepPath // sweep path
+	r    float64    // radius of a circle containing the profile
+	bb   Box3       // bounding box
+}
+
+// Sweep3D sweeps an SDF2 profile along a polyline path.
+// Use BezierPath or CubicSplinePath to generate smooth paths.
+func Sweep3D(profile SDF2, path v3.VecSet, frame SweepFrame) (SDF3, error) {
+	if profile == nil {
+		return nil, ErrMsg("profile == nil")
+	}
+	if len(path) < 2 {
+		return nil, ErrMsg("path has < 2 points")
+	}
+	sp, err := newSweepPath(path, frame)
+	if err != nil {
+		return nil, err
+	}
+	s := SweepSDF3{
+		sdf:  profile,
+		path: sp,
+	}
+	// work out the bounding box
+	// The profile bounding box is contained within a circle about the path.
+	r := box2Radius(profile.BoundingBox())
+	s.r = r
+	// Each piece is within r of its segment extended past the mitre planes.
+	// Round joins are within r of the vertex.
+	var vset v3.VecSet
+	for i, u := range sp.vector {
+		e0 := r * mitreExtension(sp.start[i], u)
+		e1 := r * mitreExtension(sp.end[i], u)
+		vset = append(vset, sp.vertex[i].Sub(u.MulScalar(e0)), sp.vertex[i+1].Add(u.MulScalar(e1)))
+	}
+	s.bb = Box3{vset.Min().SubScalar(r), vset.Max().AddScalar(r)}
+	return &s, nil
+}
+
+// mitreExtension returns how far a unit radius extrusion along u extends
+// past a clip plane with unit normal t through the end of the segment.
+func mitreExtension(t, u v3.Vec) float64 {
+	c := math.Abs(t.Dot(u))
+	return math.Sqrt(1-c*c) / c
+}
+
+// Evaluate returns the minimum distance to a swept profile.
+func (s *SweepSDF3) Evaluate(p v3.Vec) float64 {
+	d := math.MaxFloat64
+	var prev sweepPiece
+	for i := range s.path.vector {
+		cur := s.path.piece(i, p, s.r)
+		// The extrusion for the segment clipped by the mitre (or end) planes.
+		// Skip it if the lower bound shows it can't be closer.
+		c := math.Max(cur.c0, cur.c1)
+		if math.Max(cur.line, c) < d {
+			d = math.Min(d, math.Max(cur.profile(s.sdf), c))
+		}
+		if s.path.join[i] != nil {
+			// The round join with the previous extrusion.
+			d = math.Min(d, s.path.evaluate(i, p, s.sdf, s.r, d))
+		} else if i > 0 {
+			// The intersection of this extrusion with the previous one.
+			// It is inside the sweep, and is not clipped at the shared mitre plane,
+			// so it gives the interior distance across the joint.
+			c = math.Max(prev.c0, cur.c1)
+			if math.Max(math.Max(prev.line, cur.line), c) < d {
+				a := math.Max(prev.profile(s.sdf), cur.profile(s.sdf))
+				d = math.Min(d, math.Max(a, c))
+			}
+		}
+		prev = cur
+	}
+	return d
+}
+
+// BoundingBox returns the bounding box for a swept profile.
+func (s *SweepSDF3) BoundingBox() Box3 {
+	return s.bb
+}
+
+//-----------------------------------------------------------------------------
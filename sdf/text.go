@@ -11,198 +11,374 @@ Convert a string and font specification into an SDF2
 package sdf
 
 import (
-	"io/ioutil"
+	"math"
+	"sort"
 	"strings"
 
 	v2 "github.com/gmlewis/sdfx/vec/v2"
-	"github.com/golang/freetype/truetype"
-	"golang.org/x/image/font"
-	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/font/sfnt"
 )
 
 //-----------------------------------------------------------------------------
 
-type align int
+// HAlign is the horizontal alignment of text.
+type HAlign int
 
+// Horizontal alignments.
 const (
-	lAlign align = iota // left hand side x = 0
-	rAlign              // right hand side x = 0
-	cAlign              // center x = 0
+	HAlignLeft   HAlign = iota // left hand side x = 0
+	HAlignRight                // right hand side x = 0
+	HAlignCenter               // center x = 0
+)
+
+// VAlign is the vertical alignment of text.
+type VAlign int
+
+// Vertical alignments.
+const (
+	VAlignCenter   VAlign = iota // center y = 0
+	VAlignTop                    // top of the first line (font ascent) y = 0
+	VAlignBaseline               // baseline of the first line y = 0
+	VAlignBottom                 // bottom of the last line (font descent) y = 0
 )
 
 // Text stores a UTF8 string and it's rendering parameters.
 type Text struct {
-	s      string
-	halign align
+	s             string
+	halign        HAlign
+	valign        VAlign
+	lineSpacing   float64 // line spacing as a multiple of the font line height
+	letterSpacing float64 // extra space between letters as a fraction of the text height
+	noKerning     bool    // disable font kerning
 }
 
 //-----------------------------------------------------------------------------
 
-// pToV2 converts a truetype point to a v2.Vec
-func pToV2(p truetype.Point) v2.Vec {
-	return v2.Vec{float64(p.X), float64(p.Y)}
+// textGlyph is a glyph positioned on a line of text.
+type textGlyph struct {
+	s SDF2    // glyph outline (font units)
+	x float64 // x position of the glyph origin
+	w float64 // advance width of the glyph
 }
 
-//-----------------------------------------------------------------------------
+// lineGlyphs returns the positioned glyphs and the advance width for a line of text.
+func lineGlyphs(f *Font, t *Text, l string) ([]textGlyph, float64, error) {
+	var b sfnt.Buffer
+	var gPrev fontGlyph
+	letterSpacing := t.letterSpacing * lineHeight(f)
+	xOfs := 0.0
 
-// glyphCurve returns the SDF2 for the n-th curve of the glyph
-func glyphCurve(g *truetype.GlyphBuf, n int) (SDF2, bool, error) {
-	// get the start and end point
-	start := 0
-	if n != 0 {
-		start = g.Ends[n-1]
-	}
-	end := g.Ends[n] - 1
-
-	// build a bezier curve from the points
-	// work out the cw/ccw direction
-	b := NewBezier()
-	sum := 0.0
-	offPrev := false
-	vPrev := pToV2(g.Points[end])
-
-	for i := start; i <= end; i++ {
-		p := g.Points[i]
-		v := pToV2(p)
-		// is the point off/on the curve?
-		off := p.Flags&1 == 0
-		// do we have an implicit on point?
-		if off && offPrev {
-			// implicit on point at the midpoint of the 2 off points
-			b.AddV2(v.Add(vPrev).MulScalar(0.5))
-		}
-		// add the point
-		x := b.AddV2(v)
-		if off {
-			x.Mid()
+	var gs []textGlyph
+
+	for j, r := range []rune(l) {
+		g, err := f.glyph(&b, r)
+		if err != nil {
+			return nil, 0, err
 		}
-		// accumulate the cw/ccw direction
-		sum += (v.X - vPrev.X) * (v.Y + vPrev.Y)
-		// next point...
-		vPrev = v
-		offPrev = off
-	}
-	b.Close()
 
-	p, err := b.Polygon()
-	if err != nil {
-		return nil, false, err
-	}
+		// get the glyph metrics
+		w, err := f.advance(&b, g)
+		if err != nil {
+			return nil, 0, err
+		}
 
-	s, err := Polygon2D(p.Vertices())
-	return s, sum > 0, err
-}
+		// apply kerning and letter spacing
+		if j != 0 {
+			if !t.noKerning {
+				k, err := f.kern(&b, gPrev, g)
+				if err != nil {
+					return nil, 0, err
+				}
+				xOfs += k
+			}
+			xOfs += letterSpacing
+		}
+		gPrev = g
 
-// glyphConvert returns the SDF2 for a glyph
-func glyphConvert(g *truetype.GlyphBuf) (SDF2, error) {
-	var s0 SDF2
-	for n := 0; n < len(g.Ends); n++ {
-		s1, cw, err := glyphCurve(g, n)
+		// load the glyph
+		s, err := f.outline(&b, g)
 		if err != nil {
-			return nil, err
+			return nil, 0, err
 		}
-		if cw {
-			s0 = Union2D(s0, s1)
-		} else {
-			s0 = Difference2D(s0, s1)
+		if s != nil {
+			gs = append(gs, textGlyph{s, xOfs, w})
 		}
+
+		xOfs += w
 	}
-	return s0, nil
+
+	return gs, xOfs, nil
+}
+
+// alignOffset returns the x offset of a line for the horizontal alignment.
+func (t *Text) alignOffset(width float64) float64 {
+	switch t.halign {
+	case HAlignRight:
+		return -width
+	case HAlignCenter:
+		return -width / 2.0
+	}
+	return 0
+}
+
+// baselineOffset returns the y offset of the first baseline for the vertical alignment.
+// The center alignment is handled by centering the bounding box of the text.
+func (t *Text) baselineOffset(f *Font, lines int) (float64, error) {
+	ascent, descent, err := fontMetrics(f)
+	if err != nil {
+		return 0, err
+	}
+	switch t.valign {
+	case VAlignTop:
+		return -ascent, nil
+	case VAlignBottom:
+		return descent + float64(lines-1)*t.lineSpacing*lineHeight(f), nil
+	}
+	return 0, nil
 }
 
 //-----------------------------------------------------------------------------
+// public api
 
-// lineSDF2 returns an SDF2 slice for a line of text
-func lineSDF2(f *truetype.Font, l string) ([]SDF2, float64, error) {
-	iPrev := truetype.Index(0)
-	scale := fixed.Int26_6(f.FUnitsPerEm())
-	xOfs := 0.0
+// NewText returns a text object (text and alignment).
+func NewText(s string) *Text {
+	return &Text{
+		s:           s,
+		halign:      HAlignCenter,
+		valign:      VAlignCenter,
+		lineSpacing: 1,
+	}
+}
 
-	var ss []SDF2
+// SetHAlign sets the horizontal alignment of the text (default HAlignCenter).
+func (t *Text) SetHAlign(a HAlign) {
+	t.halign = a
+}
 
-	for _, r := range l {
-		i := f.Index(r)
+// SetVAlign sets the vertical alignment of the text (default VAlignCenter).
+func (t *Text) SetVAlign(a VAlign) {
+	t.valign = a
+}
 
-		// get the glyph metrics
-		hm := f.HMetric(scale, i)
+// SetLineSpacing sets the line spacing as a multiple of the font line height (default 1).
+func (t *Text) SetLineSpacing(x float64) {
+	t.lineSpacing = x
+}
 
-		// apply kerning
-		k := f.Kern(scale, iPrev, i)
-		xOfs += float64(k)
-		iPrev = i
+// SetLetterSpacing sets the extra space between letters as a fraction of the text height (default 0).
+func (t *Text) SetLetterSpacing(x float64) {
+	t.letterSpacing = x
+}
 
-		// load the glyph
-		g := &truetype.GlyphBuf{}
-		err := g.Load(f, scale, i, font.HintingNone)
-		if err != nil {
-			return nil, 0, err
-		}
+// SetKerning enables or disables the use of the font kerning table (default enabled).
+func (t *Text) SetKerning(on bool) {
+	t.noKerning = !on
+}
 
-		s, err := glyphConvert(g)
+// TextSDF2 returns a sized SDF2 for a text object.
+// h is the line height of the text. With the default (center) alignments the
+// bounding box of the text is centered on the origin.
+func TextSDF2(f *Font, t *Text, h float64) (SDF2, error) {
+	lines := strings.Split(t.s, "\n")
+	ah := lineHeight(f)
+	yOfs, err := t.baselineOffset(f, len(lines))
+	if err != nil {
+		return nil, err
+	}
+
+	var ss []SDF2
+
+	for i := range lines {
+		gs, hlen, err := lineGlyphs(f, t, lines[i])
 		if err != nil {
-			return nil, 0, err
+			return nil, err
 		}
-		if s != nil {
-			s = Transform2D(s, Translate2d(v2.Vec{xOfs, 0}))
-			ss = append(ss, s)
+		xOfs := t.alignOffset(hlen)
+		for _, g := range gs {
+			ss = append(ss, Transform2D(g.s, Translate2d(v2.Vec{xOfs + g.x, yOfs})))
 		}
-
-		xOfs += float64(hm.AdvanceWidth)
+		yOfs -= t.lineSpacing * ah
 	}
 
-	return ss, xOfs, nil
+	s := Union2D(ss...)
+	if s == nil {
+		return nil, ErrMsg("no glyphs in text")
+	}
+	// center the bounding box as required
+	ofs := s.BoundingBox().Center().Neg()
+	if t.halign != HAlignCenter {
+		ofs.X = 0
+	}
+	if t.valign != VAlignCenter {
+		ofs.Y = 0
+	}
+	s = Transform2D(s, Translate2d(ofs))
+	return ScaleUniform2D(s, h/ah), nil
 }
 
 //-----------------------------------------------------------------------------
-// public api
+// Text on a path
+
+// TextPath is a curve that text can be laid out along.
+type TextPath interface {
+	// Length returns the length of the path.
+	Length() float64
+	// At returns the position and unit tangent at a distance along the path.
+	At(d float64) (v2.Vec, v2.Vec)
+}
 
-// NewText returns a text object (text and alignment).
-func NewText(s string) *Text {
-	return &Text{
-		s:      s,
-		halign: cAlign,
+// arcPath is a circular arc centered on the origin.
+type arcPath struct {
+	radius float64 // arc radius
+	start  float64 // start angle (radians)
+	sweep  float64 // sweep angle (radians), < 0 for clockwise
+}
+
+// ArcPath returns a circular arc centered on the origin for laying out text.
+// Text reads in the direction of the sweep, use a negative (clockwise) sweep for
+// text around the outside of the arc and a positive sweep for text on the inside.
+func ArcPath(radius, start, sweep float64) (TextPath, error) {
+	if radius <= 0 {
+		return nil, ErrMsg("radius <= 0")
 	}
+	if sweep == 0 {
+		return nil, ErrMsg("sweep == 0")
+	}
+	return &arcPath{radius, start, sweep}, nil
+}
+
+// Length returns the length of the arc.
+func (p *arcPath) Length() float64 {
+	return p.radius * math.Abs(p.sweep)
 }
 
-// LoadFont loads a truetype (*.ttf) font file.
-func LoadFont(fname string) (*truetype.Font, error) {
-	// read the font file
-	b, err := ioutil.ReadFile(fname)
+// At returns the position and unit tangent at a distance along the arc.
+func (p *arcPath) At(d float64) (v2.Vec, v2.Vec) {
+	k := 1.0
+	if p.sweep < 0 {
+		k = -1.0
+	}
+	a := p.start + k*d/p.radius
+	s, c := math.Sincos(a)
+	return v2.Vec{p.radius * c, p.radius * s}, v2.Vec{-k * s, k * c}
+}
+
+// splinePath is a cubic spline parameterized by distance.
+type splinePath struct {
+	spline *CubicSplineSDF2
+	t      []float64 // spline parameter
+	d      []float64 // distance along the spline at t
+}
+
+// SplinePath returns a cubic spline through a set of knots for laying out text.
+func SplinePath(knot []v2.Vec) (TextPath, error) {
+	s, err := CubicSpline2D(knot)
 	if err != nil {
 		return nil, err
 	}
-	return truetype.Parse(b)
+	p := splinePath{spline: s.(*CubicSplineSDF2)}
+	// tabulate the distance along the spline
+	const steps = 64 // per spline segment
+	n := steps * len(p.spline.spline)
+	p.t = make([]float64, n+1)
+	p.d = make([]float64, n+1)
+	prev := p.spline.f0(0)
+	for i := 1; i <= n; i++ {
+		t := float64(i) / steps
+		x := p.spline.f0(t)
+		p.t[i] = t
+		p.d[i] = p.d[i-1] + x.Sub(prev).Length()
+		prev = x
+	}
+	if p.d[n] == 0 {
+		return nil, ErrMsg("spline has zero length")
+	}
+	return &p, nil
 }
 
-// TextSDF2 returns a sized SDF2 for a text object.
-func TextSDF2(f *truetype.Font, t *Text, h float64) (SDF2, error) {
-	scale := fixed.Int26_6(f.FUnitsPerEm())
+// Length returns the length of the spline.
+func (p *splinePath) Length() float64 {
+	return p.d[len(p.d)-1]
+}
+
+// At returns the position and unit tangent at a distance along the spline.
+// The spline is extended along the end tangents for distances beyond the ends.
+func (p *splinePath) At(d float64) (v2.Vec, v2.Vec) {
+	n := len(p.d) - 1
+	if d <= 0 {
+		t := p.spline.f1(0).Normalize()
+		return p.spline.f0(0).Add(t.MulScalar(d)), t
+	}
+	if d >= p.d[n] {
+		tEnd := p.t[n]
+		t := p.spline.f1(tEnd).Normalize()
+		return p.spline.f0(tEnd).Add(t.MulScalar(d - p.d[n])), t
+	}
+	i := sort.SearchFloat64s(p.d, d)
+	// p.d[i-1] < d <= p.d[i]
+	k := (d - p.d[i-1]) / (p.d[i] - p.d[i-1])
+	t := p.t[i-1] + k*(p.t[i]-p.t[i-1])
+	return p.spline.f0(t), p.spline.f1(t).Normalize()
+}
+
+// TextPathSDF2 returns a sized SDF2 for a text object laid out along a path.
+// h is the line height of the text. The baseline of the first line follows the
+// path and the glyphs are upright relative to the left hand normal of the path.
+// The horizontal alignment positions the text at the start (left), end (right)
+// or middle (center) of the path. The vertical alignment offsets the lines from
+// the path, VAlignCenter centers the lines on the path.
+func TextPathSDF2(f *Font, t *Text, h float64, path TextPath) (SDF2, error) {
 	lines := strings.Split(t.s, "\n")
-	yOfs := 0.0
-	vm := f.VMetric(scale, f.Index('\n'))
-	ah := float64(vm.AdvanceHeight)
+	ah := lineHeight(f)
+	k := h / ah
+	yOfs, err := t.baselineOffset(f, len(lines))
+	if err != nil {
+		return nil, err
+	}
+	if t.valign == VAlignCenter {
+		// center the block of lines between the first ascent and the last descent
+		ascent, descent, err := fontMetrics(f)
+		if err != nil {
+			return nil, err
+		}
+		yOfs = 0.5 * (float64(len(lines)-1)*t.lineSpacing*ah + descent - ascent)
+	}
+
+	// anchor position along the path
+	anchor := 0.0
+	switch t.halign {
+	case HAlignRight:
+		anchor = path.Length()
+	case HAlignCenter:
+		anchor = 0.5 * path.Length()
+	}
 
 	var ss []SDF2
 
 	for i := range lines {
-		ssLine, hlen, err := lineSDF2(f, lines[i])
+		gs, hlen, err := lineGlyphs(f, t, lines[i])
 		if err != nil {
 			return nil, err
 		}
-		xOfs := 0.0
-		if t.halign == rAlign {
-			xOfs = -hlen
-		} else if t.halign == cAlign {
-			xOfs = -hlen / 2.0
-		}
-		for i := range ssLine {
-			ssLine[i] = Transform2D(ssLine[i], Translate2d(v2.Vec{xOfs, yOfs}))
+		xOfs := t.alignOffset(hlen)
+		for _, g := range gs {
+			// move the glyph so the middle of its baseline is at the origin
+			s := ScaleUniform2D(Transform2D(g.s, Translate2d(v2.Vec{-0.5 * g.w, 0})), k)
+			// place it on the path
+			p, tangent := path.At(anchor + (xOfs+g.x+0.5*g.w)*k)
+			normal := v2.Vec{-tangent.Y, tangent.X}
+			p = p.Add(normal.MulScalar(yOfs * k))
+			m := Translate2d(p).Mul(Rotate2d(math.Atan2(tangent.Y, tangent.X)))
+			ss = append(ss, Transform2D(s, m))
 		}
-		ss = append(ss, ssLine...)
-		yOfs -= ah
+		yOfs -= t.lineSpacing * ah
 	}
 
-	return CenterAndScale2D(Union2D(ss...), h/ah), nil
+	s := Union2D(ss...)
+	if s == nil {
+		return nil, ErrMsg("no glyphs in text")
+	}
+	return s, nil
 }
 
 //-----------------------------------------------------------------------------
@@ -9,6 +9,8 @@ Voxel-based cache/smoothing to remove deep SDF2/SDF3 hierarchies and speed up ev
 package sdf
 
 import (
+	"math"
+
 	"github.com/gmlewis/sdfx/vec/conv"
 	v3 "github.com/gmlewis/sdfx/vec/v3"
 	"github.com/gmlewis/sdfx/vec/v3i"
@@ -67,11 +69,18 @@ func NewVoxelSDF3(s SDF3, meshCells int, progress chan float64) SDF3 {
 
 // Evaluate returns the minimum distance to a VoxelSDF3.
 func (m *VoxelSDF3) Evaluate(p v3.Vec) float64 {
+	// Outside of the bounding box the distance to the bounding box is a lower bound.
+	q := p.Clamp(m.bb.Min, m.bb.Max)
+	dBox := sdfBox3d(p.Sub(m.bb.Center()), m.bb.Size().MulScalar(0.5))
 	// Find the voxel's {0,0,0} corner quickly and compute p's displacement
 	voxelSize := m.bb.Size().Div(conv.V3iToV3(m.numVoxels))
-	voxelStartIndex := conv.V3ToV3i(p.Sub(m.bb.Min).Div(voxelSize))
+	voxelStartIndex := conv.V3ToV3i(q.Sub(m.bb.Min).Div(voxelSize))
+	// the far faces of the bounding box belong to the last voxel
+	voxelStartIndex.X = min(voxelStartIndex.X, m.numVoxels.X-1)
+	voxelStartIndex.Y = min(voxelStartIndex.Y, m.numVoxels.Y-1)
+	voxelStartIndex.Z = min(voxelStartIndex.Z, m.numVoxels.Z-1)
 	voxelStart := m.bb.Min.Add(voxelSize.Mul(conv.V3iToV3(voxelStartIndex)))
-	d := p.Sub(voxelStart).Div(voxelSize) // [0, 1) for each dimension
+	d := q.Sub(voxelStart).Div(voxelSize) // [0, 1] for each dimension
 	// Get the values at the voxel's corners
 	c000 := m.voxelCorners[voxelStartIndex]
 	c001 := m.voxelCorners[voxelStartIndex.Add(v3i.Vec{0, 0, 1})]
@@ -92,7 +101,7 @@ func (m *VoxelSDF3) Evaluate(p v3.Vec) float64 {
 	c1 := c01*(1-d.Y) + c11*d.Y
 	// - 1 trilinear interpolation
 	c := c0*(1-d.Z) + c1*d.Z
-	return c
+	return math.Max(c, dBox)
 }
 
 // BoundingBox returns the bounding box for a VoxelSDF3.
@@ -0,0 +1,74 @@
+//-----------------------------------------------------------------------------
+/*
+
+meshcheck: validate triangle mesh files
+
+Reports open edges, non-manifold edges, inconsistent winding, degenerate
+triangles and self-intersections for STL, OBJ and 3MF files.
+
+usage: meshcheck file...
+
+The exit status is 1 if any of the files have problems.
+
+*/
+//-----------------------------------------------------------------------------
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/gmlewis/sdfx/obj"
+	"github.com/gmlewis/sdfx/render"
+)
+
+//-----------------------------------------------------------------------------
+
+// check validates a mesh file and returns true if it has no problems.
+func check(path string) (bool, error) {
+	tris, err := obj.LoadMesh(path)
+	if err != nil {
+		return false, err
+	}
+	// weld identical vertices, this removes triangles with repeated vertices
+	m := render.NewMeshFromTriangles(tris, 0)
+	welded := len(tris) - len(m.Triangle)
+	r := render.Validate(m)
+	fmt.Printf("%s\n", path)
+	if welded != 0 {
+		fmt.Printf("triangles with repeated vertices (removed): %d\n", welded)
+	}
+	fmt.Printf("%s", r)
+	return welded == 0 && r.OK(), nil
+}
+
+//-----------------------------------------------------------------------------
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s file...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	status := 0
+	for _, path := range flag.Args() {
+		ok, err := check(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", path, err)
+			status = 1
+			continue
+		}
+		if !ok {
+			status = 1
+		}
+	}
+	os.Exit(status)
+}
+
+//-----------------------------------------------------------------------------